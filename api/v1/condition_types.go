@@ -0,0 +1,223 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Reasons set on the Ready condition of a Konfiguration when reconciliation
+// fails. These are stable and may be relied upon by external automation.
+const (
+	// InvalidSpecReason represents the fact that the spec of the Konfiguration
+	// is invalid, e.g. it passes flags to kubecfg that are not allowed.
+	InvalidSpecReason string = "InvalidSpec"
+
+	// ArtifactFailedReason represents the fact that the source artifact could
+	// not be resolved, downloaded or extracted.
+	ArtifactFailedReason string = "ArtifactFailed"
+
+	// ArtifactQuarantinedReason represents the fact that the source revision
+	// is quarantined because its artifact could not be extracted safely.
+	ArtifactQuarantinedReason string = "ArtifactQuarantined"
+
+	// EvaluationFailedReason represents the fact that the manifests could not
+	// be rendered or compared against the cluster.
+	EvaluationFailedReason string = "EvaluationFailed"
+
+	// VariablesUnresolvedReason represents the fact that external variables
+	// or top-level arguments of the Konfiguration could not be resolved, or
+	// that the manifests require variables that are not given.
+	VariablesUnresolvedReason string = "VariablesUnresolved"
+
+	// LintFailedReason represents the fact that the Jsonnet sources have lint
+	// findings and linting is enforced.
+	LintFailedReason string = "LintFailed"
+
+	// ValidationFailedReason represents the fact that the dry-run apply of the
+	// manifests failed.
+	ValidationFailedReason string = "ValidationFailed"
+
+	// ApplyFailedReason represents the fact that the manifests could not be
+	// applied to the cluster.
+	ApplyFailedReason string = "ApplyFailed"
+
+	// FieldManagerConflictReason represents the fact that the manifests set
+	// fields of live objects owned by a field manager that is not allowed to
+	// be overridden.
+	FieldManagerConflictReason string = "FieldManagerConflict"
+
+	// VerificationFailedReason represents the fact that applied objects could
+	// not be read back from the cluster.
+	VerificationFailedReason string = "VerificationFailed"
+
+	// HealthCheckFailedReason represents the fact that the applied objects did
+	// not become healthy.
+	HealthCheckFailedReason string = "HealthCheckFailed"
+
+	// TestFailedReason represents the fact that a test Job run after apply
+	// failed or did not finish in time.
+	TestFailedReason string = "TestFailed"
+
+	// HookFailedReason represents the fact that a hook Job failed or did not
+	// finish in time.
+	HookFailedReason string = "HookFailed"
+
+	// PruneFailedReason represents the fact that garbage collection of objects
+	// no longer rendered failed.
+	PruneFailedReason string = "PruneFailed"
+
+	// PreconditionNotMetReason represents the fact that a precondition of the
+	// Konfiguration is not met.
+	PreconditionNotMetReason string = "PreconditionNotMet"
+
+	// DeletionBlockedReason represents the fact that a deleted Konfiguration
+	// waits for its managed objects to be gone.
+	DeletionBlockedReason string = "DeletionBlocked"
+
+	// EvaluationTraceReason represents the fact that the evaluation of the
+	// manifests produced std.trace output.
+	EvaluationTraceReason string = "EvaluationTrace"
+
+	// DependencyNotReadyReason represents the fact that a Konfiguration listed
+	// in DependsOn is not ready.
+	DependencyNotReadyReason string = "DependencyNotReady"
+
+	// QuotaExceededReason represents the fact that applying the manifests
+	// would exceed a quota of the namespace of the Konfiguration.
+	QuotaExceededReason string = "QuotaExceeded"
+
+	// UnschedulableReason represents the fact that the pods of rendered
+	// workloads cannot be scheduled on any node of the cluster.
+	UnschedulableReason string = "Unschedulable"
+
+	// ObserveOnlyReason represents the fact that the manifests were rendered
+	// and compared with the cluster, but not applied.
+	ObserveOnlyReason string = "ObserveOnly"
+)
+
+const (
+	// HibernatingCondition reports whether the workloads of a Konfiguration
+	// are scaled to zero by a hibernation window.
+	HibernatingCondition string = "Hibernating"
+
+	// HibernationActiveReason represents the fact that a hibernation window
+	// is active.
+	HibernationActiveReason string = "HibernationActive"
+
+	// HibernationInactiveReason represents the fact that no hibernation window
+	// is active.
+	HibernationInactiveReason string = "HibernationInactive"
+)
+
+const (
+	// LintedCondition reports whether the Jsonnet sources of a Konfiguration
+	// passed linting.
+	LintedCondition string = "Linted"
+
+	// LintPassedReason represents the fact that linting found no problems.
+	LintPassedReason string = "LintPassed"
+
+	// LintFindingsReason represents the fact that linting found problems.
+	LintFindingsReason string = "LintFindings"
+)
+
+const (
+	// VariablesUnresolvedCondition reports whether variables of a
+	// Konfiguration could not be resolved, naming them in its message.
+	VariablesUnresolvedCondition string = "VariablesUnresolved"
+
+	// MissingVariablesReason represents the fact that variables are missing,
+	// or their values could not be read from their files, secret providers or
+	// sealed values.
+	MissingVariablesReason string = "MissingVariables"
+)
+
+const (
+	// CircuitOpenCondition reports whether reconciliation of a Konfiguration
+	// is paused by its circuit breaker.
+	CircuitOpenCondition string = "CircuitOpen"
+
+	// ConsecutiveFailuresReason represents the fact that the circuit breaker
+	// opened after too many consecutive failed applies.
+	ConsecutiveFailuresReason string = "ConsecutiveFailures"
+
+	// CircuitClosedReason represents the fact that the circuit breaker closed
+	// after a successful reconciliation.
+	CircuitClosedReason string = "CircuitClosed"
+)
+
+const (
+	// QuarantinedCondition reports whether a source revision of a
+	// Konfiguration is quarantined because its artifact is corrupt, or holds
+	// entries escaping the extraction directory or links.
+	QuarantinedCondition string = "Quarantined"
+
+	// UnsafeArtifactReason represents the fact that the artifact of a source
+	// revision failed inspection before extraction.
+	UnsafeArtifactReason string = "UnsafeArtifact"
+)
+
+const (
+	// DriftedCondition reports whether the cluster differs from the manifests
+	// of a Konfiguration in observe-only mode.
+	DriftedCondition string = "Drifted"
+
+	// ChangesRequiredReason represents the fact that applying the manifests
+	// would change the cluster.
+	ChangesRequiredReason string = "ChangesRequired"
+
+	// InSyncReason represents the fact that the cluster matches the manifests.
+	InSyncReason string = "InSync"
+)
+
+// Reasons of the events recorded by a controller in shadow mode.
+const (
+	// ShadowDivergedReason represents the fact that the shadow controller
+	// rendered other manifests than the active controller applied, or failed
+	// to render the revision the active controller applied.
+	ShadowDivergedReason string = "ShadowDiverged"
+)
+
+const (
+	// ChildrenReadyCondition reports whether the Konfigurations selected as
+	// children of a Konfiguration are ready.
+	ChildrenReadyCondition string = "ChildrenReady"
+
+	// AllChildrenReadyReason represents the fact that every child of the
+	// Konfiguration is ready.
+	AllChildrenReadyReason string = "AllChildrenReady"
+
+	// ChildrenNotReadyReason represents the fact that some children of the
+	// Konfiguration are not ready.
+	ChildrenNotReadyReason string = "ChildrenNotReady"
+)
+
+// Reasons set on the Ready condition of a KonfigurationInstance.
+const (
+	// TemplateNotFoundReason represents the fact that the KonfigurationTemplate
+	// of the instance does not exist.
+	TemplateNotFoundReason string = "TemplateNotFound"
+
+	// InvalidParametersReason represents the fact that the parameters of the
+	// instance do not match the schema of its template.
+	InvalidParametersReason string = "InvalidParameters"
+
+	// KonfigurationConflictReason represents the fact that a Konfiguration
+	// of the name of the instance exists and is not controlled by it.
+	KonfigurationConflictReason string = "KonfigurationConflict"
+
+	// KonfigurationPendingReason represents the fact that the Konfiguration of
+	// the instance has not reported its readiness yet.
+	KonfigurationPendingReason string = "KonfigurationPending"
+)
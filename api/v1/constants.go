@@ -17,10 +17,235 @@ limitations under the License.
 package v1
 
 const (
+	// KonfigurationKind is the kind of the Konfiguration API.
+	KonfigurationKind string = "Konfiguration"
+
 	// GitRepositoryIndexKey is the key used for indexing kustomizations
 	// based on their Git sources.
 	GitRepositoryIndexKey string = ".metadata.gitRepository"
 	// BucketIndexKey is the key used for indexing kustomizations
 	// based on their S3 sources.
 	BucketIndexKey string = ".metadata.bucket"
+	// DependsOnIndexKey is the key used for indexing Konfigurations based on
+	// the Konfigurations they depend on.
+	DependsOnIndexKey string = ".metadata.dependsOn"
+	// GCTagIndexKey is the key used for indexing Konfigurations based on the
+	// tag they mark the applied objects with for garbage collection.
+	GCTagIndexKey string = ".spec.gcTag"
+
+	// KonfigurationFinalizer is set on Konfigurations whose managed objects
+	// are removed when they are deleted.
+	KonfigurationFinalizer string = "finalizers.apps.kubecfg.io"
+
+	// PreviousManifestsExtVar is the external variable holding the objects
+	// applied by the previous revision of a Konfiguration.
+	PreviousManifestsExtVar string = "kubecfg_operator_previous"
+
+	// RevisionExtVar is the external variable holding the source revision
+	// being rendered.
+	RevisionExtVar string = "kubecfg.io/revision"
+	// KonfigurationNameExtVar is the external variable holding the name of
+	// the Konfiguration being rendered.
+	KonfigurationNameExtVar string = "kubecfg.io/konfigurationName"
+	// NamespaceExtVar is the external variable holding the namespace of the
+	// Konfiguration being rendered.
+	NamespaceExtVar string = "kubecfg.io/namespace"
+	// ReconcileTimeExtVar is the external variable holding the time of the
+	// reconciliation, when enabled.
+	ReconcileTimeExtVar string = "kubecfg.io/reconcileTime"
+
+	// OwnershipLabelDomain is the default domain of the labels used to track
+	// objects managed by the controller on behalf of a Konfiguration.
+	OwnershipLabelDomain string = "apps.kubecfg.io"
+	// KonfigurationNameLabelName is the name of the ownership label holding
+	// the name of the owning Konfiguration.
+	KonfigurationNameLabelName string = "konfiguration-name"
+	// KonfigurationNamespaceLabelName is the name of the ownership label
+	// holding the namespace of the owning Konfiguration.
+	KonfigurationNamespaceLabelName string = "konfiguration-namespace"
+	// KonfigurationNameLabel is the label used to track objects managed by
+	// the controller on behalf of a Konfiguration, under the default domain.
+	KonfigurationNameLabel string = OwnershipLabelDomain + "/" + KonfigurationNameLabelName
+	// KonfigurationNamespaceLabel is the label used alongside
+	// KonfigurationNameLabel to track the namespace of the owning Konfiguration.
+	KonfigurationNamespaceLabel string = OwnershipLabelDomain + "/" + KonfigurationNamespaceLabelName
+	// KonfigurationAnnotation is set on applied objects, when enabled, to the
+	// namespace and name of the Konfiguration applying them, so cluster audit
+	// logs can attribute changes to it.
+	KonfigurationAnnotation string = "apps.kubecfg.io/konfiguration"
+	// ManifestsNextAnnotation names the Secret holding the next part of the
+	// stored manifests of a Konfiguration.
+	ManifestsNextAnnotation string = "apps.kubecfg.io/manifests-next"
+	// ManifestsChecksumAnnotation records the checksum of the stored manifests
+	// on the first Secret of their chain.
+	ManifestsChecksumAnnotation string = "apps.kubecfg.io/manifests-checksum"
+	// InventoryNextAnnotation names the ConfigMap holding the next part of an
+	// inventory too large to be stored in a single ConfigMap.
+	InventoryNextAnnotation string = "apps.kubecfg.io/inventory-next"
+	// InventoryBackupAnnotation is set on Konfigurations to the name of a
+	// ConfigMap of their namespace to keep a copy of their inventory in. The
+	// copy is not owned by the Konfiguration, so it survives the deletion of
+	// the Konfiguration or of its CustomResourceDefinition, and is read as the
+	// inventory of a Konfiguration of the same name that has none, e.g. after
+	// migrating to a fresh installation of the controller.
+	InventoryBackupAnnotation string = "apps.kubecfg.io/inventory-backup"
+	// TargetInventoryAnnotation is set on the Secrets recording the objects
+	// applied to a target of a Konfiguration to the name of the target.
+	TargetInventoryAnnotation string = "apps.kubecfg.io/target"
+	// TargetServerAnnotation is set on the Secrets recording the objects
+	// applied to a target of a Konfiguration to the server of the target
+	// cluster.
+	TargetServerAnnotation string = "apps.kubecfg.io/target-server"
+	// AllowDerivedSourcesAnnotation is set on GitRepositories to the
+	// comma-separated namespaces, or "*" for any, whose Konfigurations may
+	// have the controller copy the GitRepository with another Git reference,
+	// reusing its credentials. Konfigurations of the namespace of the
+	// GitRepository are always allowed to.
+	AllowDerivedSourcesAnnotation string = "apps.kubecfg.io/allow-derived-sources"
+	// EventRevisionAnnotation is set on the events of a Konfiguration to the
+	// source revision it reconciled.
+	EventRevisionAnnotation string = "apps.kubecfg.io/revision"
+	// EventCommitURLAnnotation is set on the events of a Konfiguration to the
+	// web URL of the commit it reconciled, when known.
+	EventCommitURLAnnotation string = "apps.kubecfg.io/commit-url"
+	// CordonedLabel is set to "true" on objects no longer rendered that are
+	// kept until the prune grace period of their Konfiguration expires.
+	CordonedLabel string = "apps.kubecfg.io/cordoned"
+	// CordonedAtAnnotation records when an object was cordoned.
+	CordonedAtAnnotation string = "apps.kubecfg.io/cordoned-at"
+	// CordonedReplicasAnnotation records the replicas of a workload before it
+	// was cordoned and scaled to zero, to restore them if it is rendered again.
+	CordonedReplicasAnnotation string = "apps.kubecfg.io/cordoned-replicas"
+	// CordonedGCStrategyAnnotation records the garbage collection strategy of
+	// an object before it was cordoned and protected from kubecfg garbage
+	// collection, to restore it if it is rendered again.
+	CordonedGCStrategyAnnotation string = "apps.kubecfg.io/cordoned-gc-strategy"
+	// ProtectedAnnotation marks cluster-scoped objects the controller
+	// protected from kubecfg garbage collection because the Konfiguration did
+	// not allow pruning them, so the protection is lifted once it does.
+	ProtectedAnnotation string = "apps.kubecfg.io/protected"
+	// PreviewLabel is the label set on namespaces created by the controller for
+	// Konfigurations in preview mode.
+	PreviewLabel string = "apps.kubecfg.io/preview"
+	// PreviewBranchAnnotation records the source branch a preview namespace
+	// was created for.
+	PreviewBranchAnnotation string = "apps.kubecfg.io/preview-branch"
+	// PodTemplateHashAnnotation is the annotation set on pod templates holding
+	// a hash of the ConfigMaps and Secrets they reference.
+	PodTemplateHashAnnotation string = "apps.kubecfg.io/config-hash"
+	// PausedAnnotation is set to "true" on live objects to have the controller
+	// leave them as they are in the cluster, e.g. while they are hot-fixed
+	// during an incident. Paused objects are still tracked in the inventory
+	// and not garbage collected.
+	PausedAnnotation string = "kubecfg.io/paused"
+	// LogLevelAnnotation is set to "debug" on a Konfiguration to log its
+	// reconciliations at the debug level, regardless of the log level of the
+	// controller.
+	LogLevelAnnotation string = "kubecfg.io/log-level"
+	// TestHookLabel marks the Jobs in the rendered manifests that are run as
+	// tests after apply when test hooks are enabled.
+	TestHookLabel string = "apps.kubecfg.io/test"
+	// HookLabel is set on the Jobs run by hooks to the stage they run at.
+	HookLabel string = "apps.kubecfg.io/hook"
+	// TemplateLabel is set on the Konfigurations created for
+	// KonfigurationInstances to the name of their KonfigurationTemplate.
+	TemplateLabel string = "apps.kubecfg.io/template"
+	// MaxConcurrentReconcilesAnnotation is set on namespaces to override the
+	// number of Konfigurations of the namespace the controller reconciles at
+	// the same time.
+	MaxConcurrentReconcilesAnnotation string = "apps.kubecfg.io/max-concurrent-reconciles"
+	// APIQPSAnnotation is set on namespaces to override the rate of API
+	// requests per second the controller makes while reconciling the
+	// Konfigurations of the namespace, including those kubecfg makes to diff
+	// and apply the manifests. It does not limit some reads and writes such as
+	// those of the inventories.
+	APIQPSAnnotation string = "apps.kubecfg.io/api-qps"
+	// MaxObjectsAnnotation is set on namespaces to override the number of
+	// objects the Konfigurations of the namespace may manage in total.
+	MaxObjectsAnnotation string = "apps.kubecfg.io/max-objects"
+	// MaxClusterScopedObjectsAnnotation is set on namespaces to override the
+	// number of cluster-scoped objects the Konfigurations of the namespace may
+	// manage in total.
+	MaxClusterScopedObjectsAnnotation string = "apps.kubecfg.io/max-cluster-scoped-objects"
+	// MaxTargetsAnnotation is set on namespaces to override the number of
+	// target clusters the Konfigurations of the namespace may apply to in
+	// total.
+	MaxTargetsAnnotation string = "apps.kubecfg.io/max-targets"
+	// SecretProvidersAnnotation is set on namespaces to the comma-separated
+	// names of the secret providers the Konfigurations of the namespace may
+	// resolve variables from.
+	SecretProvidersAnnotation string = "apps.kubecfg.io/secret-providers"
+)
+
+const (
+	// SuspendPolicyRetain leaves the objects managed by a suspended
+	// Konfiguration in place.
+	SuspendPolicyRetain string = "retain"
+	// SuspendPolicyPrune removes the objects managed by a suspended
+	// Konfiguration.
+	SuspendPolicyPrune string = "prune"
+)
+
+const (
+	// DeletionPolicyOrphan leaves the objects managed by a deleted
+	// Konfiguration in place.
+	DeletionPolicyOrphan string = "Orphan"
+	// DeletionPolicyDelete removes the objects managed by a deleted
+	// Konfiguration.
+	DeletionPolicyDelete string = "Delete"
+	// DeletionPolicyWaitForDependents removes the objects managed by a
+	// deleted Konfiguration and waits for them to be gone.
+	DeletionPolicyWaitForDependents string = "WaitForDependents"
+)
+
+const (
+	// HealthChecksScopeAll checks every applied object after apply.
+	HealthChecksScopeAll string = "all"
+	// HealthChecksScopeChanged checks only the applied objects that changed
+	// since the last successful reconciliation.
+	HealthChecksScopeChanged string = "changed"
+)
+
+const (
+	// HookStagePreRender runs hooks before the manifests are rendered.
+	HookStagePreRender string = "pre-render"
+	// HookStagePreApply runs hooks once the manifests are rendered, before
+	// they are applied.
+	HookStagePreApply string = "pre-apply"
+	// HookStagePostApply runs hooks once the manifests are applied and
+	// checked, before any test Jobs.
+	HookStagePostApply string = "post-apply"
+)
+
+const (
+	// HookFailurePolicyFail fails the reconciliation if a hook fails.
+	HookFailurePolicyFail string = "Fail"
+	// HookFailurePolicyWarn records a warning if a hook fails, and carries on
+	// with the reconciliation.
+	HookFailurePolicyWarn string = "Warn"
+)
+
+const (
+	// HealthCheckServiceEndpoints waits for applied Services with a selector
+	// to have ready endpoints, and for LoadBalancer Services to be assigned
+	// an address.
+	HealthCheckServiceEndpoints HealthCheck = "ServiceEndpoints"
+	// HealthCheckIngressAddress waits for applied Ingresses to be assigned an
+	// address.
+	HealthCheckIngressAddress HealthCheck = "IngressAddress"
+)
+
+const (
+	// FeatureGateServerSideApply applies the manifests with server-side
+	// apply, as the kubecfg-operator field manager, instead of kubecfg.
+	// Objects no longer rendered are garbage collected from the inventory.
+	FeatureGateServerSideApply string = "ServerSideApply"
+	// FeatureGateInProcessEvaluation evaluates the Jsonnet manifests in the
+	// controller rather than with kubecfg show. Only local libraries are
+	// importable, and images cannot be resolved.
+	FeatureGateInProcessEvaluation string = "InProcessEvaluation"
+	// FeatureGateIncrementalApply applies only the objects whose digest
+	// changed since the previous inventory. Objects no longer rendered are
+	// garbage collected from the inventory rather than by kubecfg.
+	FeatureGateIncrementalApply string = "IncrementalApply"
 )
@@ -33,4 +33,13 @@ var (
 
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
+
+	// SchemeGroupVersion is the group version the generated clients use.
+	SchemeGroupVersion = GroupVersion
 )
+
+// Resource takes an unqualified resource and returns a group qualified
+// GroupResource, as expected by the generated listers.
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
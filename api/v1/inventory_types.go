@@ -0,0 +1,95 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// InventoryKey is the key of the ConfigMap referenced by
+// status.inventoryRef holding the JSON encoded Inventory.
+const InventoryKey string = "inventory.json"
+
+// InventoryCompressedKey is the key of the ConfigMaps of the chain starting at
+// status.inventoryRef holding a part of the gzip-compressed JSON encoded
+// Inventory, when it is too large to be stored under InventoryKey.
+const InventoryCompressedKey string = "inventory.json.gz"
+
+// ManifestsKey is the key of the Secrets of the chain starting at
+// status.manifestsRef holding a part of the gzip-compressed manifests.
+const ManifestsKey string = "manifests.yaml.gz"
+
+// Inventory lists the objects applied by a Konfiguration. It is stored in the
+// ConfigMaps referenced by the inventoryRef of the Konfiguration status, so
+// that tools can look up the managed objects and query their state without
+// rendering the manifests. It also records the health of the objects, read
+// back at every reconciliation.
+type Inventory struct {
+	// Revision is the source revision the objects were rendered from.
+	Revision string `json:"revision"`
+
+	// Checksum is the checksum of the applied manifests.
+	Checksum string `json:"checksum"`
+
+	// Entries are the applied objects, in the order they were rendered.
+	Entries []InventoryEntry `json:"entries"`
+
+	// Digests are the checksums of the applied objects, in the order of
+	// Entries.
+	// +optional
+	Digests []string `json:"digests,omitempty"`
+
+	// Health is the health of the applied objects as of the last
+	// reconciliation, in the order of Entries.
+	// +optional
+	Health []ObjectHealth `json:"health,omitempty"`
+}
+
+// InventoryEntry identifies an object applied by a Konfiguration.
+type InventoryEntry struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+const (
+	// HealthCurrent is the health of an object whose status reports it is
+	// fully reconciled, or which has no status to report.
+	HealthCurrent string = "Current"
+
+	// HealthInProgress is the health of an object still being reconciled by
+	// its controller, such as a Deployment rolling out.
+	HealthInProgress string = "InProgress"
+
+	// HealthFailed is the health of an object whose status reports an error,
+	// such as a failed Job.
+	HealthFailed string = "Failed"
+
+	// HealthNotFound is the health of an object that no longer exists.
+	HealthNotFound string = "NotFound"
+
+	// HealthUnknown is the health of an object that could not be read.
+	HealthUnknown string = "Unknown"
+)
+
+// ObjectHealth is the health of an object applied by a Konfiguration.
+type ObjectHealth struct {
+	// Status is one of Current, InProgress, Failed, NotFound or Unknown.
+	Status string `json:"status"`
+
+	// Message explains the status, if the object is not Current.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
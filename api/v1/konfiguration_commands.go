@@ -16,10 +16,20 @@ limitations under the License.
 
 package v1
 
-import "fmt"
-
 func (k *Konfiguration) newArgs(cmd string) []string {
-	args := []string{cmd, "--cache-dir", "/cache", "--namespace", k.GetNamespace()}
+	args := []string{cmd, "--cache-dir", "/cache", "--namespace", k.GetTargetNamespace()}
+
+	if opts := k.Spec.Kubecfg; opts != nil {
+		if opts.ResolveImages != "" {
+			args = append(args, "--resolve-images", opts.ResolveImages)
+		}
+		if opts.ResolveImagesError != "" {
+			args = append(args, "--resolve-images-error", opts.ResolveImagesError)
+		}
+		for i := int32(0); i < opts.Verbosity; i++ {
+			args = append(args, "--verbose")
+		}
+	}
 
 	// Add any global arguments provided by the user.
 	if globalArgs := k.GetKubecfgArgs(); len(globalArgs) != 0 {
@@ -30,14 +40,17 @@ func (k *Konfiguration) newArgs(cmd string) []string {
 }
 
 // ToUpdateArgs converts this Konfiguration schema into kubecfg update
-// arguments.
-func (k *Konfiguration) ToUpdateArgs(path string, dryRun bool) []string {
+// arguments. With skipGC the applied objects are still tagged for garbage
+// collection, but kubecfg does not remove the others.
+func (k *Konfiguration) ToUpdateArgs(path string, dryRun, skipGC bool) []string {
 	args := k.newArgs("update")
 
 	// Check if we are adding garbage collection flags.
 	if k.GCEnabled() {
-		gcTag := fmt.Sprintf("%s_%s", k.GetNamespace(), k.GetName())
-		args = append(args, []string{"--gc-tag", gcTag}...)
+		args = append(args, []string{"--gc-tag", k.GetGCTag()}...)
+		if skipGC {
+			args = append(args, "--skip-gc")
+		}
 	}
 
 	// Check if disabling validation.
@@ -45,6 +58,10 @@ func (k *Konfiguration) ToUpdateArgs(path string, dryRun bool) []string {
 		args = append(args, "--validate=false")
 	}
 
+	if opts := k.Spec.Kubecfg; opts != nil && opts.IgnoreUnknown {
+		args = append(args, "--ignore-unknown")
+	}
+
 	// Check if defining external or top-level arguments.
 	if vars := k.GetVariables(); vars != nil {
 		args = vars.AppendToArgs(args)
@@ -73,3 +90,21 @@ func (k *Konfiguration) ToDiffArgs(path string) []string {
 	args = append(args, path)
 	return args
 }
+
+// ToShowArgs converts this Konfiguration schema into kubecfg show arguments
+// that render the manifests as YAML. Libraries in libDirs are importable by
+// the manifests.
+func (k *Konfiguration) ToShowArgs(libDirs []string, path string) []string {
+	args := k.newArgs("show")
+	for _, libDir := range libDirs {
+		args = append(args, []string{"--jpath", libDir}...)
+	}
+	// Check if defining external or top-level arguments.
+	if vars := k.GetVariables(); vars != nil {
+		args = vars.AppendToArgs(args)
+	}
+	args = append(args, []string{"--format", "yaml"}...)
+	// Finally add the paths
+	args = append(args, path)
+	return args
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKubecfgOptionsArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *KubecfgOptions
+		want []string
+	}{
+		{name: "no options", want: []string{"show", "--cache-dir", "/cache", "--namespace", "team", "--format", "yaml", "main.jsonnet"}},
+		{
+			name: "resolving images",
+			opts: &KubecfgOptions{ResolveImages: "registry", ResolveImagesError: "warn"},
+			want: []string{"show", "--cache-dir", "/cache", "--namespace", "team", "--resolve-images", "registry", "--resolve-images-error", "warn", "--format", "yaml", "main.jsonnet"},
+		},
+		{
+			name: "verbosity",
+			opts: &KubecfgOptions{Verbosity: 2},
+			want: []string{"show", "--cache-dir", "/cache", "--namespace", "team", "--verbose", "--verbose", "--format", "yaml", "main.jsonnet"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.SetNamespace("team")
+			k.Spec.Kubecfg = tt.opts
+			if got := k.ToShowArgs(nil, "main.jsonnet"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToShowArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
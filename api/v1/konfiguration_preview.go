@@ -0,0 +1,52 @@
+/*
+Copyright 2021 Pelotech.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var invalidNamespaceChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// maxNamespaceLength is the maximum length of a DNS-1123 label.
+const maxNamespaceLength = 63
+
+// GetPreviewBranch returns the branch encoded in a Git source revision of the
+// form <branch>/<commit-sha>.
+func GetPreviewBranch(revision string) (string, error) {
+	idx := strings.LastIndex(revision, "/")
+	if idx <= 0 {
+		return "", fmt.Errorf("revision '%s' does not reference a branch", revision)
+	}
+	return revision[:idx], nil
+}
+
+// GetPreviewNamespace returns the name of the namespace the Konfiguration
+// should be rendered into for the given branch when preview mode is enabled.
+func (k *Konfiguration) GetPreviewNamespace(branch string) string {
+	prefix := k.GetName()
+	if k.Spec.Preview != nil && k.Spec.Preview.NamespacePrefix != "" {
+		prefix = k.Spec.Preview.NamespacePrefix
+	}
+	name := invalidNamespaceChars.ReplaceAllString(strings.ToLower(fmt.Sprintf("%s-%s", prefix, branch)), "-")
+	if len(name) > maxNamespaceLength {
+		name = name[:maxNamespaceLength]
+	}
+	return strings.Trim(name, "-")
+}
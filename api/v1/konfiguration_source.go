@@ -18,7 +18,10 @@ package v1
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"regexp"
+	"strings"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
@@ -26,6 +29,86 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// commitPattern matches full Git commit SHAs.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// GetPinnedRevision returns the revision the Konfiguration is pinned to, if
+// any.
+func (k *Konfiguration) GetPinnedRevision() string { return k.Spec.PinnedRevision }
+
+// GetRevisionSelector returns the selector for the source revisions the
+// Konfiguration follows, if any.
+func (k *Konfiguration) GetRevisionSelector() *RevisionSelector { return k.Spec.RevisionSelector }
+
+// UsesDerivedSource returns true if the controller manages a copy of the
+// referenced GitRepository for the Konfiguration, because its source reference
+// overrides the Git ref, or it is pinned to or selects a revision.
+func (k *Konfiguration) UsesDerivedSource() bool {
+	sref := k.GetSourceRef()
+	return sref != nil && (sref.Ref != nil || k.GetPinnedRevision() != "" || k.GetRevisionSelector() != nil)
+}
+
+// GetSourceGitRef returns the Git reference the GitRepository managed for the
+// Konfiguration should track, given the reference of the upstream
+// GitRepository. A pinned revision takes precedence over the revision selector,
+// and a commit pin keeps the branch of the reference it replaces,
+// since it is checked out from that branch.
+func (k *Konfiguration) GetSourceGitRef(upstream *sourcev1.GitRepositoryRef) *sourcev1.GitRepositoryRef {
+	ref := upstream
+	if sref := k.GetSourceRef(); sref != nil && sref.Ref != nil {
+		ref = sref.Ref
+	}
+	pin := k.GetPinnedRevision()
+	if pin == "" {
+		if sel := k.GetRevisionSelector(); sel != nil {
+			return &sourcev1.GitRepositoryRef{SemVer: sel.SemVer}
+		}
+		return ref.DeepCopy()
+	}
+	pinned := &sourcev1.GitRepositoryRef{}
+	if commitPattern.MatchString(pin) {
+		if ref != nil {
+			pinned.Branch = ref.Branch
+		}
+		pinned.Commit = pin
+	} else {
+		pinned.Tag = pin
+	}
+	return pinned
+}
+
+// MatchesPinnedRevision returns true if the given artifact revision, in the
+// form <branch|tag>/<commit-sha>, is the revision the Konfiguration is pinned
+// to. It always returns true if the Konfiguration is not pinned.
+func (k *Konfiguration) MatchesPinnedRevision(revision string) bool {
+	pin := k.GetPinnedRevision()
+	if pin == "" {
+		return true
+	}
+	idx := strings.LastIndex(revision, "/")
+	if idx < 0 {
+		return false
+	}
+	if commitPattern.MatchString(pin) {
+		return revision[idx+1:] == pin
+	}
+	return revision[:idx] == pin
+}
+
+// GetDerivedSourceName returns the name of the GitRepository the controller
+// manages for the given Konfiguration when its source reference overrides the
+// Git ref. It lives in the same namespace as the referenced GitRepository. The
+// name ends with a hash of the namespace and name of the Konfiguration, so
+// names joined with dashes cannot collide.
+func (k *Konfiguration) GetDerivedSourceName() string {
+	sref := k.GetSourceRef()
+	hash := sha256.Sum256([]byte(k.GetNamespace() + "/" + k.GetName()))
+	if sref.Namespace == k.GetNamespace() {
+		return fmt.Sprintf("%s-%s-%x", sref.Name, k.GetName(), hash[:4])
+	}
+	return fmt.Sprintf("%s-%s-%s-%x", sref.Name, k.GetNamespace(), k.GetName(), hash[:4])
+}
+
 func (sref *CrossNamespaceSourceReference) GetSource(ctx context.Context, c client.Client) (sourcev1.Source, error) {
 	var source sourcev1.Source
 	namespacedName := types.NamespacedName{
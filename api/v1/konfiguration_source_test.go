@@ -0,0 +1,41 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetDerivedSourceName(t *testing.T) {
+	konfiguration := func(namespace, name, sourceNamespace string) *Konfiguration {
+		k := &Konfiguration{}
+		k.SetNamespace(namespace)
+		k.SetName(name)
+		k.Spec.SourceRef = &CrossNamespaceSourceReference{Kind: "GitRepository", Name: "repo", Namespace: sourceNamespace}
+		return k
+	}
+	// Joined with dashes, both would be named repo-a-b-c
+	a := konfiguration("a-b", "c", "flux-system").GetDerivedSourceName()
+	b := konfiguration("a", "b-c", "flux-system").GetDerivedSourceName()
+	if a == b {
+		t.Errorf("GetDerivedSourceName() = %q for both Konfigurations", a)
+	}
+	if got := konfiguration("team", "app", "team").GetDerivedSourceName(); !strings.HasPrefix(got, "repo-app-") {
+		t.Errorf("GetDerivedSourceName() = %q, want prefix %q", got, "repo-app-")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Flux authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+Copyright 2021 Avi Zimmerman - Apache License, Version 2.0.
+  - Adaption from fluxcd/kustomize-controller for kubecfg
+*/
+
+package v1
+
+import (
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxConditionMessageLength is the maximum length of a condition message.
+const MaxConditionMessageLength = 20000
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (k *Konfiguration) GetStatusConditions() *[]metav1.Condition {
+	return &k.Status.Conditions
+}
+
+// KonfigurationProgressing resets the conditions of the given Konfiguration to
+// a single ReadyCondition with status ConditionUnknown.
+func KonfigurationProgressing(k Konfiguration) Konfiguration {
+	k = *k.DeepCopy()
+	meta.SetResourceCondition(&k, meta.ReadyCondition, metav1.ConditionUnknown, meta.ProgressingReason, "reconciliation in progress")
+	return k
+}
+
+// KonfigurationWaiting registers that the given Konfiguration waits on
+// something outside of it, such as its dependencies, before it can be applied.
+// The Ready condition is set to ConditionUnknown and the Reconciling condition
+// to ConditionTrue with the given reason, since the wait is not a failure.
+func KonfigurationWaiting(k Konfiguration, revision, reason, message string) Konfiguration {
+	k = *k.DeepCopy()
+	message = trimString(message, MaxConditionMessageLength)
+	meta.SetResourceCondition(&k, meta.ReadyCondition, metav1.ConditionUnknown, reason, message)
+	meta.SetResourceCondition(&k, meta.ReconcilingCondition, metav1.ConditionTrue, reason, message)
+	k.Status.ObservedGeneration = k.GetGeneration()
+	if revision != "" {
+		k.Status.LastAttemptedRevision = revision
+	}
+	return k
+}
+
+// KonfigurationReady registers a successful apply attempt of the given
+// Konfiguration.
+func KonfigurationReady(k Konfiguration, revision, reason, message string) Konfiguration {
+	k = *k.DeepCopy()
+	meta.SetResourceCondition(&k, meta.ReadyCondition, metav1.ConditionTrue, reason, trimString(message, MaxConditionMessageLength))
+	apimeta.RemoveStatusCondition(&k.Status.Conditions, meta.ReconcilingCondition)
+	k.Status.ObservedGeneration = k.GetGeneration()
+	k.Status.LastAppliedRevision = revision
+	k.Status.LastAttemptedRevision = revision
+	return k
+}
+
+// KonfigurationNotReady registers a failed apply attempt of the given
+// Konfiguration.
+func KonfigurationNotReady(k Konfiguration, revision, reason, message string) Konfiguration {
+	k = *k.DeepCopy()
+	meta.SetResourceCondition(&k, meta.ReadyCondition, metav1.ConditionFalse, reason, trimString(message, MaxConditionMessageLength))
+	apimeta.RemoveStatusCondition(&k.Status.Conditions, meta.ReconcilingCondition)
+	k.Status.ObservedGeneration = k.GetGeneration()
+	if revision != "" {
+		k.Status.LastAttemptedRevision = revision
+	}
+	return k
+}
+
+func trimString(str string, limit int) string {
+	if len(str) <= limit {
+		return str
+	}
+	return str[0:limit] + "..."
+}
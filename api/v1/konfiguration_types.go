@@ -18,6 +18,7 @@ package v1
 
 import (
 	"github.com/fluxcd/pkg/runtime/dependency"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -26,61 +27,303 @@ import (
 
 // KonfigurationSpec defines the desired state of Konfiguration
 type KonfigurationSpec struct {
-	// DependsOn may contain a dependency.CrossNamespaceDependencyReference slice
-	// with references to Konfiguration resources that must be ready before this
-	// Konfiguration can be reconciled.
-	// NOTE: This is not yet implemented.
+	// DependsOn may contain references to Konfiguration resources that must be
+	// ready before this Konfiguration can be reconciled, optionally at a given
+	// revision. While any of them is not ready, the Konfiguration waits with
+	// the DependencyNotReady reason and is checked again at an interval set by
+	// the controller.
 	// +optional
-	DependsOn []dependency.CrossNamespaceDependencyReference `json:"dependsOn,omitempty"`
+	DependsOn []DependencyReference `json:"dependsOn,omitempty"`
 
-	// The interval at which to reconcile the Konfiguration.
+	// Children selects, by label, the Konfigurations in the same namespace
+	// whose readiness is rolled up into the ChildrenReady condition of this
+	// Konfiguration. A Konfiguration is a child if it matches any of the
+	// selectors. While any child is not ready, this Konfiguration is not
+	// ready either, even if its own manifests were applied.
+	// +optional
+	Children []metav1.LabelSelector `json:"children,omitempty"`
+
+	// The interval at which to reconcile the Konfiguration. An interval of
+	// zero reconciles the Konfiguration only when its source or spec
+	// changes, a reconciliation is requested or a dependency is applied, so
+	// drift is not corrected in between. Conditions that raise no events,
+	// such as the labels of the namespace, are then checked at the retry
+	// interval. Cannot be zero with an artifactSource.
 	// +required
 	Interval metav1.Duration `json:"interval"`
 
 	// The interval at which to retry a previously failed reconciliation.
 	// When not specified, the controller uses the KonfigurationSpec.Interval
-	// value to retry failures.
+	// value to retry failures, or one minute if the interval is zero.
 	// +optional
 	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
 
-	// The KubeConfig for reconciling the Konfiguration on a remote cluster.
-	// Defaults to the in-cluster configuration.
+	// AdaptiveInterval lengthens the interval at which the Konfiguration is
+	// reconciled while its source does not change, doubling it from Interval
+	// with every reconciliation finding the same revision, up to a maximum.
+	// It is reset to Interval as soon as a new revision is applied.
+	// +optional
+	AdaptiveInterval *AdaptiveInterval `json:"adaptiveInterval,omitempty"`
+
+	// KubeConfig is ignored, since the Konfiguration is always reconciled on
+	// the cluster the controller runs in. Setting it is rejected, except on
+	// Konfigurations that already set it. Set Targets to apply the manifests
+	// to remote clusters instead.
+	// Deprecated: Use Targets instead.
 	// +optional
 	KubeConfig *KubeConfig `json:"kubeConfig,omitempty"`
 
+	// Targets applies the manifests to each of the given clusters instead of
+	// the cluster the controller runs in. The manifests are validated against
+	// every target before they are applied to any of them.
+	// +optional
+	Targets []Target `json:"targets,omitempty"`
+
+	// VerifyApplied reads back every applied object, retrying until it is
+	// observable or the timeout expires, before the Konfiguration is marked
+	// ready. This catches clusters that are slow to reflect writes, such as
+	// remote clusters with eventually consistent aggregated APIs.
+	// +optional
+	VerifyApplied bool `json:"verifyApplied,omitempty"`
+
+	// ValidateOnAdmission has the validating webhook evaluate the entrypoint
+	// against the artifact currently served by the source when the
+	// Konfiguration is created or updated, rejecting it if the evaluation
+	// fails. The Konfiguration is admitted with a warning if no artifact is
+	// available, or if the evaluation takes longer than 7s or renders more
+	// than 8MiB. Requires the webhooks to be enabled.
+	// +optional
+	ValidateOnAdmission bool `json:"validateOnAdmission,omitempty"`
+
+	// HealthChecks wait for the applied objects to be serving, retrying until
+	// they are or the timeout expires, before the Konfiguration is marked
+	// ready. `ServiceEndpoints` waits for Services with a selector to have
+	// ready endpoints, and for LoadBalancer Services to be assigned an
+	// address. `IngressAddress` waits for Ingresses to be assigned an address.
+	// +optional
+	HealthChecks []HealthCheck `json:"healthChecks,omitempty"`
+
+	// HealthChecksScope selects the objects read back by VerifyApplied and
+	// checked by HealthChecks. With `changed`, only the objects whose rendered
+	// manifests changed since the last successful reconciliation are checked,
+	// so small changes do not wait on unrelated objects. Defaults to `all`.
+	// +kubebuilder:validation:Enum=changed;all
+	// +optional
+	HealthChecksScope string `json:"healthChecksScope,omitempty"`
+
 	// Path to the jsonnet, json, or yaml that should be applied to the cluster.
 	// Defaults to 'None', which translates to the root path of the SourceRef.
 	// When declared as a file path it is assumed to be from the root path of the SourceRef.
 	// You may also define a HTTP(S) link to fetch files from a remote location.
+	// YAML and JSON files may contain multiple documents and are applied as-is
+	// without being evaluated. If the path is a directory, the entrypoint is
+	// looked up in it as set by Entrypoints.
 	// +required
 	Path string `json:"path"`
 
+	// Entrypoints are the file names looked up, in order of preference, when
+	// Path is a directory. If none of them exists, the only Jsonnet file of
+	// the directory is used. Defaults to main.jsonnet, kube.jsonnet and
+	// index.jsonnet.
+	// +optional
+	Entrypoints []string `json:"entrypoints,omitempty"`
+
+	// YAMLStream allows the entrypoint to evaluate to a string holding a
+	// stream of YAML documents, such as the output of std.manifestYamlStream,
+	// which is parsed back into the objects to apply. The entrypoint must be
+	// a local file, and top-level arguments must be identifiers.
+	// +optional
+	YAMLStream bool `json:"yamlStream,omitempty"`
+
 	// Variables to use when invoking kubecfg to render manifests.
 	// +optional
 	Variables *Variables `json:"variables,omitempty"`
 
+	// JsonnetLibRefs are ConfigMaps in the namespace of the Konfiguration
+	// holding Jsonnet libraries, one per key, to add to the library search
+	// path when evaluating the manifests. This allows distributing shared
+	// libraries in-cluster rather than vendoring them into every source.
+	// +optional
+	JsonnetLibRefs []corev1.LocalObjectReference `json:"jsonnetLibRefs,omitempty"`
+
+	// Lint checks the Jsonnet entrypoint with jsonnet-lint, which reports
+	// problems such as unused variables, and for canonical formatting before
+	// it is evaluated. The formatting of the files it imports can be checked
+	// as well.
+	// +optional
+	Lint *Lint `json:"lint,omitempty"`
+
 	// Reference of the source where the jsonnet, json, or yaml file(s) are.
 	// NOTE: This is not finished yet, and only http(s) URLs in the `paths`
 	// field are supported.
 	// +optional
 	SourceRef *CrossNamespaceSourceReference `json:"sourceRef"`
 
+	// ArtifactSource fetches the sources of the manifests without Flux, e.g.
+	// in air-gapped clusters without source-controller. Path is relative to
+	// the root of the artifact. Cannot be set along with SourceRef.
+	// +optional
+	ArtifactSource *ArtifactSource `json:"artifactSource,omitempty"`
+
+	// SparsePaths limits the extraction of the source artifact to the given
+	// files and directories, relative to its root, along with the file at
+	// Path. Paths outside of them, such as the rest of a large monorepo, are
+	// skipped rather than written to disk. Imported libraries and variable
+	// files must be included.
+	// +optional
+	SparsePaths []string `json:"sparsePaths,omitempty"`
+
+	// PinnedRevision holds the Konfiguration at a full Git commit SHA or a tag
+	// (e.g. a semver release) of its GitRepository source. The controller keeps
+	// applying the pinned revision, correcting any drift, and does not advance
+	// when the source publishes newer revisions.
+	// +optional
+	PinnedRevision string `json:"pinnedRevision,omitempty"`
+
+	// RevisionSelector makes the Konfiguration follow the releases of its
+	// GitRepository source that match a selector, rather than the head of the
+	// tracked branch. Ignored when PinnedRevision is set.
+	// +optional
+	RevisionSelector *RevisionSelector `json:"revisionSelector,omitempty"`
+
 	// Prune enables garbage collection. Note that this makes commands take
 	// considerably longer, so you may want to adjust your timeouts accordingly.
+	// Since it is a boolean, garbage collection is configured in PruneOptions,
+	// e.g. the propagation policy in `pruneOptions.propagationPolicy`.
 	// +required
 	Prune bool `json:"prune"`
 
+	// GCTag is the tag the applied objects are marked with for garbage
+	// collection, defaulting to `<namespace>_<name>` of the Konfiguration.
+	// Set it to the `--gc-tag` objects were previously applied with by
+	// `kubecfg update`, so they are pruned by the Konfiguration rather than
+	// orphaned. Changing it orphans the objects applied with the previous
+	// tag. A tag already used by a Konfiguration created earlier, in any
+	// namespace, is rejected, so objects are never pruned by two
+	// Konfigurations.
+	// +optional
+	GCTag string `json:"gcTag,omitempty"`
+
+	// PruneOptions configures garbage collection when Prune is enabled.
+	// +optional
+	PruneOptions *PruneOptions `json:"pruneOptions,omitempty"`
+
+	// Deletes lists objects that must not exist, so cleanups can be expressed
+	// in the source rather than with manual deletions. They are deleted after
+	// the manifests are applied, with the propagation policies of
+	// PruneOptions, and are left in place while the Konfiguration renders
+	// them, if they were not applied by this Konfiguration or if they are
+	// protected from garbage collection. Cluster-scoped objects are only
+	// deleted if PruneOptions allow pruning them. Ignored when targets are
+	// set.
+	// +optional
+	Deletes []ObjectDeletion `json:"deletes,omitempty"`
+
 	// This flag tells the controller to suspend subsequent kubecfg executions,
 	// it does not apply to already started executions. Defaults to false.
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// SuspendPolicy controls what happens to the objects managed by the
+	// Konfiguration while it is suspended. `retain` leaves them in place,
+	// while `prune` removes them, and they are applied again when the
+	// Konfiguration is resumed. Pruning requires Prune to be enabled, and
+	// cluster-scoped objects are only removed if PruneOptions allow it.
+	// Defaults to `retain`.
+	// +kubebuilder:default:=retain
+	// +kubebuilder:validation:Enum=retain;prune
+	// +optional
+	SuspendPolicy string `json:"suspendPolicy,omitempty"`
+
+	// ObserveOnly renders the manifests, compares them with the cluster and
+	// runs the health checks, but never writes to the cluster. Nothing is
+	// applied, pruned or deleted, hooks and tests are not run, and the managed
+	// objects are left in place on deletion. The outcome is reported on the
+	// Drifted condition. Targets are compared with their clusters, without
+	// running the health checks. Derived sources are not created or updated,
+	// so a source whose ref is overridden is only observed once the copy of
+	// it matches.
+	// +optional
+	ObserveOnly bool `json:"observeOnly,omitempty"`
+
+	// DeletionPolicy controls what happens to the objects managed by the
+	// Konfiguration when it is deleted. `Orphan` leaves them in place.
+	// `Delete` removes them before the Konfiguration is deleted, without
+	// waiting for them to be gone. `WaitForDependents` keeps the Konfiguration
+	// until they are gone, including any finalizers of their own, listing the
+	// remaining objects in status.remainingObjects. Removing the objects
+	// requires Prune to be enabled. Defaults to `Orphan`.
+	// +kubebuilder:default:=Orphan
+	// +kubebuilder:validation:Enum=Orphan;Delete;WaitForDependents
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// Hibernation scales the Deployments and StatefulSets rendered by the
+	// Konfiguration to zero during scheduled windows, e.g. outside office
+	// hours, and restores them afterwards.
+	// +optional
+	Hibernation *Hibernation `json:"hibernation,omitempty"`
+
+	// CircuitBreaker pauses reconciliation after repeated failures to apply
+	// the manifests, to avoid a continuous stream of failing writes against
+	// the cluster.
+	// +optional
+	CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// Preconditions are checked before the manifests are rendered and
+	// applied, e.g. that a database is reachable. While any of them is not
+	// met the Konfiguration is not applied, so workloads that would crash
+	// loop are held back, and it is retried at the retry interval. They are
+	// checked within the timeout of the Konfiguration, and are only available
+	// when enabled on the controller.
+	// +optional
+	Preconditions []Precondition `json:"preconditions,omitempty"`
+
+	// PreRender renders the manifests of new revisions while their apply is
+	// held back by unmet Preconditions, and keeps them, so they are applied
+	// without rendering as soon as the preconditions are met. Modifications
+	// depending on the cluster, such as leaving replica counts to
+	// HorizontalPodAutoscalers, are still made at apply time. Ignored when
+	// targets or `pre-render` hooks are set, since hooks only run when the
+	// manifests are applied.
+	// +optional
+	PreRender bool `json:"preRender,omitempty"`
+
 	// Timeout for diff, validation, apply, and (soon) health checking operations.
-	// Defaults to 'Interval' duration.
+	// Defaults to 'Interval' duration, or five minutes if the interval is zero.
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
 
-	// Additional global arguments to pass to kubecfg invocations.
+	// Kubecfg configures the kubecfg invocations.
+	// +optional
+	Kubecfg *KubecfgOptions `json:"kubecfg,omitempty"`
+
+	// Apply configures how the rendered manifests are applied.
+	// +optional
+	Apply *ApplyOptions `json:"apply,omitempty"`
+
+	// FeatureGates enable experimental behaviors for this Konfiguration only.
+	// `ServerSideApply` applies the manifests with server-side apply instead
+	// of kubecfg, forcing the ownership of fields conflicting with kubecfg or
+	// the field managers of `apply.overrideManagers`.
+	// `InProcessEvaluation` evaluates the Jsonnet manifests in the controller
+	// instead of with kubecfg. Only local libraries are importable, and
+	// `kubecfg.resolveImages` cannot be `registry`.
+	// `IncrementalApply` applies only the objects whose digest changed since
+	// the previous inventory, so drift of the other objects is not corrected,
+	// and prunes only the objects of the previous inventory.
+	// Targets are always applied in full with kubecfg. Unknown gates are
+	// rejected.
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// Additional global arguments to pass to kubecfg invocations. Only the
+	// flags `--ext-str`, `--ext-code`, `--tla-str`, `--tla-code`,
+	// `--ignore-unknown`, `--resolve-images`, `--resolve-images-error` and
+	// `--verbose` are accepted.
+	// Deprecated: Use Variables and Kubecfg instead, which cover every
+	// accepted flag. The namespace and garbage collection tag kubecfg
+	// operates on are set by the controller.
 	// +optional
 	KubecfgArgs []string `json:"kubecfgArgs,omitempty"`
 
@@ -97,6 +340,103 @@ type KonfigurationSpec struct {
 	// +optional
 	DiffStrategy string `json:"diffStrategy,omitempty"`
 
+	// IgnoreHPAReplicas leaves the replica count of rendered Deployments and
+	// StatefulSets targeted by a HorizontalPodAutoscaler to the autoscaler.
+	// The replicas field is dropped from new objects and kept at its live value
+	// for existing ones. Defaults to true.
+	// +kubebuilder:default:=true
+	// +optional
+	IgnoreHPAReplicas bool `json:"ignoreHPAReplicas,omitempty"`
+
+	// PodTemplateHash annotates the pod templates of rendered workloads with a
+	// hash of the ConfigMaps and Secrets they reference that are rendered by
+	// this Konfiguration, so changes to them trigger a rollout.
+	// +optional
+	PodTemplateHash bool `json:"podTemplateHash,omitempty"`
+
+	// PermissionsCheck fails the reconciliation before the manifests are
+	// applied if the identity kubecfg applies them with, the controller or the
+	// kubeconfig of a target, is not allowed to get, create and patch the
+	// rendered objects, or to list and delete them with prune enabled. Each
+	// verb is reviewed once per kind and namespace of the rendered objects, on
+	// every reconciliation.
+	// +optional
+	PermissionsCheck bool `json:"permissionsCheck,omitempty"`
+
+	// SchedulingCheck fails the reconciliation before the manifests are
+	// applied if the pods of a rendered workload cannot be scheduled on any
+	// uncordoned node of the cluster they are applied to, because of their
+	// node selector, required node affinity, or the taints of the nodes they
+	// do not tolerate. The resources of the nodes are not taken into account.
+	// Not available when the controller runs namespace-scoped, unless the
+	// manifests are applied to targets.
+	// +optional
+	SchedulingCheck bool `json:"schedulingCheck,omitempty"`
+
+	// Transformers mutate the rendered objects, in order, before they are
+	// applied, e.g. to inject sidecars or enforce security settings.
+	// +optional
+	Transformers []Transformer `json:"transformers,omitempty"`
+
+	// SkipKinds lists kinds that are never applied, even if rendered, e.g.
+	// because they are managed by another process. Kinds are given as `Kind`,
+	// or as `Kind.group` to match a single API group. The skipped objects are
+	// listed in status.skippedObjects. Objects of skipped kinds applied before
+	// are garbage collected like any other object that is no longer rendered.
+	// +optional
+	SkipKinds []string `json:"skipKinds,omitempty"`
+
+	// SkipCRDs never applies rendered CustomResourceDefinitions, as if listed
+	// in SkipKinds.
+	// +optional
+	SkipCRDs bool `json:"skipCRDs,omitempty"`
+
+	// PreviousManifests makes the objects applied by the previous revision, as
+	// they were rendered, available to the manifests through
+	// `prevManifest(kind, name)` of `kubecfg-operator.libsonnet`, e.g. to keep
+	// a field until a migration is cut over. The manifests are kept in the
+	// Secrets of StoreManifests. Not supported for targets.
+	// +optional
+	PreviousManifests bool `json:"previousManifests,omitempty"`
+
+	// ReconcileTimeVar sets the `kubecfg.io/reconcileTime` external variable
+	// to the RFC 3339 time of the reconciliation, rather than an empty string.
+	// Manifests using it change on every reconciliation and are applied every
+	// interval.
+	// +optional
+	ReconcileTimeVar bool `json:"reconcileTimeVar,omitempty"`
+
+	// StoreManifests keeps a gzip-compressed copy of the manifests applied by
+	// the last successful reconciliation in a chain of Secrets referenced by
+	// status.manifestsRef, so tools can diff them against the live objects
+	// without rendering them again.
+	// +optional
+	StoreManifests bool `json:"storeManifests,omitempty"`
+
+	// Preview configures rendering the Konfiguration into an ephemeral
+	// namespace per source branch.
+	// +optional
+	Preview *Preview `json:"preview,omitempty"`
+
+	// EventSink configures an HTTP endpoint that reconciliation events for this
+	// Konfiguration are posted to, in addition to any configured on the
+	// controller.
+	// +optional
+	EventSink *EventSink `json:"eventSink,omitempty"`
+
+	// TestHooks configures running the test Jobs in the rendered manifests
+	// after they are applied.
+	// +optional
+	TestHooks *TestHooks `json:"testHooks,omitempty"`
+
+	// Hooks run Jobs at stages of the reconciliation, e.g. to migrate a
+	// database schema before the manifests are applied or to flush a cache
+	// after. Hooks of a stage run in order, in the namespace of the
+	// Konfiguration. A hook that succeeded is not run again until the
+	// revision or the rendered manifests change. Ignored when targets are set.
+	// +optional
+	Hooks []Hook `json:"hooks,omitempty"`
+
 	// Force instructs the controller to recreate resources
 	// when patching fails due to an immutable field change.
 	// +kubebuilder:default:=false
@@ -104,6 +444,23 @@ type KonfigurationSpec struct {
 	// Force bool `json:"force,omitempty"`
 }
 
+// DependencyReference is a reference to a Konfiguration that must be ready
+// before the Konfiguration depending on it can be reconciled.
+type DependencyReference struct {
+	dependency.CrossNamespaceDependencyReference `json:",inline"`
+
+	// Revision is the source revision the dependency must have last applied.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// SameRevisionAs requires the dependency to have last applied the source
+	// revision this Konfiguration is about to apply, so Konfigurations
+	// rendered from the same source apply each revision in dependency order.
+	// It cannot be set along with revision.
+	// +optional
+	SameRevisionAs bool `json:"sameRevisionAs,omitempty"`
+}
+
 // KubeConfig holds the configuration for where to fetch the contents of a
 // kubeconfig file.
 type KubeConfig struct {
@@ -114,9 +471,552 @@ type KubeConfig struct {
 	// is regularly updated if credentials such as a cloud-access-token expire.
 	// Cloud specific `cmd-path` auth helpers will not function without adding
 	// binaries and credentials to the Pod that is responsible for reconciling
-	// the Konfiguration.
-	// +required
+	// the Konfiguration. Required unless InClusterWithOverrides is set.
+	// +optional
 	SecretRef corev1.LocalObjectReference `json:"secretRef,omitempty"`
+	// Context selects the context of the kubeconfig to use. Defaults to the
+	// current-context of the kubeconfig.
+	// +optional
+	Context string `json:"context,omitempty"`
+	// InClusterWithOverrides builds the kubeconfig for the cluster the
+	// controller runs in, reached through another API server endpoint, e.g.
+	// a gateway in front of the cluster per tenant. SecretRef and Context are
+	// ignored when it is set.
+	// +optional
+	InClusterWithOverrides *InClusterOverrides `json:"inClusterWithOverrides,omitempty"`
+	// ProxyURL is the URL of the proxy to reach the API server through, for
+	// clusters only reachable through a bastion. The http, https and socks5
+	// schemes are supported. It overrides the proxy-url of the cluster of the
+	// selected context, which is honored otherwise.
+	// +kubebuilder:validation:Pattern="^(http|https|socks5)://.*$"
+	// +optional
+	ProxyURL string `json:"proxyURL,omitempty"`
+}
+
+// InClusterOverrides overrides the endpoint and credentials of the in-cluster
+// configuration.
+type InClusterOverrides struct {
+	// Server is the URL of the API server endpoint. Defaults to the in-cluster
+	// endpoint.
+	// +optional
+	Server string `json:"server,omitempty"`
+
+	// CASecretRef references a Secret holding the CA bundle of the endpoint
+	// under the 'ca.crt' key. Defaults to the CA of the in-cluster
+	// configuration.
+	// +optional
+	CASecretRef *corev1.LocalObjectReference `json:"caSecretRef,omitempty"`
+
+	// TokenSecretRef references a Secret holding the bearer token to
+	// authenticate with under the 'token' key, such as a service account
+	// token Secret. It must be in the same namespace as the Konfiguration.
+	// +required
+	TokenSecretRef corev1.LocalObjectReference `json:"tokenSecretRef"`
+}
+
+// Target is a cluster the manifests of a Konfiguration are applied to.
+type Target struct {
+	// Name identifies the target in the status of the Konfiguration.
+	// +required
+	Name string `json:"name"`
+
+	// KubeConfig for the target cluster.
+	// +required
+	KubeConfig KubeConfig `json:"kubeConfig"`
+
+	// Variables override the variables of the Konfiguration when rendering
+	// the manifests for this target.
+	// +optional
+	Variables *Variables `json:"variables,omitempty"`
+}
+
+// PruneOptions configures garbage collection for a Konfiguration.
+type PruneOptions struct {
+	// ClusterScoped allows garbage collection of cluster-scoped objects such
+	// as ClusterRoles and Namespaces. When false, rendered cluster-scoped
+	// objects are annotated to be ignored by garbage collection, and marked
+	// with `apps.kubecfg.io/protected` so the protection is lifted once it is
+	// true. Defaults to false.
+	// +optional
+	ClusterScoped bool `json:"clusterScoped,omitempty"`
+
+	// CustomResourceDefinitions additionally allows garbage collection of
+	// CustomResourceDefinitions, which deletes all custom resources of their
+	// kind. Only takes effect when ClusterScoped is true. Defaults to false.
+	// +optional
+	CustomResourceDefinitions bool `json:"customResourceDefinitions,omitempty"`
+
+	// PropagationPolicy sets the deletion propagation policy of pruned
+	// objects per kind, e.g. `Orphan` for StatefulSets to keep the
+	// PersistentVolumeClaims they own. Kinds not listed are deleted in the
+	// foreground, so their dependents are removed before them.
+	// +optional
+	PropagationPolicy []PropagationPolicy `json:"propagationPolicy,omitempty"`
+
+	// RemovedTargets deletes the objects applied to a target cluster once the
+	// target is removed from the Konfiguration, like stale objects are pruned.
+	// The objects of each target and the reference to its kubeconfig are
+	// recorded when its manifests are applied, and the kubeconfig Secret of a
+	// removed target must be kept until it is pruned. Objects applied to the
+	// same cluster by a current target, e.g. after renaming a target, are left
+	// in place. Defaults to false, leaving the objects of removed targets in
+	// place.
+	// +optional
+	RemovedTargets bool `json:"removedTargets,omitempty"`
+
+	// GracePeriod delays the deletion of the objects no longer rendered,
+	// giving a window to recover from their accidental removal. The objects
+	// are cordoned first, by labeling them `apps.kubecfg.io/cordoned`,
+	// protecting them from garbage collection and, for Deployments,
+	// StatefulSets and ReplicaSets, scaling them to zero. They are deleted
+	// once the grace period expires if they are still not rendered, and
+	// restored if they are rendered again before then. Removing all the
+	// objects, e.g. on deletion, deletes them right away, and targets are
+	// pruned without a grace period.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// ObjectDeletion identifies an object that must not exist.
+type ObjectDeletion struct {
+	// APIVersion of the object, e.g. 'apps/v1'.
+	// +required
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object, e.g. 'Deployment'.
+	// +required
+	Kind string `json:"kind"`
+
+	// Namespace of the object. Defaults to the namespace the manifests are
+	// rendered into for namespaced kinds. Only that namespace and the
+	// namespace of the Konfiguration are allowed.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object.
+	// +required
+	Name string `json:"name"`
+}
+
+// PropagationPolicy is the deletion propagation policy of pruned objects of a
+// kind.
+type PropagationPolicy struct {
+	// Kind of the objects, e.g. 'StatefulSet'.
+	// +required
+	Kind string `json:"kind"`
+
+	// Policy is the deletion propagation policy, one of `Foreground`,
+	// `Background` or `Orphan`.
+	// +kubebuilder:validation:Enum=Foreground;Background;Orphan
+	// +required
+	Policy metav1.DeletionPropagation `json:"policy"`
+}
+
+// Preview configures a preview environment for a Konfiguration.
+type Preview struct {
+	// Enabled renders the Konfiguration into an auto-created namespace named
+	// after the branch of the source revision. The namespace is removed when
+	// the branch no longer exists, the source moves to another branch, or the
+	// Konfiguration is deleted. Requires a GitRepository source.
+	// +required
+	Enabled bool `json:"enabled"`
+
+	// NamespacePrefix is prepended to the name of the preview namespace.
+	// Defaults to the name of the Konfiguration.
+	// +optional
+	NamespacePrefix string `json:"namespacePrefix,omitempty"`
+}
+
+// KubecfgOptions configures the kubecfg invocations of a Konfiguration.
+type KubecfgOptions struct {
+	// IgnoreUnknown skips validation of objects whose kind has no schema on
+	// the server, e.g. custom resources whose definition is applied along
+	// with them. Defaults to false.
+	// +optional
+	IgnoreUnknown bool `json:"ignoreUnknown,omitempty"`
+
+	// ResolveImages replaces the tags of container images with their digests
+	// when set to `registry`. Defaults to `noop`.
+	// +kubebuilder:validation:Enum=noop;registry
+	// +optional
+	ResolveImages string `json:"resolveImages,omitempty"`
+
+	// ResolveImagesError is the action taken when the digest of an image
+	// cannot be resolved: `fail` the render, `warn` and keep the tag, or
+	// `ignore` the error. Defaults to `fail`.
+	// +kubebuilder:validation:Enum=fail;warn;ignore
+	// +optional
+	ResolveImagesError string `json:"resolveImagesError,omitempty"`
+
+	// Verbosity raises the log level of kubecfg, whose output is included in
+	// the errors of the Konfiguration. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3
+	// +optional
+	Verbosity int32 `json:"verbosity,omitempty"`
+}
+
+// ApplyOptions configures how the manifests of a Konfiguration are applied.
+type ApplyOptions struct {
+	// IgnoreMissingKinds defers the objects whose kind is not registered with
+	// the API server, e.g. custom resources whose definition is applied along
+	// with them or by an operator being installed, instead of failing the
+	// apply. The other objects are applied first, and the manifests are
+	// applied again once the missing kinds are registered, within the
+	// Timeout of the Konfiguration. Ignored when targets are set. Defaults to
+	// false.
+	// +optional
+	IgnoreMissingKinds bool `json:"ignoreMissingKinds,omitempty"`
+
+	// OverrideManagers are the field managers, e.g.
+	// `kubectl-client-side-apply` or a previously installed operator, whose
+	// fields of live objects are taken over when the manifests set them to
+	// different values. When set, setting fields owned by any other field
+	// manager to different values is a conflict that fails the apply instead.
+	// +optional
+	OverrideManagers []string `json:"overrideManagers,omitempty"`
+}
+
+// Lint configures checking the Jsonnet sources of a Konfiguration before they
+// are evaluated.
+type Lint struct {
+	// Enforce is the severity of lint findings. `warn` reports them on the
+	// Linted condition and as events, while `error` also fails the
+	// reconciliation so the manifests are not applied. Defaults to `warn`.
+	// +kubebuilder:default:=warn
+	// +kubebuilder:validation:Enum=warn;error
+	// +optional
+	Enforce string `json:"enforce,omitempty"`
+
+	// Format selects the Jsonnet files checked for canonical formatting with
+	// jsonnetfmt. `entrypoint` checks the entrypoint only, while `imports`
+	// also checks every file of the source it imports, directly or not, and
+	// lists the files that are not formatted in the status. Defaults to
+	// `entrypoint`.
+	// +kubebuilder:default:=entrypoint
+	// +kubebuilder:validation:Enum=entrypoint;imports
+	// +optional
+	Format string `json:"format,omitempty"`
+}
+
+// Hibernation configures the windows during which a Konfiguration hibernates.
+type Hibernation struct {
+	// Schedules are the windows during which the Konfiguration hibernates.
+	// It hibernates while any of them is active.
+	// +required
+	Schedules []HibernationSchedule `json:"schedules"`
+}
+
+// HibernationSchedule is a window bounded by two cron schedules. Schedules use
+// the standard five field format and are evaluated in UTC, unless prefixed
+// with a time zone such as 'CRON_TZ=Europe/Berlin'.
+type HibernationSchedule struct {
+	// Start is the schedule on which hibernation starts, e.g. '0 20 * * 1-5'.
+	// +required
+	Start string `json:"start"`
+
+	// End is the schedule on which hibernation ends, e.g. '0 7 * * 1-5'.
+	// +required
+	End string `json:"end"`
+}
+
+// ApplyRecord attributes an apply to the source revision and Konfiguration
+// spec it was rendered from.
+type ApplyRecord struct {
+	// Time is when the manifests were applied.
+	// +required
+	Time metav1.Time `json:"time"`
+
+	// Revision is the source revision the manifests were rendered from.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Checksum is the checksum of the applied manifests.
+	// +required
+	Checksum string `json:"checksum"`
+
+	// SpecChecksum is the checksum of the Konfiguration spec the manifests
+	// were rendered with.
+	// +required
+	SpecChecksum string `json:"specChecksum"`
+
+	// ModifiedBy is the field manager that last modified the Konfiguration
+	// spec, as recorded in its managedFields.
+	// +optional
+	ModifiedBy string `json:"modifiedBy,omitempty"`
+
+	// ChangedPaths lists the files of the source artifact that changed from
+	// the revision reconciled before, up to 20 of them.
+	// +optional
+	ChangedPaths []string `json:"changedPaths,omitempty"`
+
+	// CommitSubject is the first line of the message of the commit of the
+	// revision, when the source is a GitRepository hosted on GitHub or
+	// GitLab whose API tells it.
+	// +optional
+	CommitSubject string `json:"commitSubject,omitempty"`
+
+	// CommitAuthor is the email, or else the name, of the author of the
+	// commit of the revision, when the commit subject is known.
+	// +optional
+	CommitAuthor string `json:"commitAuthor,omitempty"`
+}
+
+// AdaptiveInterval configures how far the interval of a Konfiguration is
+// lengthened while its source does not change.
+type AdaptiveInterval struct {
+	// Max is the longest interval the Konfiguration is reconciled at.
+	// +required
+	Max metav1.Duration `json:"max"`
+}
+
+// RunRecord is the outcome of a reconciliation of a Konfiguration kept in its
+// history.
+type RunRecord struct {
+	// StartTime is when the reconciliation started.
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+
+	// Revision is the source revision reconciled.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Result is whether the reconciliation succeeded.
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	// +required
+	Result string `json:"result"`
+
+	// Reason is the reason of the failure of the reconciliation, if it failed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Duration is how long the reconciliation took.
+	// +required
+	Duration metav1.Duration `json:"duration"`
+}
+
+// RunSummary summarizes a reconciliation of a Konfiguration for tools polling
+// its status, such as CI pipelines.
+type RunSummary struct {
+	// StartTime is when the reconciliation started.
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+
+	// Revision is the source revision reconciled.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// CommitURL links to the commit of the revision reconciled, when the
+	// source is a GitRepository and the revision names a commit.
+	// +optional
+	CommitURL string `json:"commitURL,omitempty"`
+
+	// Result is whether the reconciliation succeeded.
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	// +required
+	Result string `json:"result"`
+
+	// Reason is the reason of the failure of the reconciliation, if it failed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Error is the error the reconciliation failed with, if it failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Created is the number of objects created in the cluster.
+	// +optional
+	Created int32 `json:"created"`
+
+	// Updated is the number of objects whose manifests changed and were
+	// updated in the cluster.
+	// +optional
+	Updated int32 `json:"updated"`
+
+	// Deleted is the number of objects no longer rendered that were deleted
+	// from the cluster.
+	// +optional
+	Deleted int32 `json:"deleted"`
+
+	// Unchanged is the number of rendered objects whose manifests did not
+	// change.
+	// +optional
+	Unchanged int32 `json:"unchanged"`
+
+	// Skipped is the number of rendered objects that were not applied because
+	// their kind is skipped.
+	// +optional
+	Skipped int32 `json:"skipped"`
+
+	// Stages lists the stages of the reconciliation that ran, in order, with
+	// their durations.
+	// +optional
+	Stages []StageDuration `json:"stages,omitempty"`
+}
+
+// StageDuration is the duration of a stage of a reconciliation.
+type StageDuration struct {
+	// Name of the stage, one of render, apply, verify and test.
+	// +required
+	Name string `json:"name"`
+
+	// Duration of the stage.
+	// +required
+	Duration metav1.Duration `json:"duration"`
+}
+
+// CircuitBreaker configures when reconciliation of a Konfiguration is paused
+// after failed applies.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive failed applies after which
+	// reconciliation is paused.
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	Threshold int32 `json:"threshold"`
+
+	// Backoff is how long reconciliation is paused before it is attempted
+	// again. Defaults to 10m.
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+}
+
+// HealthCheck is a check the applied objects must pass before the
+// Konfiguration is marked ready.
+// +kubebuilder:validation:Enum=ServiceEndpoints;IngressAddress
+type HealthCheck string
+
+// Precondition is an endpoint that must be available before a Konfiguration
+// is applied.
+type Precondition struct {
+	// Type of the check. `tcp` connects to the address, `dns` resolves it and
+	// `http` expects a 2xx response to a GET request of it.
+	// +kubebuilder:validation:Enum=tcp;dns;http
+	// +required
+	Type string `json:"type"`
+
+	// Address is a host and port for `tcp`, a host name for `dns` and a URL
+	// for `http` checks.
+	// +required
+	Address string `json:"address"`
+
+	// Timeout of the check. Defaults to 5s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Transformer mutates the rendered objects of a Konfiguration.
+type Transformer struct {
+	// Name of the transformer. Either a transformer built into the controller,
+	// or an executable in the transformer directory of the controller.
+	// `seccomp-runtime-default` sets the RuntimeDefault seccomp profile on
+	// pods that do not set one, and `normalize` strips null values and fields
+	// set to well-known server defaults, so the rendered objects match those
+	// in the cluster and are not applied needlessly. Empty maps, lists and
+	// strings are kept.
+	// Executables read the objects as a YAML stream from stdin and write the
+	// transformed objects to stdout. WASM modules and Go plugins are not
+	// supported.
+	// +kubebuilder:validation:Pattern="^[a-zA-Z0-9][a-zA-Z0-9._-]*$"
+	// +required
+	Name string `json:"name"`
+
+	// Config is passed to executable transformers as `--key=value` arguments.
+	// `normalize` keeps null values if `empty` is `false`, and server defaults
+	// if `defaults` is `false`.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// TestHooks configures test Jobs run after the manifests are applied.
+type TestHooks struct {
+	// Enabled takes the Jobs in the rendered manifests labeled with
+	// apps.kubecfg.io/test=true out of the apply, and runs them once the rest
+	// of the manifests are applied. The Konfiguration is only marked
+	// Ready when they all succeed. Succeeded Jobs are not run again until the
+	// rendered manifests change, while failed Jobs are run again on the next
+	// attempt. The logs of finished Jobs are recorded as events.
+	// +required
+	Enabled bool `json:"enabled"`
+
+	// Timeout for the test Jobs to finish. Defaults to the Konfiguration
+	// Timeout.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Hook runs a Job at a stage of the reconciliation. The container of the Job
+// is passed the namespace and name of the Konfiguration, the stage, the
+// revision and, after pre-render, the checksum of the rendered manifests in
+// the KUBECFG_KONFIGURATION, KUBECFG_STAGE, KUBECFG_REVISION and
+// KUBECFG_CHECKSUM environment variables.
+type Hook struct {
+	// Name of the hook. The Jobs of the hook are named after it.
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// +kubebuilder:validation:MaxLength=40
+	// +required
+	Name string `json:"name"`
+
+	// Stage the hook runs at. `pre-render` runs before the manifests are
+	// rendered, `pre-apply` once they are rendered, before they are applied,
+	// and `post-apply` once they are applied and checked, before any test
+	// Jobs.
+	// +kubebuilder:validation:Enum=pre-render;pre-apply;post-apply
+	// +required
+	Stage string `json:"stage"`
+
+	// Image of the container of the Job.
+	// +required
+	Image string `json:"image"`
+
+	// Command of the container. Defaults to the entrypoint of the image.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args of the container.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// ServiceAccountName is the service account the Job runs as. Defaults to
+	// the default service account of the namespace.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// FailurePolicy controls what happens if the Job fails or does not
+	// finish in time. `Fail` fails the reconciliation, and the hook is run
+	// again on the next attempt. `Warn` records a warning event and carries
+	// on. Defaults to `Fail`.
+	// +kubebuilder:default:=Fail
+	// +kubebuilder:validation:Enum=Fail;Warn
+	// +optional
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+
+	// Timeout for the Job to finish. Defaults to the Konfiguration Timeout.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// RevisionSelector selects the revision of a source to apply.
+type RevisionSelector struct {
+	// SemVer is a semver range, e.g. '>=1.2.0 <2.0.0'. The latest Git tag of
+	// the source within the range is applied.
+	// +required
+	SemVer string `json:"semver"`
+}
+
+// EventSink configures an external HTTP endpoint for reconciliation events.
+type EventSink struct {
+	// Address is the URL that events are posted to as JSON. Its host must be
+	// allowed by the operator of the controller, otherwise no events are
+	// posted.
+	// +required
+	Address string `json:"address"`
+
+	// SecretRef holds the name to a secret that contains a 'token' key used to
+	// sign events with HMAC-SHA256. The signature is sent in the X-Signature
+	// header as 'sha256=<hex digest>'. It must be in the same namespace as the
+	// Konfiguration.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
 }
 
 // Variables describe code/strings for external variables and top-level arguments.
@@ -133,6 +1033,81 @@ type Variables struct {
 	// Values of top level arguments with values supplied as Jsonnet code.
 	// +optional
 	TLACode map[string]string `json:"tlaCode,omitempty"`
+	// Files containing values of external variables with string values. Paths
+	// are relative to the root of the SourceRef artifact.
+	// +optional
+	ExtStrFiles map[string]string `json:"extStrFiles,omitempty"`
+	// Files containing values of external variables supplied as Jsonnet code.
+	// Paths are relative to the root of the SourceRef artifact.
+	// +optional
+	ExtCodeFiles map[string]string `json:"extCodeFiles,omitempty"`
+	// Files containing values of top level arguments with string values. Paths
+	// are relative to the root of the SourceRef artifact.
+	// +optional
+	TLAStrFiles map[string]string `json:"tlaStrFiles,omitempty"`
+	// Files containing values of top level arguments supplied as Jsonnet code.
+	// Paths are relative to the root of the SourceRef artifact.
+	// +optional
+	TLACodeFiles map[string]string `json:"tlaCodeFiles,omitempty"`
+	// Keys of ConfigMaps containing values of external variables with string
+	// values. ConfigMaps must be in the namespace of the Konfiguration and are
+	// read at every render.
+	// +optional
+	ExtStrFromConfigMaps map[string]ConfigMapKeyRef `json:"extStrFromConfigMaps,omitempty"`
+	// Keys of ConfigMaps containing values of external variables supplied as
+	// Jsonnet code.
+	// +optional
+	ExtCodeFromConfigMaps map[string]ConfigMapKeyRef `json:"extCodeFromConfigMaps,omitempty"`
+	// Keys of ConfigMaps containing values of top level arguments with string
+	// values.
+	// +optional
+	TLAStrFromConfigMaps map[string]ConfigMapKeyRef `json:"tlaStrFromConfigMaps,omitempty"`
+	// Keys of ConfigMaps containing values of top level arguments supplied as
+	// Jsonnet code.
+	// +optional
+	TLACodeFromConfigMaps map[string]ConfigMapKeyRef `json:"tlaCodeFromConfigMaps,omitempty"`
+	// Values of external variables with string values resolved at render time
+	// from the secret provider volumes mounted in the controller, such as
+	// Secrets Store CSI driver SecretProviderClasses. Providers must be listed
+	// in the apps.kubecfg.io/secret-providers annotation of the namespace of
+	// the Konfiguration. Resolved values are passed to kubecfg in files and are
+	// never stored in the cluster other than in the rendered manifests.
+	// +optional
+	ExtStrFromSecretProvider map[string]SecretProviderRef `json:"extStrFromSecretProvider,omitempty"`
+	// Values of external variables with string values sealed against the
+	// keypair of the controller, whose certificate it serves on its metrics
+	// endpoint at /v1/cert.pem. Values use the format of sealed-secrets with
+	// a strict scope, as produced by 'kubeseal --raw' with the namespace and
+	// name of the Konfiguration, so they can only be unsealed for it. Values
+	// are decrypted at render time and passed to kubecfg in files removed
+	// once the manifests are rendered.
+	// +optional
+	Sealed map[string]string `json:"sealed,omitempty"`
+}
+
+// SecretProviderRef references an object of a secret provider volume mounted
+// in the controller.
+type SecretProviderRef struct {
+	// Provider is the name of the secret provider volume, e.g. the name of the
+	// SecretProviderClass.
+	// +required
+	Provider string `json:"provider"`
+
+	// Object is the name of the object within the volume.
+	// +required
+	Object string `json:"object"`
+}
+
+// ConfigMapKeyRef references a key of a ConfigMap in the namespace of the
+// Konfiguration.
+type ConfigMapKeyRef struct {
+	// Name of the ConfigMap.
+	// +required
+	Name string `json:"name"`
+
+	// Key of the value within the ConfigMap.
+	// +required
+	Key string `json:"key"`
 }
 
 // CrossNamespaceSourceReference contains enough information to let you locate the
@@ -154,6 +1129,33 @@ type CrossNamespaceSourceReference struct {
 	// Namespace of the referent, defaults to the Konfiguration namespace
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
+
+	// Ref overrides the Git reference of the referenced GitRepository. When set,
+	// the controller manages a copy of the GitRepository tracking this reference
+	// and reconciles against it instead of the original. The copy is created in
+	// the namespace of the GitRepository, which must list the namespace of the
+	// Konfiguration in its apps.kubecfg.io/allow-derived-sources annotation if
+	// it is another one. The same applies to pinned revisions and revision
+	// selectors. Only valid for the GitRepository kind.
+	// +optional
+	Ref *sourcev1.GitRepositoryRef `json:"ref,omitempty"`
+}
+
+// ArtifactSource is a source of manifests served outside of Flux. Exactly one
+// of URL and Volume must be set. Changes are detected by hashing the contents
+// of the artifact, which serves as the revision.
+type ArtifactSource struct {
+	// URL of a gzipped tarball served over HTTP(S), e.g. by an in-cluster
+	// file server. It is downloaded on every reconciliation.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Volume is the name of a directory, under the directory the controller
+	// mounts volume sources in, holding the sources, such as a
+	// PersistentVolumeClaim mounted into the controller. It is read in place.
+	// +optional
+	Volume string `json:"volume,omitempty"`
 }
 
 // KonfigurationStatus defines the observed state of Konfiguration
@@ -162,6 +1164,12 @@ type KonfigurationStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// ObservedSpecChecksum is the checksum of the canonical form of the spec
+	// last reconciled. Spec edits that leave it unchanged, such as a duration
+	// written in other units, are not reconciled again.
+	// +optional
+	ObservedSpecChecksum string `json:"observedSpecChecksum,omitempty"`
+
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
@@ -176,17 +1184,168 @@ type KonfigurationStatus struct {
 	// +optional
 	LastAttemptedRevision string `json:"lastAttemptedRevision,omitempty"`
 
+	// QuarantinedRevision is the source revision whose artifact failed
+	// inspection before extraction. It is not downloaded again, and the
+	// Konfiguration is not reconciled until its source moves to another
+	// revision.
+	// +optional
+	QuarantinedRevision string `json:"quarantinedRevision,omitempty"`
+
+	// LastAttemptedChecksum is the checksum of the manifests rendered for the
+	// last reconciliation attempt.
+	// +optional
+	LastAttemptedChecksum string `json:"lastAttemptedChecksum,omitempty"`
+
+	// LastEvaluationTrace is the output of the std.trace calls made by the
+	// last evaluation of the manifests, truncated to its last 4096 bytes.
+	// +optional
+	LastEvaluationTrace string `json:"lastEvaluationTrace,omitempty"`
+
+	// PreviewNamespace is the namespace the Konfiguration is currently
+	// rendered into when preview mode is enabled.
+	// +optional
+	PreviewNamespace string `json:"previewNamespace,omitempty"`
+
+	// Targets reports the state of each target cluster.
+	// +optional
+	Targets []TargetStatus `json:"targets,omitempty"`
+
+	// InventoryRef references the ConfigMap listing the objects applied by the
+	// last successful reconciliation and their health as of then, under the
+	// 'inventory.json' key. Inventories too large for a single ConfigMap are gzip-compressed and split across a
+	// chain of ConfigMaps starting at this one, each holding a part under the
+	// 'inventory.json.gz' key and naming the ConfigMap holding the next part,
+	// if any, in its 'apps.kubecfg.io/inventory-next' annotation.
+	// +optional
+	InventoryRef *corev1.LocalObjectReference `json:"inventoryRef,omitempty"`
+
+	// InventoryParts is the number of ConfigMaps the inventory is split across,
+	// when it is too large for a single one.
+	// +optional
+	InventoryParts int32 `json:"inventoryParts,omitempty"`
+
+	// InventoryCount is the number of objects listed in the inventory.
+	// +optional
+	InventoryCount int32 `json:"inventoryCount,omitempty"`
+
+	// ClusterScopedCount is the number of cluster-scoped objects listed in
+	// the inventory.
+	// +optional
+	ClusterScopedCount int32 `json:"clusterScopedCount,omitempty"`
+
+	// ManifestsRef references the first of the chain of Secrets holding the
+	// manifests applied by the last successful reconciliation, when they are
+	// stored. Each holds a part of the gzip-compressed manifests under the
+	// 'manifests.yaml.gz' key and names the Secret holding the next part, if
+	// any, in its 'apps.kubecfg.io/manifests-next' annotation.
+	// +optional
+	ManifestsRef *corev1.LocalObjectReference `json:"manifestsRef,omitempty"`
+
+	// RemainingObjects lists the managed objects that still exist while a
+	// deleted Konfiguration waits for them to be gone.
+	// +optional
+	RemainingObjects []string `json:"remainingObjects,omitempty"`
+
+	// SkippedObjects lists the rendered objects that were not applied because
+	// their kind is skipped.
+	// +optional
+	SkippedObjects []string `json:"skippedObjects,omitempty"`
+
+	// PausedObjects lists the rendered objects that were left as they are in
+	// the cluster because they are annotated with `kubecfg.io/paused: "true"`.
+	// +optional
+	PausedObjects []string `json:"pausedObjects,omitempty"`
+
+	// CordonedObjects lists the objects no longer rendered that await their
+	// deletion until the prune grace period expires.
+	// +optional
+	CordonedObjects []CordonedObject `json:"cordonedObjects,omitempty"`
+
+	// UnformattedFiles lists the Jsonnet files of the source, relative to its
+	// root, that are not canonically formatted, when lint checks the
+	// formatting of the imports of the entrypoint.
+	// +optional
+	UnformattedFiles []string `json:"unformattedFiles,omitempty"`
+
+	// LastApply records the change responsible for the last apply that
+	// modified the cluster.
+	// +optional
+	LastApply *ApplyRecord `json:"lastApply,omitempty"`
+
+	// LastRunSummary summarizes the last reconciliation, whether it succeeded
+	// or not. Reconciliations with the same outcome as the one summarized,
+	// such as those finding the revision applied and nothing changed, are
+	// not summarized again.
+	// +optional
+	LastRunSummary *RunSummary `json:"lastRunSummary,omitempty"`
+
+	// History records the outcome of the last reconciliations, most recent
+	// first, to tell persistent failures from flapping ones. Consecutive
+	// reconciliations with the same outcome are recorded once.
+	// +optional
+	History []RunRecord `json:"history,omitempty"`
+
+	// EffectiveInterval is the interval the Konfiguration is currently
+	// reconciled at, when it uses an adaptive interval that lengthened it.
+	// Unset while the Konfiguration is reconciled at its interval.
+	// +optional
+	EffectiveInterval *metav1.Duration `json:"effectiveInterval,omitempty"`
+
+	// ConsecutiveFailures is the number of failed applies since the last
+	// successful reconciliation.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
 	// The last successfully applied revision metadata.
 	// +optional
 	Snapshot *Snapshot `json:"snapshot,omitempty"`
 }
 
+// CordonedObject is an object no longer rendered by a Konfiguration that is
+// deleted once the prune grace period expires.
+type CordonedObject struct {
+	InventoryEntry `json:",inline"`
+
+	// CordonedAt is when the object was cordoned.
+	// +required
+	CordonedAt metav1.Time `json:"cordonedAt"`
+}
+
+// TargetStatus is the state of a target cluster of a Konfiguration.
+type TargetStatus struct {
+	// Name of the target.
+	// +required
+	Name string `json:"name"`
+
+	// Ready is true if the manifests of the last reconciliation were applied
+	// to the target.
+	// +required
+	Ready metav1.ConditionStatus `json:"ready"`
+
+	// Message describes the last failure, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastAppliedRevision is the last revision applied to the target.
+	// +optional
+	LastAppliedRevision string `json:"lastAppliedRevision,omitempty"`
+
+	// LastAppliedChecksum is the checksum of the manifests last applied to
+	// the target.
+	// +optional
+	LastAppliedChecksum string `json:"lastAppliedChecksum,omitempty"`
+}
+
+//+genclient
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".status.lastAppliedRevision"
+//+kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
-//+kubebuilder:printColumn:name="LastAppliedRevision,type="string",JSONPath=".status.lastAppliedRevision",priority=0
 //+kubebuilder:printcolumn:name="LastAttemptedRevision",type="string",JSONPath=".status.lastAttemptedRevision",priority=1
-//+kubebuilder:printColumn:name="LastAppliedChecksum",type="string",JSONPath=".status.snapshot.checksum",priority=1
+//+kubebuilder:printcolumn:name="Objects",type="integer",JSONPath=".status.inventoryCount",priority=1
 
 // Konfiguration is the Schema for the konfigurations API
 type Konfiguration struct {
@@ -18,24 +18,63 @@ package v1
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/fluxcd/pkg/runtime/dependency"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // GetInterval returns the interval at which to reconcile the Konfiguration.
 func (k *Konfiguration) GetInterval() time.Duration { return k.Spec.Interval.Duration }
 
+// IsEventDriven returns true if the Konfiguration is only reconciled on
+// events, such as changes to its source or spec, because its interval is zero.
+func (k *Konfiguration) IsEventDriven() bool { return k.GetInterval() == 0 }
+
+// GetEffectiveInterval returns the interval at which the Konfiguration is
+// currently reconciled, as adapted to the activity of its source if it uses an
+// adaptive interval.
+func (k *Konfiguration) GetEffectiveInterval() time.Duration {
+	if k.Spec.AdaptiveInterval != nil && k.Status.EffectiveInterval != nil {
+		return k.Status.EffectiveInterval.Duration
+	}
+	return k.GetInterval()
+}
+
+// GetPollInterval returns the interval at which to check again conditions whose
+// changes raise no event on the Konfiguration, such as the labels of its
+// namespace: the interval, or the retry interval if the interval is zero.
+func (k *Konfiguration) GetPollInterval() time.Duration {
+	if k.IsEventDriven() {
+		return k.GetRetryInterval()
+	}
+	return k.GetInterval()
+}
+
 // GetRetryInterval returns the interval at which to retry a previously failed
 // reconciliation.
 func (k *Konfiguration) GetRetryInterval() time.Duration {
 	if k.Spec.RetryInterval != nil {
 		return k.Spec.RetryInterval.Duration
 	}
+	if k.IsEventDriven() {
+		return time.Minute
+	}
 	return k.GetInterval()
 }
 
@@ -45,6 +84,9 @@ func (k *Konfiguration) GetTimeout() time.Duration {
 	if k.Spec.Timeout != nil {
 		return k.Spec.Timeout.Duration
 	}
+	if k.IsEventDriven() {
+		return 5 * time.Minute
+	}
 	return k.GetInterval()
 }
 
@@ -54,8 +96,15 @@ func (k *Konfiguration) GetTimeout() time.Duration {
 func (k *Konfiguration) GetKubeConfig() *KubeConfig { return k.Spec.KubeConfig }
 
 // Fetch will use the given client and namespace to retrieve the contents of the
-// kubeconfig from the referenced secret.
+// kubeconfig from the referenced secret. If a context is selected, the
+// kubeconfig is returned with it as the current-context.
+// If InClusterWithOverrides is set, the kubeconfig is built from the in-cluster
+// configuration instead. If a proxy URL is set, it is set on the cluster of the
+// current-context.
 func (k *KubeConfig) Fetch(ctx context.Context, c client.Client, namespace string) (string, error) {
+	if k.InClusterWithOverrides != nil {
+		return k.InClusterWithOverrides.build(ctx, c, namespace, k.ProxyURL)
+	}
 	nn := types.NamespacedName{
 		Name:      k.SecretRef.Name,
 		Namespace: namespace,
@@ -71,17 +120,269 @@ func (k *KubeConfig) Fetch(ctx context.Context, c client.Client, namespace strin
 	if !ok {
 		return "", fmt.Errorf("Secret '%s/%s' contains no 'value' key", secret.GetNamespace(), secret.GetName())
 	}
-	return string(bytes), nil
+	if k.Context == "" && k.ProxyURL == "" {
+		return string(bytes), nil
+	}
+
+	cfg, err := clientcmd.Load(bytes)
+	if err != nil {
+		return "", fmt.Errorf("Secret '%s/%s' contains an invalid kubeconfig: %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	if k.Context != "" {
+		if _, ok := cfg.Contexts[k.Context]; !ok {
+			contexts := make([]string, 0, len(cfg.Contexts))
+			for name := range cfg.Contexts {
+				contexts = append(contexts, name)
+			}
+			sort.Strings(contexts)
+			return "", fmt.Errorf("context '%s' not found in the kubeconfig in Secret '%s/%s', available contexts: [%s]",
+				k.Context, secret.GetNamespace(), secret.GetName(), strings.Join(contexts, ", "))
+		}
+		cfg.CurrentContext = k.Context
+	}
+	if k.ProxyURL != "" {
+		kubeContext, ok := cfg.Contexts[cfg.CurrentContext]
+		if !ok {
+			return "", fmt.Errorf("current-context '%s' not found in the kubeconfig in Secret '%s/%s'", cfg.CurrentContext, secret.GetNamespace(), secret.GetName())
+		}
+		cluster, ok := cfg.Clusters[kubeContext.Cluster]
+		if !ok {
+			return "", fmt.Errorf("cluster '%s' not found in the kubeconfig in Secret '%s/%s'", kubeContext.Cluster, secret.GetNamespace(), secret.GetName())
+		}
+		if err := validateProxyURL(k.ProxyURL); err != nil {
+			return "", err
+		}
+		cluster.ProxyURL = k.ProxyURL
+	}
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// build returns a kubeconfig for the in-cluster configuration with the
+// overridden endpoint and credentials, reached through the given proxy if any.
+func (o *InClusterOverrides) build(ctx context.Context, c client.Client, namespace, proxyURL string) (string, error) {
+	inCluster, err := rest.InClusterConfig()
+	if err != nil {
+		return "", err
+	}
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = inCluster.Host
+	cluster.CertificateAuthority = inCluster.TLSClientConfig.CAFile
+	if o.Server != "" {
+		cluster.Server = o.Server
+	}
+	if proxyURL != "" {
+		if err := validateProxyURL(proxyURL); err != nil {
+			return "", err
+		}
+		cluster.ProxyURL = proxyURL
+	}
+	if o.CASecretRef != nil {
+		ca, err := secretValue(ctx, c, namespace, o.CASecretRef.Name, "ca.crt")
+		if err != nil {
+			return "", err
+		}
+		cluster.CertificateAuthority = ""
+		cluster.CertificateAuthorityData = ca
+	}
+
+	token, err := secretValue(ctx, c, namespace, o.TokenSecretRef.Name, "token")
+	if err != nil {
+		return "", err
+	}
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Token = string(token)
+
+	kubeContext := clientcmdapi.NewContext()
+	kubeContext.Cluster = "in-cluster"
+	kubeContext.AuthInfo = "in-cluster"
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["in-cluster"] = cluster
+	cfg.AuthInfos["in-cluster"] = authInfo
+	cfg.Contexts["in-cluster"] = kubeContext
+	cfg.CurrentContext = "in-cluster"
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// validateProxyURL returns an error if the given proxy URL is not an absolute
+// URL with a scheme supported by kubeconfigs.
+func validateProxyURL(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy URL '%s': scheme must be http, https or socks5", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL '%s': no host", proxyURL)
+	}
+	return nil
+}
+
+// secretValue returns the value of the given key of a Secret.
+func secretValue(ctx context.Context, c client.Client, namespace, name, key string) ([]byte, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &secret); err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("Secret '%s/%s' contains no '%s' key", namespace, name, key)
+	}
+	return value, nil
+}
+
+// HasTargets returns true if the manifests are applied to target clusters
+// rather than the cluster the controller runs in.
+func (k *Konfiguration) HasTargets() bool { return len(k.Spec.Targets) != 0 }
+
+// HasChildren returns true if the readiness of other Konfigurations is rolled
+// up into the Konfiguration.
+func (k *Konfiguration) HasChildren() bool { return len(k.Spec.Children) != 0 }
+
+// GetEventSink returns the external endpoint to post events for the
+// Konfiguration to, if any.
+func (k *Konfiguration) GetEventSink() *EventSink { return k.Spec.EventSink }
+
+// FetchToken will use the given client and namespace to retrieve the token used
+// to sign events from the referenced secret. It returns nil if no secret is
+// referenced.
+func (e *EventSink) FetchToken(ctx context.Context, c client.Client, namespace string) ([]byte, error) {
+	if e.SecretRef == nil {
+		return nil, nil
+	}
+	nn := types.NamespacedName{
+		Name:      e.SecretRef.Name,
+		Namespace: namespace,
+	}
+	var secret corev1.Secret
+	if err := c.Get(ctx, nn, &secret); err != nil {
+		return nil, err
+	}
+	token, ok := secret.Data["token"]
+	if !ok {
+		return nil, fmt.Errorf("Secret '%s/%s' contains no 'token' key", secret.GetNamespace(), secret.GetName())
+	}
+	return token, nil
 }
 
 // GetPath returns the Path to the jsonnet, json, or yaml to evaluate.
 func (k *Konfiguration) GetPath() string { return k.Spec.Path }
 
+// DefaultEntrypoints are the file names looked up when the path of a
+// Konfiguration is a directory, unless it sets its own.
+var DefaultEntrypoints = []string{"main.jsonnet", "kube.jsonnet", "index.jsonnet"}
+
+// GetEntrypoints returns the file names looked up, in order of preference,
+// when the path of the Konfiguration is a directory.
+func (k *Konfiguration) GetEntrypoints() []string {
+	if len(k.Spec.Entrypoints) != 0 {
+		return k.Spec.Entrypoints
+	}
+	return DefaultEntrypoints
+}
+
+// GetSparsePaths returns the cleaned paths, relative to the root of the source
+// artifact, to extract from it, including Path, in sorted order. It returns
+// nil if the whole artifact is extracted.
+func (k *Konfiguration) GetSparsePaths() []string {
+	if len(k.Spec.SparsePaths) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	for _, p := range append([]string{k.GetPath()}, k.Spec.SparsePaths...) {
+		p = strings.TrimPrefix(path.Clean("/"+p), "/")
+		if p == "" {
+			// The root includes everything
+			return nil
+		}
+		seen[p] = struct{}{}
+	}
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// YAMLStreamEnabled returns true if the entrypoint of the Konfiguration may
+// evaluate to a stream of YAML documents.
+func (k *Konfiguration) YAMLStreamEnabled() bool { return k.Spec.YAMLStream }
+
 // GetVariables returns the external and top level arguments to pass to kubecfg.
 func (k *Konfiguration) GetVariables() *Variables {
 	return k.Spec.Variables
 }
 
+// Merge returns a copy of the variables with those of override taking
+// precedence. Either may be nil.
+func (v *Variables) Merge(override *Variables) *Variables {
+	merged := v.DeepCopy()
+	if merged == nil {
+		merged = &Variables{}
+	}
+	if override == nil {
+		return merged
+	}
+	merged.ExtStr = mergeValues(merged.ExtStr, override.ExtStr)
+	merged.ExtCode = mergeValues(merged.ExtCode, override.ExtCode)
+	merged.TLAStr = mergeValues(merged.TLAStr, override.TLAStr)
+	merged.TLACode = mergeValues(merged.TLACode, override.TLACode)
+	merged.ExtStrFiles = mergeValues(merged.ExtStrFiles, override.ExtStrFiles)
+	merged.ExtCodeFiles = mergeValues(merged.ExtCodeFiles, override.ExtCodeFiles)
+	merged.TLAStrFiles = mergeValues(merged.TLAStrFiles, override.TLAStrFiles)
+	merged.TLACodeFiles = mergeValues(merged.TLACodeFiles, override.TLACodeFiles)
+	merged.ExtStrFromConfigMaps = mergeConfigMapRefs(merged.ExtStrFromConfigMaps, override.ExtStrFromConfigMaps)
+	merged.ExtCodeFromConfigMaps = mergeConfigMapRefs(merged.ExtCodeFromConfigMaps, override.ExtCodeFromConfigMaps)
+	merged.TLAStrFromConfigMaps = mergeConfigMapRefs(merged.TLAStrFromConfigMaps, override.TLAStrFromConfigMaps)
+	merged.TLACodeFromConfigMaps = mergeConfigMapRefs(merged.TLACodeFromConfigMaps, override.TLACodeFromConfigMaps)
+	for name, ref := range override.ExtStrFromSecretProvider {
+		if merged.ExtStrFromSecretProvider == nil {
+			merged.ExtStrFromSecretProvider = make(map[string]SecretProviderRef)
+		}
+		merged.ExtStrFromSecretProvider[name] = ref
+	}
+	merged.Sealed = mergeValues(merged.Sealed, override.Sealed)
+	return merged
+}
+
+func mergeValues(dst, src map[string]string) map[string]string {
+	for k, v := range src {
+		if dst == nil {
+			dst = make(map[string]string, len(src))
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+func mergeConfigMapRefs(dst, src map[string]ConfigMapKeyRef) map[string]ConfigMapKeyRef {
+	for k, v := range src {
+		if dst == nil {
+			dst = make(map[string]ConfigMapKeyRef, len(src))
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// HasFiles returns true if any variables are read from files.
+func (v *Variables) HasFiles() bool {
+	return len(v.ExtStrFiles) != 0 || len(v.ExtCodeFiles) != 0 || len(v.TLAStrFiles) != 0 || len(v.TLACodeFiles) != 0
+}
+
 // AppendToArgs formats the configured variables to kubecfg command line arguments.
 func (v *Variables) AppendToArgs(args []string) []string {
 	for k, v := range v.ExtStr {
@@ -96,9 +397,27 @@ func (v *Variables) AppendToArgs(args []string) []string {
 	for k, v := range v.TLACode {
 		args = append(args, []string{"--tla-code", fmt.Sprintf("%s=%s", k, v)}...)
 	}
+	for k, v := range v.ExtStrFiles {
+		args = append(args, []string{"--ext-str-file", fmt.Sprintf("%s=%s", k, v)}...)
+	}
+	for k, v := range v.ExtCodeFiles {
+		args = append(args, []string{"--ext-code-file", fmt.Sprintf("%s=%s", k, v)}...)
+	}
+	for k, v := range v.TLAStrFiles {
+		args = append(args, []string{"--tla-str-file", fmt.Sprintf("%s=%s", k, v)}...)
+	}
+	for k, v := range v.TLACodeFiles {
+		args = append(args, []string{"--tla-code-file", fmt.Sprintf("%s=%s", k, v)}...)
+	}
 	return args
 }
 
+// GetSpecChecksum returns the checksum of the canonical JSON form of the spec.
+func (k *Konfiguration) GetSpecChecksum() string {
+	spec, _ := json.Marshal(&k.Spec)
+	return fmt.Sprintf("%x", sha1.Sum(spec))
+}
+
 // GetKubecfgArgs returns user-defined arguments to pass to kubecfg.
 func (k *Konfiguration) GetKubecfgArgs() []string { return k.Spec.KubecfgArgs }
 
@@ -106,25 +425,257 @@ func (k *Konfiguration) GetKubecfgArgs() []string { return k.Spec.KubecfgArgs }
 // manifests.
 func (k *Konfiguration) GCEnabled() bool { return k.Spec.Prune }
 
+// IgnoreMissingKindsEnabled returns true if objects of kinds that are not
+// registered yet should be applied once they are, rather than failing the
+// apply.
+func (k *Konfiguration) IgnoreMissingKindsEnabled() bool {
+	return k.Spec.Apply != nil && k.Spec.Apply.IgnoreMissingKinds
+}
+
+// FeatureGateEnabled returns true if the Konfiguration enabled the given
+// feature gate.
+func (k *Konfiguration) FeatureGateEnabled(gate string) bool {
+	return k.Spec.FeatureGates[gate]
+}
+
+// GetOverrideManagers returns the field managers whose fields of live objects
+// may be taken over by the apply.
+func (k *Konfiguration) GetOverrideManagers() []string {
+	if k.Spec.Apply == nil {
+		return nil
+	}
+	return k.Spec.Apply.OverrideManagers
+}
+
+// PermissionsCheckEnabled returns true if the permissions needed to apply the
+// rendered objects should be reviewed before they are applied.
+func (k *Konfiguration) PermissionsCheckEnabled() bool { return k.Spec.PermissionsCheck }
+
+// SchedulingCheckEnabled returns true if the placement of rendered workloads
+// should be checked against the nodes of the cluster before they are applied.
+func (k *Konfiguration) SchedulingCheckEnabled() bool { return k.Spec.SchedulingCheck }
+
+// PreRenderEnabled returns true if new revisions should be rendered while
+// their apply is held back.
+func (k *Konfiguration) PreRenderEnabled() bool { return k.Spec.PreRender }
+
+// VerifyAppliedEnabled returns true if applied objects should be read back
+// before the Konfiguration is marked ready.
+func (k *Konfiguration) VerifyAppliedEnabled() bool { return k.Spec.VerifyApplied }
+
+// ValidateOnAdmissionEnabled returns true if the entrypoint should be
+// evaluated by the validating webhook.
+func (k *Konfiguration) ValidateOnAdmissionEnabled() bool { return k.Spec.ValidateOnAdmission }
+
+// GetHealthChecks returns the checks the applied objects must pass before the
+// Konfiguration is marked ready.
+func (k *Konfiguration) GetHealthChecks() []HealthCheck { return k.Spec.HealthChecks }
+
+// GetHealthChecksScope returns the scope of the objects read back after
+// apply, defaulting to all of them.
+func (k *Konfiguration) GetHealthChecksScope() string {
+	if k.Spec.HealthChecksScope == "" {
+		return HealthChecksScopeAll
+	}
+	return k.Spec.HealthChecksScope
+}
+
+// LintEnabled returns whether the Jsonnet sources are linted before they are
+// evaluated.
+func (k *Konfiguration) LintEnabled() bool { return k.Spec.Lint != nil }
+
+// LintEnforced returns whether lint findings fail the reconciliation.
+func (k *Konfiguration) LintEnforced() bool {
+	return k.Spec.Lint != nil && k.Spec.Lint.Enforce == "error"
+}
+
+// LintFormatImports returns whether lint checks the formatting of the files
+// imported by the entrypoint, rather than of the entrypoint only.
+func (k *Konfiguration) LintFormatImports() bool {
+	return k.Spec.Lint != nil && k.Spec.Lint.Format == "imports"
+}
+
+// GetGCTag returns the tag kubecfg marks the applied objects with for garbage
+// collection.
+func (k *Konfiguration) GetGCTag() string {
+	if k.Spec.GCTag != "" {
+		return k.Spec.GCTag
+	}
+	return fmt.Sprintf("%s_%s", k.GetNamespace(), k.GetName())
+}
+
+// PruneClusterScopedEnabled returns whether garbage collection may delete
+// cluster-scoped objects.
+func (k *Konfiguration) PruneClusterScopedEnabled() bool {
+	return k.Spec.PruneOptions != nil && k.Spec.PruneOptions.ClusterScoped
+}
+
+// PruneCRDsEnabled returns whether garbage collection may delete
+// CustomResourceDefinitions.
+func (k *Konfiguration) PruneCRDsEnabled() bool {
+	return k.PruneClusterScopedEnabled() && k.Spec.PruneOptions.CustomResourceDefinitions
+}
+
+// PruneRemovedTargetsEnabled returns whether garbage collection deletes the
+// objects applied to targets removed from the Konfiguration.
+func (k *Konfiguration) PruneRemovedTargetsEnabled() bool {
+	return k.GCEnabled() && k.Spec.PruneOptions != nil && k.Spec.PruneOptions.RemovedTargets
+}
+
+// GetPruneGracePeriod returns how long objects no longer rendered are kept
+// cordoned before they are pruned, or zero if they are pruned right away.
+func (k *Konfiguration) GetPruneGracePeriod() time.Duration {
+	if k.Spec.PruneOptions == nil || k.Spec.PruneOptions.GracePeriod == nil {
+		return 0
+	}
+	return k.Spec.PruneOptions.GracePeriod.Duration
+}
+
+// GetPropagationPolicy returns the deletion propagation policy of pruned
+// objects of the given kind.
+func (k *Konfiguration) GetPropagationPolicy(kind string) metav1.DeletionPropagation {
+	if k.Spec.PruneOptions != nil {
+		for _, p := range k.Spec.PruneOptions.PropagationPolicy {
+			if p.Kind == kind {
+				return p.Policy
+			}
+		}
+	}
+	return metav1.DeletePropagationForeground
+}
+
+// GetTimeout returns the timeout of the precondition check.
+func (p *Precondition) GetTimeout() time.Duration {
+	if p.Timeout != nil {
+		return p.Timeout.Duration
+	}
+	return 5 * time.Second
+}
+
 // ValidateEnabled returns true if server-side validation is enabled.
 func (k *Konfiguration) ValidateEnabled() bool { return k.Spec.Validate }
 
+// IgnoreHPAReplicasEnabled returns true if replica counts of workloads scaled
+// by a HorizontalPodAutoscaler should be left to the autoscaler.
+func (k *Konfiguration) IgnoreHPAReplicasEnabled() bool { return k.Spec.IgnoreHPAReplicas }
+
+// PodTemplateHashEnabled returns true if pod templates should be annotated
+// with a hash of the configuration they reference.
+func (k *Konfiguration) PodTemplateHashEnabled() bool { return k.Spec.PodTemplateHash }
+
+// PreviousManifestsEnabled returns true if the objects applied by the previous
+// revision are passed to the manifests.
+func (k *Konfiguration) PreviousManifestsEnabled() bool { return k.Spec.PreviousManifests }
+
+// StoreManifestsEnabled returns true if the applied manifests are stored in
+// ConfigMaps.
+func (k *Konfiguration) StoreManifestsEnabled() bool { return k.Spec.StoreManifests }
+
+// HasHooks returns true if the Konfiguration runs hooks at the given stage.
+func (k *Konfiguration) HasHooks(stage string) bool {
+	for _, hook := range k.Spec.Hooks {
+		if hook.Stage == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// TestHooksEnabled returns true if the test Jobs in the rendered manifests
+// should be run after they are applied.
+func (k *Konfiguration) TestHooksEnabled() bool {
+	return k.Spec.TestHooks != nil && k.Spec.TestHooks.Enabled
+}
+
+// GetTestHooksTimeout returns the timeout for test Jobs to finish.
+func (k *Konfiguration) GetTestHooksTimeout() time.Duration {
+	if k.Spec.TestHooks != nil && k.Spec.TestHooks.Timeout != nil {
+		return k.Spec.TestHooks.Timeout.Duration
+	}
+	return k.GetTimeout()
+}
+
 // IsSuspended returns whether the controller should not apply any manifests
 // at the moment.
 func (k *Konfiguration) IsSuspended() bool { return k.Spec.Suspend }
 
+// IsHibernating returns true if a hibernation window of the Konfiguration is
+// active, as last recorded on its HibernatingCondition.
+func (k *Konfiguration) IsHibernating() bool {
+	return k.Spec.Hibernation != nil && apimeta.IsStatusConditionTrue(k.Status.Conditions, HibernatingCondition)
+}
+
+// GetCircuitBreakerBackoff returns how long reconciliation is paused once the
+// circuit breaker opens.
+func (k *Konfiguration) GetCircuitBreakerBackoff() time.Duration {
+	if k.Spec.CircuitBreaker != nil && k.Spec.CircuitBreaker.Backoff != nil {
+		return k.Spec.CircuitBreaker.Backoff.Duration
+	}
+	return 10 * time.Minute
+}
+
+// IsObserveOnly returns true if the controller must not write to the cluster
+// on behalf of the Konfiguration.
+func (k *Konfiguration) IsObserveOnly() bool { return k.Spec.ObserveOnly }
+
+// PruneOnSuspend returns true if the objects managed by the Konfiguration
+// should be removed while it is suspended.
+func (k *Konfiguration) PruneOnSuspend() bool { return k.Spec.SuspendPolicy == SuspendPolicyPrune }
+
+// SkipsKind returns true if rendered objects of the given kind are never
+// applied.
+func (k *Konfiguration) SkipsKind(gk schema.GroupKind) bool {
+	if k.Spec.SkipCRDs && gk == (schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}) {
+		return true
+	}
+	for _, kind := range k.Spec.SkipKinds {
+		if kind == gk.Kind || kind == gk.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDeletionPolicy returns the deletion policy of the Konfiguration.
+func (k *Konfiguration) GetDeletionPolicy() string {
+	if k.Spec.DeletionPolicy == "" {
+		return DeletionPolicyOrphan
+	}
+	return k.Spec.DeletionPolicy
+}
+
 // GetDiffStrategy retrieves the diff strategy to use.
 func (k *Konfiguration) GetDiffStrategy() string { return k.Spec.DiffStrategy }
 
+// PreviewEnabled returns whether the Konfiguration should be rendered into a
+// preview namespace for its source branch.
+func (k *Konfiguration) PreviewEnabled() bool {
+	return k.Spec.Preview != nil && k.Spec.Preview.Enabled
+}
+
+// GetTargetNamespace returns the default namespace to render manifests into.
+// This is the preview namespace when one is in use, otherwise the namespace of
+// the Konfiguration.
+func (k *Konfiguration) GetTargetNamespace() string {
+	if k.PreviewEnabled() && k.Status.PreviewNamespace != "" {
+		return k.Status.PreviewNamespace
+	}
+	return k.GetNamespace()
+}
+
 // ForceCreate returns whether the controller should force recreating resources
 // when patching fails due to an immutable field change.
 // func (k *Konfiguration) ForceCreate() bool { return k.Spec.Force }
 
 func (k Konfiguration) GetDependsOn() (types.NamespacedName, []dependency.CrossNamespaceDependencyReference) {
+	deps := make([]dependency.CrossNamespaceDependencyReference, len(k.Spec.DependsOn))
+	for i, dep := range k.Spec.DependsOn {
+		deps[i] = dep.CrossNamespaceDependencyReference
+	}
 	return types.NamespacedName{
 		Namespace: k.Namespace,
 		Name:      k.Name,
-	}, k.Spec.DependsOn
+	}, deps
 }
 
 func (k *Konfiguration) GetSourceRef() *CrossNamespaceSourceReference {
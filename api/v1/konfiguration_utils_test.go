@@ -0,0 +1,48 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetSparsePaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		sparsePaths []string
+		want        []string
+	}{
+		{name: "no sparse paths", path: "apps/web"},
+		{name: "path and sparse paths", path: "apps/web/main.jsonnet", sparsePaths: []string{"lib"}, want: []string{"apps/web/main.jsonnet", "lib"}},
+		{name: "cleaned paths", path: "./apps/web/", sparsePaths: []string{"/lib/../lib", "apps/web"}, want: []string{"apps/web", "lib"}},
+		{name: "escaping paths", path: "apps/web", sparsePaths: []string{"../../etc"}, want: []string{"apps/web", "etc"}},
+		{name: "root path", path: "./", sparsePaths: []string{"lib"}},
+		{name: "root sparse path", path: "apps/web", sparsePaths: []string{"."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.Spec.Path = tt.path
+			k.Spec.SparsePaths = tt.sparsePaths
+			if got := k.GetSparsePaths(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSparsePaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// allowedKubecfgArgs are the flags accepted in KubecfgArgs, mapped to whether
+// they take a value. Flags that change the cluster, namespace or garbage
+// collection tag kubecfg operates on, disable validation or read files from
+// the controller are not allowed.
+var allowedKubecfgArgs = map[string]bool{
+	"ext-str":              true,
+	"ext-code":             true,
+	"tla-str":              true,
+	"tla-code":             true,
+	"ignore-unknown":       false,
+	"resolve-images":       true,
+	"resolve-images-error": true,
+	"verbose":              false,
+	"v":                    false,
+}
+
+// knownFeatureGates are the feature gates a Konfiguration may set.
+var knownFeatureGates = map[string]bool{
+	FeatureGateServerSideApply:     true,
+	FeatureGateInProcessEvaluation: true,
+	FeatureGateIncrementalApply:    true,
+}
+
+// SetupWebhookWithManager registers the validating webhook for Konfigurations
+// with the manager.
+func (k *Konfiguration) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(k).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-apps-kubecfg-io-v1-konfiguration,mutating=false,failurePolicy=fail,sideEffects=None,groups=apps.kubecfg.io,resources=konfigurations,verbs=create;update,versions=v1,name=vkonfiguration.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &Konfiguration{}
+
+// ValidateCreate implements webhook.Validator.
+func (k *Konfiguration) ValidateCreate() error {
+	if err := k.ValidateKubeConfig(nil); err != nil {
+		return err
+	}
+	return k.ValidateSpec()
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (k *Konfiguration) ValidateUpdate(old runtime.Object) error {
+	if err := k.ValidateKubeConfig(old); err != nil {
+		return err
+	}
+	return k.ValidateSpec()
+}
+
+// ValidateDelete implements webhook.Validator.
+func (k *Konfiguration) ValidateDelete() error {
+	return nil
+}
+
+// ValidateSpec returns an error if the spec holds kubecfg arguments, feature
+// gates, propagation policies or dependencies that are not allowed, or an
+// interval of zero along with an artifact source.
+func (k *Konfiguration) ValidateSpec() error {
+	if err := k.ValidateKubecfgArgs(); err != nil {
+		return err
+	}
+	if err := k.ValidateFeatureGates(); err != nil {
+		return err
+	}
+	if k.IsEventDriven() && k.Spec.ArtifactSource != nil {
+		return fmt.Errorf("interval: cannot be zero with artifactSource, whose changes raise no events")
+	}
+	if err := k.ValidateDeletes(); err != nil {
+		return err
+	}
+	if err := k.ValidatePruneOptions(); err != nil {
+		return err
+	}
+	return k.ValidateDependsOn()
+}
+
+// ValidateKubeConfig returns an error if the deprecated kubeConfig is set,
+// unless the old Konfiguration being updated already set it, so Konfigurations
+// created before it was rejected can still be updated.
+func (k *Konfiguration) ValidateKubeConfig(old runtime.Object) error {
+	if k.Spec.KubeConfig == nil {
+		return nil
+	}
+	if o, ok := old.(*Konfiguration); ok && o.Spec.KubeConfig != nil {
+		return nil
+	}
+	return fmt.Errorf("kubeConfig: deprecated and ignored, set targets to apply the manifests to remote clusters")
+}
+
+// ValidateDeletes returns an error if a deletion names an object outside of
+// the namespace the manifests are rendered into and the namespace of the
+// Konfiguration.
+func (k *Konfiguration) ValidateDeletes() error {
+	for _, deletion := range k.Spec.Deletes {
+		if deletion.Namespace != "" && deletion.Namespace != k.GetTargetNamespace() && deletion.Namespace != k.GetNamespace() {
+			return fmt.Errorf("deletes: %s '%s/%s' is outside of the namespaces of the Konfiguration", deletion.Kind, deletion.Namespace, deletion.Name)
+		}
+	}
+	return nil
+}
+
+// ValidatePruneOptions returns an error if a propagation policy is not one of
+// the deletion propagation policies of the API server.
+func (k *Konfiguration) ValidatePruneOptions() error {
+	if k.Spec.PruneOptions == nil {
+		return nil
+	}
+	for _, p := range k.Spec.PruneOptions.PropagationPolicy {
+		switch p.Policy {
+		case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+		default:
+			return fmt.Errorf("pruneOptions.propagationPolicy: unknown policy '%s' for kind '%s'", p.Policy, p.Kind)
+		}
+	}
+	return nil
+}
+
+// ValidateDependsOn returns an error if a dependency sets both a revision and
+// sameRevisionAs.
+func (k *Konfiguration) ValidateDependsOn() error {
+	for _, dep := range k.Spec.DependsOn {
+		if dep.Revision != "" && dep.SameRevisionAs {
+			return fmt.Errorf("dependsOn: '%s' cannot set both revision and sameRevisionAs", dep.CrossNamespaceDependencyReference)
+		}
+	}
+	return nil
+}
+
+// ValidateFeatureGates returns an error if FeatureGates holds unknown gates,
+// or enables in-process evaluation along with resolving images.
+func (k *Konfiguration) ValidateFeatureGates() error {
+	for gate := range k.Spec.FeatureGates {
+		if !knownFeatureGates[gate] {
+			return fmt.Errorf("featureGates: unknown feature gate '%s'", gate)
+		}
+	}
+	if k.FeatureGateEnabled(FeatureGateInProcessEvaluation) && k.Spec.Kubecfg != nil && k.Spec.Kubecfg.ResolveImages == "registry" {
+		return fmt.Errorf("featureGates: %s cannot resolve images", FeatureGateInProcessEvaluation)
+	}
+	return nil
+}
+
+// ValidateKubecfgArgs returns an error if KubecfgArgs holds flags that are not
+// allowed, or arguments that are not flags.
+func (k *Konfiguration) ValidateKubecfgArgs() error {
+	args := k.Spec.KubecfgArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("kubecfgArgs: unexpected argument '%s'", arg)
+		}
+		name := strings.TrimLeft(arg, "-")
+		hasValue := false
+		if idx := strings.Index(name, "="); idx != -1 {
+			name, hasValue = name[:idx], true
+		}
+		takesValue, ok := allowedKubecfgArgs[name]
+		if !ok {
+			return fmt.Errorf("kubecfgArgs: flag '%s' is not allowed", arg)
+		}
+		// Skip the value if it is passed as the next argument
+		if takesValue && !hasValue {
+			i++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateDeletes(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		wantErr   bool
+	}{
+		{name: "default namespace"},
+		{name: "namespace of the Konfiguration", namespace: "team"},
+		{name: "other namespace", namespace: "kube-system", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.SetNamespace("team")
+			k.SetName("app")
+			k.Spec.Deletes = []ObjectDeletion{{APIVersion: "v1", Kind: "ConfigMap", Namespace: tt.namespace, Name: "old"}}
+			if err := k.ValidateDeletes(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDeletes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecInterval(t *testing.T) {
+	tests := []struct {
+		name           string
+		interval       time.Duration
+		artifactSource *ArtifactSource
+		wantErr        bool
+	}{
+		{name: "event-driven"},
+		{name: "artifact source", interval: time.Minute, artifactSource: &ArtifactSource{URL: "http://files/app.tar.gz"}},
+		{name: "event-driven artifact source", artifactSource: &ArtifactSource{URL: "http://files/app.tar.gz"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.Spec.Interval = metav1.Duration{Duration: tt.interval}
+			k.Spec.ArtifactSource = tt.artifactSource
+			if err := k.ValidateSpec(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFeatureGates(t *testing.T) {
+	tests := []struct {
+		name          string
+		gates         map[string]bool
+		resolveImages string
+		wantErr       bool
+	}{
+		{name: "known gates", gates: map[string]bool{FeatureGateInProcessEvaluation: true, FeatureGateIncrementalApply: true}},
+		{name: "unknown gate", gates: map[string]bool{"Unknown": true}, wantErr: true},
+		{name: "in-process evaluation without resolving images", gates: map[string]bool{FeatureGateInProcessEvaluation: true}, resolveImages: "noop"},
+		{name: "in-process evaluation resolving images", gates: map[string]bool{FeatureGateInProcessEvaluation: true}, resolveImages: "registry", wantErr: true},
+		{name: "resolving images with kubecfg", gates: map[string]bool{FeatureGateInProcessEvaluation: false}, resolveImages: "registry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.Spec.FeatureGates = tt.gates
+			k.Spec.Kubecfg = &KubecfgOptions{ResolveImages: tt.resolveImages}
+			if err := k.ValidateFeatureGates(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFeatureGates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePruneOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  metav1.DeletionPropagation
+		wantErr bool
+	}{
+		{name: "foreground", policy: metav1.DeletePropagationForeground},
+		{name: "orphan", policy: metav1.DeletePropagationOrphan},
+		{name: "unknown", policy: "Cascade", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			k.Spec.PruneOptions = &PruneOptions{PropagationPolicy: []PropagationPolicy{{Kind: "StatefulSet", Policy: tt.policy}}}
+			if err := k.ValidatePruneOptions(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePruneOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateKubeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     bool
+		old     *Konfiguration
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "set on create", set: true, wantErr: true},
+		{name: "newly set on update", set: true, old: &Konfiguration{}, wantErr: true},
+		{name: "already set before update", set: true, old: &Konfiguration{Spec: KonfigurationSpec{KubeConfig: &KubeConfig{}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Konfiguration{}
+			if tt.set {
+				k.Spec.KubeConfig = &KubeConfig{}
+			}
+			var err error
+			if tt.old != nil {
+				err = k.ValidateUpdate(tt.old)
+			} else {
+				err = k.ValidateCreate()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
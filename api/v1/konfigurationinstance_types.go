@@ -0,0 +1,84 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KonfigurationInstanceSpec defines the desired state of KonfigurationInstance
+type KonfigurationInstanceSpec struct {
+	// TemplateName is the name of the KonfigurationTemplate to instantiate.
+	// +required
+	TemplateName string `json:"templateName"`
+
+	// Parameters are the values of the parameters of the template.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Parameters *apiextensionsv1.JSON `json:"parameters,omitempty"`
+}
+
+// KonfigurationInstanceStatus defines the observed state of
+// KonfigurationInstance
+type KonfigurationInstanceStatus struct {
+	// ObservedGeneration is the last reconciled generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the Ready condition of the instance, which mirrors
+	// that of its Konfiguration once it is created.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Template",type="string",JSONPath=".spec.templateName"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// KonfigurationInstance is the Schema for the konfigurationinstances API. It
+// binds values to the parameters of a KonfigurationTemplate, and owns the
+// Konfiguration of the same name created from it.
+type KonfigurationInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KonfigurationInstanceSpec   `json:"spec,omitempty"`
+	Status KonfigurationInstanceStatus `json:"status,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (i *KonfigurationInstance) GetStatusConditions() *[]metav1.Condition {
+	return &i.Status.Conditions
+}
+
+//+kubebuilder:object:root=true
+
+// KonfigurationInstanceList contains a list of KonfigurationInstance
+type KonfigurationInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KonfigurationInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KonfigurationInstance{}, &KonfigurationInstanceList{})
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KonfigurationReportSpec defines the desired state of KonfigurationReport
+type KonfigurationReportSpec struct {
+	// Selector restricts the report to Konfigurations with matching labels.
+	// Defaults to all Konfigurations in the cluster.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// KonfigurationReportStatus defines the observed state of KonfigurationReport
+type KonfigurationReportStatus struct {
+	// Total is the number of Konfigurations covered by the report.
+	// +optional
+	Total int `json:"total"`
+
+	// Ready is the number of Konfigurations whose last reconciliation
+	// succeeded.
+	// +optional
+	Ready int `json:"ready"`
+
+	// NotReady is the number of Konfigurations whose last reconciliation
+	// failed.
+	// +optional
+	NotReady int `json:"notReady"`
+
+	// Suspended is the number of suspended Konfigurations.
+	// +optional
+	Suspended int `json:"suspended"`
+
+	// FailureReasons counts the NotReady Konfigurations by the reason of
+	// their Ready condition.
+	// +optional
+	FailureReasons map[string]int `json:"failureReasons,omitempty"`
+
+	// Konfigurations summarizes the Konfigurations covered by the report, up
+	// to MaxReportEntries of them. Konfigurations that are not ready are
+	// listed first, so they are the last to be left out.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Konfigurations []KonfigurationSummary `json:"konfigurations,omitempty"`
+
+	// Omitted is the number of Konfigurations covered by the report but left
+	// out of Konfigurations.
+	// +optional
+	Omitted int `json:"omitted,omitempty"`
+
+	// LastUpdateTime is the last time the report was updated.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// MaxReportEntries is the maximum number of Konfigurations summarized in the
+// status of a KonfigurationReport.
+const MaxReportEntries = 100
+
+// KonfigurationSummary holds the status of a single Konfiguration in a
+// KonfigurationReport.
+type KonfigurationSummary struct {
+	// Namespace of the Konfiguration.
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Name of the Konfiguration.
+	// +required
+	Name string `json:"name"`
+
+	// Ready is the status of the Ready condition of the Konfiguration.
+	// +optional
+	Ready metav1.ConditionStatus `json:"ready,omitempty"`
+
+	// Reason is the reason of the Ready condition of the Konfiguration.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Suspended is true if the Konfiguration is suspended.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// LastAppliedRevision is the last successfully applied revision.
+	// +optional
+	LastAppliedRevision string `json:"lastAppliedRevision,omitempty"`
+
+	// LastAttemptedRevision is the revision of the last reconciliation
+	// attempt.
+	// +optional
+	LastAttemptedRevision string `json:"lastAttemptedRevision,omitempty"`
+}
+
+//+genclient
+//+genclient:nonNamespaced
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+//+kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.ready"
+//+kubebuilder:printcolumn:name="NotReady",type="integer",JSONPath=".status.notReady"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// KonfigurationReport is the Schema for the konfigurationreports API. It
+// aggregates the status of Konfigurations across the cluster.
+type KonfigurationReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KonfigurationReportSpec   `json:"spec,omitempty"`
+	Status KonfigurationReportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KonfigurationReportList contains a list of KonfigurationReport
+type KonfigurationReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KonfigurationReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KonfigurationReport{}, &KonfigurationReportList{})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KonfigurationTemplateSpec defines the desired state of KonfigurationTemplate
+type KonfigurationTemplateSpec struct {
+	// Parameters is the OpenAPI v3 schema of the parameters of the template,
+	// which must be of type object. The parameters bound by an instance are
+	// validated against it and defaulted from it, and each of its properties
+	// is passed to the entrypoint as a top level argument of the same name.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Parameters *apiextensionsv1.JSONSchemaProps `json:"parameters,omitempty"`
+
+	// Konfiguration is the spec of the Konfigurations created for the
+	// instances of the template, in their namespace.
+	// +required
+	Konfiguration KonfigurationSpec `json:"konfiguration"`
+}
+
+//+genclient
+//+genclient:nonNamespaced
+//+genclient:noStatus
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// KonfigurationTemplate is the Schema for the konfigurationtemplates API. It
+// offers a vetted Konfiguration to be instantiated with validated parameters
+// by KonfigurationInstances.
+type KonfigurationTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KonfigurationTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KonfigurationTemplateList contains a list of KonfigurationTemplate
+type KonfigurationTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KonfigurationTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KonfigurationTemplate{}, &KonfigurationTemplateList{})
+}
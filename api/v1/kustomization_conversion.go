@@ -0,0 +1,159 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	"github.com/fluxcd/pkg/runtime/dependency"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// KustomizationKind is the kind of Flux Kustomizations.
+const KustomizationKind string = "Kustomization"
+
+// kustomizationTargetName is the name of the target a Kustomization applying
+// to a remote cluster is converted to.
+const kustomizationTargetName = "remote"
+
+// kustomization holds the fields of a Flux Kustomization that are converted to
+// a Konfiguration. It is decoded from unstructured content, so the converter
+// does not depend on a specific release of the Flux APIs.
+// +kubebuilder:object:generate=false
+type kustomization struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		DependsOn     []dependency.CrossNamespaceDependencyReference `json:"dependsOn,omitempty"`
+		Interval      metav1.Duration                                `json:"interval"`
+		RetryInterval *metav1.Duration                               `json:"retryInterval,omitempty"`
+		KubeConfig    *struct {
+			SecretRef corev1.LocalObjectReference `json:"secretRef"`
+		} `json:"kubeConfig,omitempty"`
+		Path            string           `json:"path,omitempty"`
+		Prune           bool             `json:"prune"`
+		HealthChecks    []interface{}    `json:"healthChecks,omitempty"`
+		Wait            bool             `json:"wait,omitempty"`
+		Suspend         bool             `json:"suspend,omitempty"`
+		Timeout         *metav1.Duration `json:"timeout,omitempty"`
+		Validation      string           `json:"validation,omitempty"`
+		TargetNamespace string           `json:"targetNamespace,omitempty"`
+		SourceRef       struct {
+			APIVersion string `json:"apiVersion,omitempty"`
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Namespace  string `json:"namespace,omitempty"`
+		} `json:"sourceRef"`
+		PostBuild *struct {
+			Substitute map[string]string `json:"substitute,omitempty"`
+		} `json:"postBuild,omitempty"`
+		ServiceAccountName string        `json:"serviceAccountName,omitempty"`
+		Patches            []interface{} `json:"patches,omitempty"`
+		PatchesStrategic   []interface{} `json:"patchesStrategicMerge,omitempty"`
+		PatchesJSON6902    []interface{} `json:"patchesJson6902,omitempty"`
+		Images             []interface{} `json:"images,omitempty"`
+		Decryption         interface{}   `json:"decryption,omitempty"`
+	} `json:"spec"`
+}
+
+// KonfigurationFromKustomization generates a Konfiguration equivalent to the
+// given Flux Kustomization, to ease the migration of kustomize overlays to
+// Jsonnet. The source, intervals, timeout, prune, suspend and dependencies are
+// carried over, a kubeconfig becomes the single target of the Konfiguration,
+// health checks are approximated by reading back the applied objects, and
+// post-build substitutions become external string variables. Settings that
+// have no equivalent are returned as warnings. The path is kept as is, but
+// must be changed to point at an entrypoint of the converted manifests. The
+// controller binary runs it with --convert-kustomization.
+func KonfigurationFromKustomization(obj *unstructured.Unstructured) (*Konfiguration, []string, error) {
+	if obj.GetKind() != KustomizationKind || obj.GroupVersionKind().Group != "kustomize.toolkit.fluxcd.io" {
+		return nil, nil, fmt.Errorf("expected a Flux Kustomization, got %s", obj.GroupVersionKind())
+	}
+	var ks kustomization
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ks); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode Kustomization '%s/%s': %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	var dependsOn []DependencyReference
+	for _, dep := range ks.Spec.DependsOn {
+		dependsOn = append(dependsOn, DependencyReference{CrossNamespaceDependencyReference: dep})
+	}
+
+	konfig := &Konfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ks.GetName(),
+			Namespace:   ks.GetNamespace(),
+			Labels:      ks.GetLabels(),
+			Annotations: ks.GetAnnotations(),
+		},
+		Spec: KonfigurationSpec{
+			DependsOn:     dependsOn,
+			Interval:      ks.Spec.Interval,
+			RetryInterval: ks.Spec.RetryInterval,
+			Path:          ks.Spec.Path,
+			Prune:         ks.Spec.Prune,
+			Suspend:       ks.Spec.Suspend,
+			Timeout:       ks.Spec.Timeout,
+			Validate:      ks.Spec.Validation != "none",
+			VerifyApplied: ks.Spec.Wait || len(ks.Spec.HealthChecks) != 0,
+			SourceRef: &CrossNamespaceSourceReference{
+				APIVersion: ks.Spec.SourceRef.APIVersion,
+				Kind:       ks.Spec.SourceRef.Kind,
+				Name:       ks.Spec.SourceRef.Name,
+				Namespace:  ks.Spec.SourceRef.Namespace,
+			},
+		},
+	}
+	konfig.SetGroupVersionKind(GroupVersion.WithKind("Konfiguration"))
+	if ks.Spec.KubeConfig != nil {
+		konfig.Spec.Targets = []Target{{
+			Name:       kustomizationTargetName,
+			KubeConfig: KubeConfig{SecretRef: ks.Spec.KubeConfig.SecretRef},
+		}}
+	}
+	if ks.Spec.PostBuild != nil && len(ks.Spec.PostBuild.Substitute) != 0 {
+		konfig.Spec.Variables = &Variables{ExtStr: ks.Spec.PostBuild.Substitute}
+	}
+
+	var warnings []string
+	if len(ks.Spec.HealthChecks) != 0 || ks.Spec.Wait {
+		warnings = append(warnings, "health checks are approximated by verifyApplied, which only waits for the applied objects to be readable")
+	}
+	if ks.Spec.PostBuild != nil && len(ks.Spec.PostBuild.Substitute) != 0 {
+		warnings = append(warnings, "post-build substitutions were converted to external string variables, read them with std.extVar")
+	}
+	if ks.Spec.KubeConfig != nil {
+		warnings = append(warnings, fmt.Sprintf("kubeConfig was converted to the target '%s', its Secret must hold the kubeconfig under the 'value' key",
+			kustomizationTargetName))
+	}
+	if ks.Spec.TargetNamespace != "" {
+		warnings = append(warnings, "targetNamespace is not supported, objects are applied to the namespace of the Konfiguration unless they set one")
+	}
+	if ks.Spec.ServiceAccountName != "" {
+		warnings = append(warnings, "serviceAccountName is not supported, use a target whose kubeConfig sets inClusterWithOverrides with the token of the service account instead")
+	}
+	if len(ks.Spec.Patches) != 0 || len(ks.Spec.PatchesStrategic) != 0 || len(ks.Spec.PatchesJSON6902) != 0 || len(ks.Spec.Images) != 0 {
+		warnings = append(warnings, "patches and image overrides are not supported, apply them in the Jsonnet sources instead")
+	}
+	if ks.Spec.Decryption != nil {
+		warnings = append(warnings, "decryption is not supported, resolve secrets with extStrFromSecretProvider instead")
+	}
+	warnings = append(warnings, fmt.Sprintf("path '%s' must be changed to the Jsonnet, YAML or JSON entrypoint of the converted manifests", ks.Spec.Path))
+	return konfig, warnings, nil
+}
@@ -82,6 +82,19 @@ func NewSnapshot(manifests []byte, checksum string) (*Snapshot, error) {
 	return &snapshot, nil
 }
 
+// NewSnapshotForObjects returns the snapshot of the given objects, which must
+// not be Lists.
+func NewSnapshotForObjects(objs []*unstructured.Unstructured, checksum string) *Snapshot {
+	snapshot := Snapshot{
+		Checksum: checksum,
+		Entries:  []SnapshotEntry{},
+	}
+	for _, obj := range objs {
+		snapshot.addEntry(obj)
+	}
+	return &snapshot
+}
+
 func (s *Snapshot) addEntry(item *unstructured.Unstructured) {
 	found := false
 	for _, tracker := range s.Entries {
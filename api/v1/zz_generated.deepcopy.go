@@ -21,175 +21,1182 @@ limitations under the License.
 package v1
 
 import (
-	"github.com/fluxcd/pkg/runtime/dependency"
+	"github.com/fluxcd/source-controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveInterval) DeepCopyInto(out *AdaptiveInterval) {
+	*out = *in
+	out.Max = in.Max
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveInterval.
+func (in *AdaptiveInterval) DeepCopy() *AdaptiveInterval {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveInterval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyOptions) DeepCopyInto(out *ApplyOptions) {
+	*out = *in
+	if in.OverrideManagers != nil {
+		in, out := &in.OverrideManagers, &out.OverrideManagers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyOptions.
+func (in *ApplyOptions) DeepCopy() *ApplyOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyRecord) DeepCopyInto(out *ApplyRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.ChangedPaths != nil {
+		in, out := &in.ChangedPaths, &out.ChangedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyRecord.
+func (in *ApplyRecord) DeepCopy() *ApplyRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactSource) DeepCopyInto(out *ArtifactSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactSource.
+func (in *ArtifactSource) DeepCopy() *ArtifactSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreaker) DeepCopyInto(out *CircuitBreaker) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreaker.
+func (in *CircuitBreaker) DeepCopy() *CircuitBreaker {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreaker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyRef) DeepCopyInto(out *ConfigMapKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyRef.
+func (in *ConfigMapKeyRef) DeepCopy() *ConfigMapKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CordonedObject) DeepCopyInto(out *CordonedObject) {
+	*out = *in
+	out.InventoryEntry = in.InventoryEntry
+	in.CordonedAt.DeepCopyInto(&out.CordonedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CordonedObject.
+func (in *CordonedObject) DeepCopy() *CordonedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(CordonedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CrossNamespaceSourceReference) DeepCopyInto(out *CrossNamespaceSourceReference) {
 	*out = *in
+	if in.Ref != nil {
+		in, out := &in.Ref, &out.Ref
+		*out = new(v1beta1.GitRepositoryRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrossNamespaceSourceReference.
+func (in *CrossNamespaceSourceReference) DeepCopy() *CrossNamespaceSourceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CrossNamespaceSourceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencyReference) DeepCopyInto(out *DependencyReference) {
+	*out = *in
+	out.CrossNamespaceDependencyReference = in.CrossNamespaceDependencyReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencyReference.
+func (in *DependencyReference) DeepCopy() *DependencyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventSink) DeepCopyInto(out *EventSink) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSink.
+func (in *EventSink) DeepCopy() *EventSink {
+	if in == nil {
+		return nil
+	}
+	out := new(EventSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hibernation) DeepCopyInto(out *Hibernation) {
+	*out = *in
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]HibernationSchedule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hibernation.
+func (in *Hibernation) DeepCopy() *Hibernation {
+	if in == nil {
+		return nil
+	}
+	out := new(Hibernation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HibernationSchedule) DeepCopyInto(out *HibernationSchedule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HibernationSchedule.
+func (in *HibernationSchedule) DeepCopy() *HibernationSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(HibernationSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InClusterOverrides) DeepCopyInto(out *InClusterOverrides) {
+	*out = *in
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	out.TokenSecretRef = in.TokenSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InClusterOverrides.
+func (in *InClusterOverrides) DeepCopy() *InClusterOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(InClusterOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Inventory) DeepCopyInto(out *Inventory) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]InventoryEntry, len(*in))
+		copy(*out, *in)
+	}
+	if in.Digests != nil {
+		in, out := &in.Digests, &out.Digests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = make([]ObjectHealth, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Inventory.
+func (in *Inventory) DeepCopy() *Inventory {
+	if in == nil {
+		return nil
+	}
+	out := new(Inventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryEntry) DeepCopyInto(out *InventoryEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryEntry.
+func (in *InventoryEntry) DeepCopy() *InventoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Konfiguration) DeepCopyInto(out *Konfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Konfiguration.
+func (in *Konfiguration) DeepCopy() *Konfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(Konfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Konfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationInstance) DeepCopyInto(out *KonfigurationInstance) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationInstance.
+func (in *KonfigurationInstance) DeepCopy() *KonfigurationInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationInstance) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationInstanceList) DeepCopyInto(out *KonfigurationInstanceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KonfigurationInstance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationInstanceList.
+func (in *KonfigurationInstanceList) DeepCopy() *KonfigurationInstanceList {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationInstanceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationInstanceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationInstanceSpec) DeepCopyInto(out *KonfigurationInstanceSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationInstanceSpec.
+func (in *KonfigurationInstanceSpec) DeepCopy() *KonfigurationInstanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationInstanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationInstanceStatus) DeepCopyInto(out *KonfigurationInstanceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationInstanceStatus.
+func (in *KonfigurationInstanceStatus) DeepCopy() *KonfigurationInstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationInstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationList) DeepCopyInto(out *KonfigurationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Konfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationList.
+func (in *KonfigurationList) DeepCopy() *KonfigurationList {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationReport) DeepCopyInto(out *KonfigurationReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationReport.
+func (in *KonfigurationReport) DeepCopy() *KonfigurationReport {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationReportList) DeepCopyInto(out *KonfigurationReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KonfigurationReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationReportList.
+func (in *KonfigurationReportList) DeepCopy() *KonfigurationReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationReportSpec) DeepCopyInto(out *KonfigurationReportSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationReportSpec.
+func (in *KonfigurationReportSpec) DeepCopy() *KonfigurationReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationReportStatus) DeepCopyInto(out *KonfigurationReportStatus) {
+	*out = *in
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Konfigurations != nil {
+		in, out := &in.Konfigurations, &out.Konfigurations
+		*out = make([]KonfigurationSummary, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationReportStatus.
+func (in *KonfigurationReportStatus) DeepCopy() *KonfigurationReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationSpec) DeepCopyInto(out *KonfigurationSpec) {
+	*out = *in
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]DependencyReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Interval = in.Interval
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AdaptiveInterval != nil {
+		in, out := &in.AdaptiveInterval, &out.AdaptiveInterval
+		*out = new(AdaptiveInterval)
+		**out = **in
+	}
+	if in.KubeConfig != nil {
+		in, out := &in.KubeConfig, &out.KubeConfig
+		*out = new(KubeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]Target, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]HealthCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Entrypoints != nil {
+		in, out := &in.Entrypoints, &out.Entrypoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = new(Variables)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JsonnetLibRefs != nil {
+		in, out := &in.JsonnetLibRefs, &out.JsonnetLibRefs
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lint != nil {
+		in, out := &in.Lint, &out.Lint
+		*out = new(Lint)
+		**out = **in
+	}
+	if in.SourceRef != nil {
+		in, out := &in.SourceRef, &out.SourceRef
+		*out = new(CrossNamespaceSourceReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ArtifactSource != nil {
+		in, out := &in.ArtifactSource, &out.ArtifactSource
+		*out = new(ArtifactSource)
+		**out = **in
+	}
+	if in.SparsePaths != nil {
+		in, out := &in.SparsePaths, &out.SparsePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RevisionSelector != nil {
+		in, out := &in.RevisionSelector, &out.RevisionSelector
+		*out = new(RevisionSelector)
+		**out = **in
+	}
+	if in.PruneOptions != nil {
+		in, out := &in.PruneOptions, &out.PruneOptions
+		*out = new(PruneOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deletes != nil {
+		in, out := &in.Deletes, &out.Deletes
+		*out = make([]ObjectDeletion, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Kubecfg != nil {
+		in, out := &in.Kubecfg, &out.Kubecfg
+		*out = new(KubecfgOptions)
+		**out = **in
+	}
+	if in.Apply != nil {
+		in, out := &in.Apply, &out.Apply
+		*out = new(ApplyOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubecfgArgs != nil {
+		in, out := &in.KubecfgArgs, &out.KubecfgArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transformers != nil {
+		in, out := &in.Transformers, &out.Transformers
+		*out = make([]Transformer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SkipKinds != nil {
+		in, out := &in.SkipKinds, &out.SkipKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Preview != nil {
+		in, out := &in.Preview, &out.Preview
+		*out = new(Preview)
+		**out = **in
+	}
+	if in.EventSink != nil {
+		in, out := &in.EventSink, &out.EventSink
+		*out = new(EventSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hibernation != nil {
+		in, out := &in.Hibernation, &out.Hibernation
+		*out = new(Hibernation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(CircuitBreaker)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Preconditions != nil {
+		in, out := &in.Preconditions, &out.Preconditions
+		*out = make([]Precondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TestHooks != nil {
+		in, out := &in.TestHooks, &out.TestHooks
+		*out = new(TestHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationSpec.
+func (in *KonfigurationSpec) DeepCopy() *KonfigurationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationStatus) DeepCopyInto(out *KonfigurationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]TargetStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.InventoryRef != nil {
+		in, out := &in.InventoryRef, &out.InventoryRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ManifestsRef != nil {
+		in, out := &in.ManifestsRef, &out.ManifestsRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.RemainingObjects != nil {
+		in, out := &in.RemainingObjects, &out.RemainingObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedObjects != nil {
+		in, out := &in.SkippedObjects, &out.SkippedObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PausedObjects != nil {
+		in, out := &in.PausedObjects, &out.PausedObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CordonedObjects != nil {
+		in, out := &in.CordonedObjects, &out.CordonedObjects
+		*out = make([]CordonedObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnformattedFiles != nil {
+		in, out := &in.UnformattedFiles, &out.UnformattedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastApply != nil {
+		in, out := &in.LastApply, &out.LastApply
+		*out = new(ApplyRecord)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastRunSummary != nil {
+		in, out := &in.LastRunSummary, &out.LastRunSummary
+		*out = new(RunSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RunRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EffectiveInterval != nil {
+		in, out := &in.EffectiveInterval, &out.EffectiveInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(Snapshot)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationStatus.
+func (in *KonfigurationStatus) DeepCopy() *KonfigurationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationSummary) DeepCopyInto(out *KonfigurationSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationSummary.
+func (in *KonfigurationSummary) DeepCopy() *KonfigurationSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationTemplate) DeepCopyInto(out *KonfigurationTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationTemplate.
+func (in *KonfigurationTemplate) DeepCopy() *KonfigurationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationTemplateList) DeepCopyInto(out *KonfigurationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KonfigurationTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationTemplateList.
+func (in *KonfigurationTemplateList) DeepCopy() *KonfigurationTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KonfigurationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KonfigurationTemplateSpec) DeepCopyInto(out *KonfigurationTemplateSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = new(apiextensionsv1.JSONSchemaProps)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Konfiguration.DeepCopyInto(&out.Konfiguration)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationTemplateSpec.
+func (in *KonfigurationTemplateSpec) DeepCopy() *KonfigurationTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KonfigurationTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeConfig) DeepCopyInto(out *KubeConfig) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	if in.InClusterWithOverrides != nil {
+		in, out := &in.InClusterWithOverrides, &out.InClusterWithOverrides
+		*out = new(InClusterOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeConfig.
+func (in *KubeConfig) DeepCopy() *KubeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubecfgOptions) DeepCopyInto(out *KubecfgOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubecfgOptions.
+func (in *KubecfgOptions) DeepCopy() *KubecfgOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(KubecfgOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Lint) DeepCopyInto(out *Lint) {
+	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrossNamespaceSourceReference.
-func (in *CrossNamespaceSourceReference) DeepCopy() *CrossNamespaceSourceReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Lint.
+func (in *Lint) DeepCopy() *Lint {
 	if in == nil {
 		return nil
 	}
-	out := new(CrossNamespaceSourceReference)
+	out := new(Lint)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Konfiguration) DeepCopyInto(out *Konfiguration) {
+func (in *ObjectDeletion) DeepCopyInto(out *ObjectDeletion) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Konfiguration.
-func (in *Konfiguration) DeepCopy() *Konfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectDeletion.
+func (in *ObjectDeletion) DeepCopy() *ObjectDeletion {
 	if in == nil {
 		return nil
 	}
-	out := new(Konfiguration)
+	out := new(ObjectDeletion)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Konfiguration) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectHealth) DeepCopyInto(out *ObjectHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectHealth.
+func (in *ObjectHealth) DeepCopy() *ObjectHealth {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(ObjectHealth)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KonfigurationList) DeepCopyInto(out *KonfigurationList) {
+func (in *Precondition) DeepCopyInto(out *Precondition) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Konfiguration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationList.
-func (in *KonfigurationList) DeepCopy() *KonfigurationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Precondition.
+func (in *Precondition) DeepCopy() *Precondition {
 	if in == nil {
 		return nil
 	}
-	out := new(KonfigurationList)
+	out := new(Precondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KonfigurationList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Preview) DeepCopyInto(out *Preview) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Preview.
+func (in *Preview) DeepCopy() *Preview {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(Preview)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KonfigurationSpec) DeepCopyInto(out *KonfigurationSpec) {
+func (in *PropagationPolicy) DeepCopyInto(out *PropagationPolicy) {
 	*out = *in
-	if in.DependsOn != nil {
-		in, out := &in.DependsOn, &out.DependsOn
-		*out = make([]dependency.CrossNamespaceDependencyReference, len(*in))
-		copy(*out, *in)
-	}
-	out.Interval = in.Interval
-	if in.RetryInterval != nil {
-		in, out := &in.RetryInterval, &out.RetryInterval
-		*out = new(metav1.Duration)
-		**out = **in
-	}
-	if in.KubeConfig != nil {
-		in, out := &in.KubeConfig, &out.KubeConfig
-		*out = new(KubeConfig)
-		**out = **in
-	}
-	if in.Variables != nil {
-		in, out := &in.Variables, &out.Variables
-		*out = new(Variables)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationPolicy.
+func (in *PropagationPolicy) DeepCopy() *PropagationPolicy {
+	if in == nil {
+		return nil
 	}
-	if in.SourceRef != nil {
-		in, out := &in.SourceRef, &out.SourceRef
-		*out = new(CrossNamespaceSourceReference)
-		**out = **in
+	out := new(PropagationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PruneOptions) DeepCopyInto(out *PruneOptions) {
+	*out = *in
+	if in.PropagationPolicy != nil {
+		in, out := &in.PropagationPolicy, &out.PropagationPolicy
+		*out = make([]PropagationPolicy, len(*in))
+		copy(*out, *in)
 	}
-	if in.Timeout != nil {
-		in, out := &in.Timeout, &out.Timeout
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
 		*out = new(metav1.Duration)
 		**out = **in
 	}
-	if in.KubecfgArgs != nil {
-		in, out := &in.KubecfgArgs, &out.KubecfgArgs
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PruneOptions.
+func (in *PruneOptions) DeepCopy() *PruneOptions {
+	if in == nil {
+		return nil
 	}
+	out := new(PruneOptions)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationSpec.
-func (in *KonfigurationSpec) DeepCopy() *KonfigurationSpec {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionSelector) DeepCopyInto(out *RevisionSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionSelector.
+func (in *RevisionSelector) DeepCopy() *RevisionSelector {
 	if in == nil {
 		return nil
 	}
-	out := new(KonfigurationSpec)
+	out := new(RevisionSelector)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KonfigurationStatus) DeepCopyInto(out *KonfigurationStatus) {
+func (in *RunRecord) DeepCopyInto(out *RunRecord) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunRecord.
+func (in *RunRecord) DeepCopy() *RunRecord {
+	if in == nil {
+		return nil
 	}
-	if in.Snapshot != nil {
-		in, out := &in.Snapshot, &out.Snapshot
-		*out = new(Snapshot)
-		(*in).DeepCopyInto(*out)
+	out := new(RunRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSummary) DeepCopyInto(out *RunSummary) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]StageDuration, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KonfigurationStatus.
-func (in *KonfigurationStatus) DeepCopy() *KonfigurationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSummary.
+func (in *RunSummary) DeepCopy() *RunSummary {
 	if in == nil {
 		return nil
 	}
-	out := new(KonfigurationStatus)
+	out := new(RunSummary)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeConfig) DeepCopyInto(out *KubeConfig) {
+func (in *SecretProviderRef) DeepCopyInto(out *SecretProviderRef) {
 	*out = *in
-	out.SecretRef = in.SecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeConfig.
-func (in *KubeConfig) DeepCopy() *KubeConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderRef.
+func (in *SecretProviderRef) DeepCopy() *SecretProviderRef {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeConfig)
+	out := new(SecretProviderRef)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -238,6 +1245,100 @@ func (in *SnapshotEntry) DeepCopy() *SnapshotEntry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageDuration) DeepCopyInto(out *StageDuration) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageDuration.
+func (in *StageDuration) DeepCopy() *StageDuration {
+	if in == nil {
+		return nil
+	}
+	out := new(StageDuration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Target) DeepCopyInto(out *Target) {
+	*out = *in
+	in.KubeConfig.DeepCopyInto(&out.KubeConfig)
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = new(Variables)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Target.
+func (in *Target) DeepCopy() *Target {
+	if in == nil {
+		return nil
+	}
+	out := new(Target)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetStatus) DeepCopyInto(out *TargetStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetStatus.
+func (in *TargetStatus) DeepCopy() *TargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestHooks) DeepCopyInto(out *TestHooks) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestHooks.
+func (in *TestHooks) DeepCopy() *TestHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(TestHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Transformer) DeepCopyInto(out *Transformer) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transformer.
+func (in *Transformer) DeepCopy() *Transformer {
+	if in == nil {
+		return nil
+	}
+	out := new(Transformer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Variables) DeepCopyInto(out *Variables) {
 	*out = *in
@@ -269,6 +1370,76 @@ func (in *Variables) DeepCopyInto(out *Variables) {
 			(*out)[key] = val
 		}
 	}
+	if in.ExtStrFiles != nil {
+		in, out := &in.ExtStrFiles, &out.ExtStrFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExtCodeFiles != nil {
+		in, out := &in.ExtCodeFiles, &out.ExtCodeFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLAStrFiles != nil {
+		in, out := &in.TLAStrFiles, &out.TLAStrFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLACodeFiles != nil {
+		in, out := &in.TLACodeFiles, &out.TLACodeFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExtStrFromConfigMaps != nil {
+		in, out := &in.ExtStrFromConfigMaps, &out.ExtStrFromConfigMaps
+		*out = make(map[string]ConfigMapKeyRef, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExtCodeFromConfigMaps != nil {
+		in, out := &in.ExtCodeFromConfigMaps, &out.ExtCodeFromConfigMaps
+		*out = make(map[string]ConfigMapKeyRef, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLAStrFromConfigMaps != nil {
+		in, out := &in.TLAStrFromConfigMaps, &out.TLAStrFromConfigMaps
+		*out = make(map[string]ConfigMapKeyRef, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLACodeFromConfigMaps != nil {
+		in, out := &in.TLACodeFromConfigMaps, &out.TLACodeFromConfigMaps
+		*out = make(map[string]ConfigMapKeyRef, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExtStrFromSecretProvider != nil {
+		in, out := &in.ExtStrFromSecretProvider, &out.ExtStrFromSecretProvider
+		*out = make(map[string]SecretProviderRef, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Sealed != nil {
+		in, out := &in.Sealed, &out.Sealed
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Variables.
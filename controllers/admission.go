@@ -0,0 +1,233 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+const (
+	// renderWebhookPath is the path the admission render check is served on.
+	renderWebhookPath = "/validate-apps-kubecfg-io-v1-konfiguration-render"
+	// admissionRenderTimeout bounds the admission render check, so it fits
+	// within the default timeout of the API server for webhooks.
+	admissionRenderTimeout = 7 * time.Second
+	// admissionRenderMaxSize bounds the size of the manifests rendered by the
+	// admission render check.
+	admissionRenderMaxSize = 8 * 1024 * 1024
+)
+
+// errRenderTooLarge is returned when the manifests rendered at admission
+// exceed admissionRenderMaxSize.
+var errRenderTooLarge = errors.New("rendered manifests exceed the admission size limit")
+
+// SetupRenderWebhookWithManager registers the admission render check for
+// Konfigurations with the manager. The reconciler must be set up first.
+func (r *KonfigurationReconciler) SetupRenderWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(renderWebhookPath, &webhook.Admission{Handler: &renderValidator{r: r}})
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-apps-kubecfg-io-v1-konfiguration-render,mutating=false,failurePolicy=ignore,sideEffects=None,groups=apps.kubecfg.io,resources=konfigurations,verbs=create;update,versions=v1,name=vkonfigurationrender.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// renderValidator rejects Konfigurations enabling validateOnAdmission whose
+// entrypoint fails to evaluate against the artifact their source currently
+// serves, so errors surface when the Konfiguration is applied rather than at
+// its next reconciliation. Konfigurations are admitted with a warning if the
+// check cannot complete within its guards.
+type renderValidator struct {
+	r       *KonfigurationReconciler
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *renderValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *renderValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	konfig := &appsv1.Konfiguration{}
+	if err := v.decoder.Decode(req, konfig); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !konfig.ValidateOnAdmissionEnabled() {
+		return admission.Allowed("")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, admissionRenderTimeout)
+	defer cancel()
+	err := v.r.renderOnAdmission(ctx, konfig)
+	switch {
+	case err == nil:
+		return admission.Allowed("")
+	case isReconcileError(err) && reasonFor(err) == appsv1.EvaluationFailedReason:
+		return admission.Denied(fmt.Sprintf("manifests failed to evaluate: %s", err))
+	case ctx.Err() == context.DeadlineExceeded:
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("render check skipped: timed out after %s", admissionRenderTimeout))
+	default:
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("render check skipped: %s", err))
+	}
+}
+
+// renderOnAdmission evaluates the entrypoint of the Konfiguration against the
+// artifact its source currently serves, discarding the output. Evaluation
+// errors are returned with the EvaluationFailedReason. Nothing is written to
+// the cluster, and the objects applied by the previous revision are not
+// available to the manifests.
+func (r *KonfigurationReconciler) renderOnAdmission(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	reqLogger := log.FromContext(ctx).WithValues("konfiguration", client.ObjectKeyFromObject(konfig))
+	if err := konfig.ValidateSpec(); err != nil {
+		return err
+	}
+
+	if konfig.Spec.ArtifactSource != nil {
+		return errors.New("artifact sources are not supported")
+	}
+	path, revision := konfig.GetPath(), konfig.GetPath()
+	if konfig.GetSourceRef() != nil {
+		artifact, err := r.admissionArtifact(ctx, konfig)
+		if err != nil {
+			return err
+		}
+		// The artifact is released once rendered, and only removed if no
+		// Konfiguration uses it.
+		cacheKey := "admission:" + client.ObjectKeyFromObject(konfig).String()
+		sparsePaths := konfig.GetSparsePaths()
+		artifactID := sparseArtifactKey(artifactKey(artifact), sparsePaths)
+		dir, ok := r.artifacts.Get(cacheKey, artifactID)
+		if !ok {
+			if dir, err = r.artifacts.Allocate(konfig.GetName()); err != nil {
+				return err
+			}
+			if err := r.downloadAndExtractTo(ctx, artifact.URL, artifact.Checksum, dir, sparsePaths); err != nil {
+				os.RemoveAll(dir)
+				return err
+			}
+			dir = r.artifacts.Put(cacheKey, artifactID, dir)
+		}
+		defer r.artifacts.Evict(cacheKey)
+
+		if path, err = securejoin.SecureJoin(dir, path); err != nil {
+			return err
+		}
+		if path, err = resolveEntrypoint(path, konfig.GetEntrypoints()); err != nil {
+			return err
+		}
+		if vars := konfig.GetVariables(); vars != nil && vars.HasFiles() {
+			if err := resolveVariableFiles(dir, vars); err != nil {
+				return withReason(appsv1.EvaluationFailedReason, err)
+			}
+		}
+		revision = artifact.Revision
+	}
+	if isPlainManifest(path) {
+		return nil
+	}
+
+	secrets, err := r.resolveSecretProviderVars(ctx, konfig)
+	if err != nil {
+		return err
+	}
+	secrets, err = r.resolveSealedVars(konfig, secrets)
+	if err != nil {
+		return err
+	}
+	rk := withReconcileVars(konfig, revision, time.Now())
+	rk.Spec.KubecfgArgs = append(rk.Spec.KubecfgArgs, "--ext-code", appsv1.PreviousManifestsExtVar+"=[]")
+	rk, removeVars, err := r.withSecretVars(rk, secrets)
+	if err != nil {
+		return err
+	}
+	defer removeVars()
+	rk, removeConfigMapVars, err := r.withConfigMapVars(ctx, rk)
+	if err != nil {
+		return err
+	}
+	defer removeConfigMapVars()
+	libDirs, removeLibs, err := r.jsonnetLibDirs(ctx, rk)
+	if err != nil {
+		return err
+	}
+	defer removeLibs()
+	if konfig.YAMLStreamEnabled() {
+		wrapper, removeWrapper, err := r.yamlStreamEntrypoint(rk, path)
+		if err != nil {
+			return withReason(appsv1.EvaluationFailedReason, err)
+		}
+		defer removeWrapper()
+		path = wrapper
+	}
+	_, err = evaluateManifests(ctx, reqLogger, rk, libDirs, path, func(out io.Reader) error {
+		n, err := io.Copy(ioutil.Discard, io.LimitReader(out, admissionRenderMaxSize+1))
+		if err == nil && n > admissionRenderMaxSize {
+			return errRenderTooLarge
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, errRenderTooLarge) && ctx.Err() == nil {
+		return withReason(appsv1.EvaluationFailedReason, err)
+	}
+	return err
+}
+
+// admissionArtifact returns the artifact currently served by the source of
+// the Konfiguration, or by the source derived for it if it overrides the ref,
+// pins a revision or selects revisions. Derived sources are not created.
+func (r *KonfigurationReconciler) admissionArtifact(ctx context.Context, konfig *appsv1.Konfiguration) (*sourcev1.Artifact, error) {
+	sourceRef := konfig.GetSourceRef()
+	var source sourcev1.Source
+	if konfig.UsesDerivedSource() {
+		var derived sourcev1.GitRepository
+		err := r.Get(ctx, client.ObjectKey{Namespace: sourceRef.Namespace, Name: konfig.GetDerivedSourceName()}, &derived)
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("derived source '%s/%s' does not exist yet", sourceRef.Namespace, konfig.GetDerivedSourceName())
+		} else if err != nil {
+			return nil, err
+		}
+		source = &derived
+	} else {
+		var err error
+		if source, err = sourceRef.GetSource(ctx, r.Client); err != nil {
+			return nil, err
+		}
+	}
+	artifact := source.GetArtifact()
+	if artifact == nil {
+		return nil, fmt.Errorf("source '%s/%s' is not ready, artifact not found", sourceRef.Namespace, sourceRef.Name)
+	}
+	return artifact, nil
+}
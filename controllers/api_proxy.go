@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// apiProxy serves the API server on a loopback address with the credentials
+// of the controller, so the requests kubecfg makes carry the user agent of the
+// Konfiguration it runs for and, given a rate limiter, are limited like those
+// of the controller. Requests must carry the token of the proxy, which is only
+// written to its kubeconfig.
+type apiProxy struct {
+	server     *http.Server
+	kubeconfig string
+}
+
+// proxyKubeconfig is the kubeconfig of an apiProxy, given its address and
+// token.
+const proxyKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: proxy
+  cluster:
+    server: http://%s
+users:
+- name: proxy
+  user:
+    token: %s
+contexts:
+- name: proxy
+  context:
+    cluster: proxy
+    user: proxy
+current-context: proxy
+`
+
+// startAPIProxy starts a proxy to the API server of the given config setting
+// the given user agent on every request, and limited by the given rate
+// limiter, if any, writing its kubeconfig in dir.
+func startAPIProxy(config *rest.Config, limiter flowcontrol.RateLimiter, userAgent, dir string) (*apiProxy, error) {
+	target, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "" {
+		target.Scheme = "https"
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(secret)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	// Stream watches rather than buffering them
+	proxy.FlushInterval = -1
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		req.Header.Del("Authorization")
+		req.Header.Set("User-Agent", userAgent)
+		if limiter != nil {
+			if err := limiter.Wait(req.Context()); err != nil {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+		}
+		proxy.ServeHTTP(w, req)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(dir, "api-proxy-kubeconfig-*")
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	_, err = fmt.Fprintf(f, proxyKubeconfig, listener.Addr().String(), token)
+	f.Close()
+	if err != nil {
+		listener.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	p := &apiProxy{server: &http.Server{Handler: handler}, kubeconfig: f.Name()}
+	go p.server.Serve(listener)
+	return p, nil
+}
+
+// Close stops the proxy and removes its kubeconfig.
+func (p *apiProxy) Close() {
+	p.server.Close()
+	os.Remove(p.kubeconfig)
+}
+
+// kubecfgKubeconfigKey is the context key of the kubeconfig kubecfg is run
+// with against the cluster the controller runs in.
+type kubecfgKubeconfigKey struct{}
+
+// withKubecfgKubeconfig returns a context running kubecfg with the given
+// kubeconfig, unless the Konfiguration points it at a target cluster.
+func withKubecfgKubeconfig(ctx context.Context, kubeconfig string) context.Context {
+	return context.WithValue(ctx, kubecfgKubeconfigKey{}, kubeconfig)
+}
+
+// kubecfgArgs returns the given kubecfg arguments, starting with its command,
+// with the kubeconfig of the context, if any. Any kubeconfig set later in the
+// arguments, such as that of a target cluster, takes precedence.
+func kubecfgArgs(ctx context.Context, args []string) []string {
+	kubeconfig, ok := ctx.Value(kubecfgKubeconfigKey{}).(string)
+	if !ok || len(args) == 0 {
+		return args
+	}
+	return append([]string{args[0], "--kubeconfig", kubeconfig}, args[1:]...)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestAPIProxy(t *testing.T) {
+	var gotAuth, gotUserAgent string
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		gotAuth = req.Header.Get("Authorization")
+		gotUserAgent = req.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(1000, 1)
+	proxy, err := startAPIProxy(&rest.Config{Host: server.URL, BearerToken: "controller"}, limiter, "kubecfg-operator/dev konfiguration=team/app", t.TempDir())
+	if err != nil {
+		t.Fatalf("startAPIProxy() error = %v", err)
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(proxy.kubeconfig)
+	if err != nil {
+		t.Fatalf("failed to load kubeconfig: %v", err)
+	}
+	cluster := kubeconfig.Clusters[kubeconfig.Contexts[kubeconfig.CurrentContext].Cluster]
+	token := kubeconfig.AuthInfos[kubeconfig.Contexts[kubeconfig.CurrentContext].AuthInfo].Token
+
+	tests := []struct {
+		name     string
+		auth     string
+		wantCode int
+	}{
+		{name: "without token", wantCode: http.StatusUnauthorized},
+		{name: "with wrong token", auth: "Bearer wrong", wantCode: http.StatusUnauthorized},
+		{name: "with token", auth: "Bearer " + token, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			req, _ := http.NewRequest(http.MethodGet, cluster.Server+"/api/v1/namespaces", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				if requests != 0 {
+					t.Errorf("unauthorized request was proxied")
+				}
+				return
+			}
+			if gotAuth != "Bearer controller" {
+				t.Errorf("Authorization = %q, want the credentials of the controller", gotAuth)
+			}
+			if gotUserAgent != "kubecfg-operator/dev konfiguration=team/app" {
+				t.Errorf("User-Agent = %q, want that of the Konfiguration", gotUserAgent)
+			}
+		})
+	}
+
+	proxy.Close()
+	if _, err := os.Stat(proxy.kubeconfig); !os.IsNotExist(err) {
+		t.Errorf("kubeconfig not removed on Close")
+	}
+}
+
+func TestKubecfgArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		args       []string
+		want       []string
+	}{
+		{name: "without kubeconfig", args: []string{"update", "main.jsonnet"}, want: []string{"update", "main.jsonnet"}},
+		{
+			name:       "with kubeconfig",
+			kubeconfig: "/tmp/proxy",
+			args:       []string{"update", "main.jsonnet"},
+			want:       []string{"update", "--kubeconfig", "/tmp/proxy", "main.jsonnet"},
+		},
+		{
+			name:       "before the kubeconfig of a target",
+			kubeconfig: "/tmp/proxy",
+			args:       []string{"diff", "--kubeconfig", "/tmp/target", "main.jsonnet"},
+			want:       []string{"diff", "--kubeconfig", "/tmp/proxy", "--kubeconfig", "/tmp/target", "main.jsonnet"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			if tt.kubeconfig != "" {
+				ctx = withKubecfgKubeconfig(ctx, tt.kubeconfig)
+			}
+			if got := kubecfgArgs(ctx, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubecfgArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
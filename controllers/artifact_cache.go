@@ -0,0 +1,217 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+// artifactCache keeps extracted source artifacts on disk between reconciles.
+// When a Konfiguration is reconciled again at the same source revision (for
+// example, because only its variables changed) the previously extracted
+// directory is reused instead of downloading and untarring the artifact again.
+// Extracted artifacts are shared by all the Konfigurations referencing the
+// same artifact, since rendering never writes to them, and removed once none
+// of them uses it anymore. When a Konfiguration moves to another artifact, the
+// previous one is kept until the files that changed between the two are asked
+// for, and compared outside of the lock.
+type artifactCache struct {
+	root    string
+	entries map[string]*artifactCacheEntry
+	// users maps the Konfigurations to the key of the artifact they use.
+	users map[string]string
+	// changes maps the Konfigurations to the move to the artifact they use.
+	changes map[string]*artifactChange
+	mu      sync.Mutex
+}
+
+type artifactCacheEntry struct {
+	dir   string
+	users map[string]struct{}
+	// retained counts the moves away from the artifact whose changed files
+	// were not compared yet.
+	retained int
+}
+
+// artifactChange is the move of a Konfiguration from one artifact to another.
+type artifactChange struct {
+	// artifact is the key of the artifact moved from, and from its entry
+	// until the changed files are compared.
+	artifact string
+	from, to *artifactCacheEntry
+	once     sync.Once
+	files    []string
+}
+
+func newArtifactCache(root string) *artifactCache {
+	return &artifactCache{
+		root:    root,
+		entries: make(map[string]*artifactCacheEntry),
+		users:   make(map[string]string),
+		changes: make(map[string]*artifactChange),
+	}
+}
+
+// artifactKey returns the key identifying the contents of the given artifact.
+func artifactKey(artifact *sourcev1.Artifact) string {
+	if artifact.Checksum != "" {
+		return artifact.Checksum
+	}
+	return artifact.URL
+}
+
+// Get returns the directory holding the extracted artifact with the given key,
+// if one exists, and records that the Konfiguration with the given key uses
+// it.
+func (c *artifactCache) Get(key, artifact string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[artifact]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(entry.dir); err != nil {
+		delete(c.entries, artifact)
+		return "", false
+	}
+	c.use(key, artifact, entry)
+	return entry.dir, true
+}
+
+// Allocate creates a new empty directory for an artifact to be extracted into.
+// It is not tracked until passed to Put.
+func (c *artifactCache) Allocate(prefix string) (string, error) {
+	return ioutil.TempDir(c.root, prefix)
+}
+
+// Put stores the directory for the given artifact and records that the
+// Konfiguration with the given key uses it. If the artifact was extracted
+// concurrently for another Konfiguration, dir is removed in favour of the
+// existing directory. It returns the directory to use.
+func (c *artifactCache) Put(key, artifact, dir string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[artifact]
+	if ok && entry.dir != dir {
+		os.RemoveAll(dir)
+	} else if !ok {
+		entry = &artifactCacheEntry{dir: dir, users: make(map[string]struct{})}
+		c.entries[artifact] = entry
+	}
+	c.use(key, artifact, entry)
+	return entry.dir
+}
+
+// Evict releases the artifact used by the Konfiguration with the given key.
+func (c *artifactCache) Evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.release(key)
+	c.forget(key)
+}
+
+// Changes returns the files that changed when the Konfiguration with the given
+// key moved to the artifact it uses, or nil if it did not use one before. The
+// files are compared the first time they are asked for.
+func (c *artifactCache) Changes(key string) []string {
+	c.mu.Lock()
+	change, ok := c.changes[key]
+	var fromDir, toDir string
+	if ok && change.from != nil {
+		fromDir, toDir = change.from.dir, change.to.dir
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	change.once.Do(func() { change.files = changedFiles(fromDir, toDir) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unretain(change)
+	return change.files
+}
+
+// use records that the Konfiguration with the given key uses the given
+// artifact, releasing the artifact it used before.
+func (c *artifactCache) use(key, artifact string, entry *artifactCacheEntry) {
+	if previous, ok := c.users[key]; ok && previous != artifact {
+		c.forget(key)
+		if old, ok := c.entries[previous]; ok {
+			old.retained++
+			c.changes[key] = &artifactChange{artifact: previous, from: old, to: entry}
+		}
+	}
+	if c.users[key] != artifact {
+		c.release(key)
+	}
+	c.users[key] = artifact
+	entry.users[key] = struct{}{}
+}
+
+// forget drops the move of the Konfiguration with the given key to the
+// artifact it uses.
+func (c *artifactCache) forget(key string) {
+	if change, ok := c.changes[key]; ok {
+		c.unretain(change)
+		delete(c.changes, key)
+	}
+}
+
+// unretain releases the artifact the given move was from, if it was not
+// already.
+func (c *artifactCache) unretain(change *artifactChange) {
+	if change.from == nil {
+		return
+	}
+	change.from.retained--
+	c.removeUnused(change.artifact, change.from)
+	change.from = nil
+}
+
+// release removes the Konfiguration with the given key from the users of its
+// artifact, removing the artifact if it has no users left.
+func (c *artifactCache) release(key string) {
+	artifact, ok := c.users[key]
+	if !ok {
+		return
+	}
+	delete(c.users, key)
+	entry, ok := c.entries[artifact]
+	if !ok {
+		return
+	}
+	delete(entry.users, key)
+	c.removeUnused(artifact, entry)
+}
+
+// removeUnused removes the given artifact if it has no users left and is not
+// retained.
+func (c *artifactCache) removeUnused(artifact string, entry *artifactCacheEntry) {
+	if len(entry.users) != 0 || entry.retained != 0 {
+		return
+	}
+	os.RemoveAll(entry.dir)
+	if c.entries[artifact] == entry {
+		delete(c.entries, artifact)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/fluxcd/pkg/untar"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-retryablehttp"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// prepareArtifactSource resolves the path and revision to render from the
+// artifact source of the Konfiguration. The revision is the digest of the
+// contents of the artifact.
+func (r *KonfigurationReconciler) prepareArtifactSource(ctx context.Context, reqLogger logr.Logger, req ctrl.Request, konfig *appsv1.Konfiguration) (path, revision string, err error) {
+	if konfig.GetPinnedRevision() != "" {
+		return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("pinned revisions require a sourceRef"))
+	}
+	if konfig.GetRevisionSelector() != nil {
+		return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("revision selectors require a sourceRef"))
+	}
+	if err := r.cleanupDerivedSources(ctx, req.NamespacedName, ""); err != nil {
+		return "", "", err
+	}
+
+	var dir string
+	source := konfig.Spec.ArtifactSource
+	switch {
+	case source.URL != "" && source.Volume != "":
+		return "", "", withReason(appsv1.InvalidSpecReason, errors.New("artifactSource cannot set both a url and a volume"))
+	case source.URL != "":
+		dir, revision, err = r.fetchURLArtifact(ctx, req.NamespacedName.String(), konfig, source.URL)
+	case source.Volume != "":
+		dir, revision, err = r.volumeArtifact(source.Volume)
+	default:
+		return "", "", withReason(appsv1.InvalidSpecReason, errors.New("artifactSource requires a url or a volume"))
+	}
+	if err != nil {
+		reqLogger.Error(err, "Failed to fetch artifact source")
+		return "", "", withReason(appsv1.ArtifactFailedReason, err)
+	}
+	releaseQuarantine(konfig)
+
+	if path, err = resolveArtifactPaths(reqLogger, konfig, dir); err != nil {
+		return "", "", err
+	}
+	return path, revision, nil
+}
+
+// fetchURLArtifact downloads the tarball at the given URL and returns the
+// directory it is extracted to, reusing a previous extraction if its contents
+// did not change, along with their digest.
+func (r *KonfigurationReconciler) fetchURLArtifact(ctx context.Context, cacheKey string, konfig *appsv1.Konfiguration, artifactURL string) (dir, digest string, err error) {
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-artifact-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	req, err := retryablehttp.NewRequest(http.MethodGet, artifactURL, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create a new request: %w", err)
+	}
+	resp, err := r.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download artifact, error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to download artifact from %s, status: %s", artifactURL, resp.Status)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		return "", "", fmt.Errorf("failed to download artifact, error: %w", err)
+	}
+	digest = fmt.Sprintf("sha256:%x", hash.Sum(nil))
+	if err := checkQuarantine(konfig, digest); err != nil {
+		return "", "", err
+	}
+
+	sparsePaths := konfig.GetSparsePaths()
+	artifactID := sparseArtifactKey(digest, sparsePaths)
+	if dir, ok := r.artifacts.Get(cacheKey, artifactID); ok {
+		return dir, digest, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	if err := inspectArtifact(f); err != nil {
+		return "", "", r.quarantineRevision(konfig, digest, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	if dir, err = r.artifacts.Allocate(konfig.GetName()); err != nil {
+		return "", "", err
+	}
+	var body io.Reader = f
+	if len(sparsePaths) != 0 {
+		filtered := filterArtifact(f, sparsePaths)
+		defer filtered.Close()
+		body = filtered
+	}
+	if _, err := untar.Untar(body, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("failed to untar artifact, error: %w", err)
+	}
+	return r.artifacts.Put(cacheKey, artifactID, dir), digest, nil
+}
+
+// volumeArtifact returns the directory of the named volume source, along with
+// the digest of its contents.
+func (r *KonfigurationReconciler) volumeArtifact(name string) (dir, digest string, err error) {
+	if r.volumeSourceDir == "" {
+		return "", "", errors.New("volume sources are not enabled on the controller")
+	}
+	if dir, err = securejoin.SecureJoin(r.volumeSourceDir, name); err != nil {
+		return "", "", err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return "", "", fmt.Errorf("volume source '%s' is not mounted: %w", name, err)
+	} else if !info.IsDir() {
+		return "", "", fmt.Errorf("volume source '%s' is not a directory", name)
+	}
+	if digest, err = hashDir(dir); err != nil {
+		return "", "", fmt.Errorf("failed to hash volume source '%s': %w", name, err)
+	}
+	return dir, digest, nil
+}
+
+// hashDir returns the digest of the paths and contents of the regular files
+// in the given directory, in lexical order.
+func hashDir(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
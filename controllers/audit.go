@@ -0,0 +1,147 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// recordApply records on the status of the Konfiguration, and as an event,
+// the source revision and spec responsible for an apply that modified the
+// cluster, along with the files of the source that changed in the revision
+// and the subject and author of its commit, if known.
+func (r *KonfigurationReconciler) recordApply(ctx context.Context, konfig *appsv1.Konfiguration, state *pipelineState) {
+	record := &appsv1.ApplyRecord{
+		Time:         metav1.Now(),
+		Revision:     state.revision,
+		Checksum:     state.manifests.checksum,
+		SpecChecksum: state.specChecksum,
+		ModifiedBy:   specManager(konfig),
+	}
+	changed := r.artifacts.Changes(client.ObjectKeyFromObject(konfig).String())
+	if len(changed) > maxChangedPaths {
+		record.ChangedPaths = changed[:maxChangedPaths]
+	} else {
+		record.ChangedPaths = changed
+	}
+	if commit := r.sourceCommit(ctx, konfig, state.revision); commit != nil {
+		record.CommitSubject, record.CommitAuthor = commit.subject, commit.author
+	}
+	konfig.Status.LastApply = record
+
+	if r.recorder != nil {
+		modifiedBy := record.ModifiedBy
+		if modifiedBy == "" {
+			modifiedBy = "unknown"
+		}
+		msg := fmt.Sprintf("Applied manifests %s of revision '%s' rendered from spec %s, last modified by %s",
+			record.Checksum, record.Revision, record.SpecChecksum, modifiedBy)
+		if record.CommitSubject != "" {
+			msg = fmt.Sprintf("Applied '%s' by %s: manifests %s of revision '%s' rendered from spec %s, last modified by %s",
+				record.CommitSubject, record.CommitAuthor, record.Checksum, record.Revision, record.SpecChecksum, modifiedBy)
+		}
+		if len(changed) != 0 {
+			msg += fmt.Sprintf(", changing %s", strings.Join(record.ChangedPaths, ", "))
+			if more := len(changed) - len(record.ChangedPaths); more > 0 {
+				msg += fmt.Sprintf(" and %d more", more)
+			}
+		}
+		r.recorder.Event(konfig, corev1.EventTypeNormal, "Applied", msg)
+	}
+}
+
+// appliedMessage returns the message of the Ready condition of a Konfiguration
+// that applied the given revision, naming the subject and author of its commit
+// if they were recorded when the revision was applied.
+func appliedMessage(konfig *appsv1.Konfiguration, revision string) string {
+	if record := konfig.Status.LastApply; record != nil && record.Revision == revision && record.CommitSubject != "" {
+		return fmt.Sprintf("Applied revision: %s ('%s' by %s)", revision, record.CommitSubject, record.CommitAuthor)
+	}
+	return fmt.Sprintf("Applied revision: %s", revision)
+}
+
+// konfigurationUserAgent returns the user agent of the requests kubecfg makes
+// for the Konfiguration of the given key, given that of the controller.
+func konfigurationUserAgent(userAgent string, key client.ObjectKey) string {
+	return fmt.Sprintf("%s konfiguration=%s", userAgent, key)
+}
+
+// userAgentTransport sets the user agent of the controller on the requests it
+// makes outside the cluster, such as downloads of source artifacts.
+type userAgentTransport struct {
+	userAgent string
+	rt        http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent == "" {
+		return t.rt.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.rt.RoundTrip(req)
+}
+
+// annotateAudit sets the KonfigurationAnnotation on the given objects.
+func annotateAudit(konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) {
+	value := client.ObjectKeyFromObject(konfig).String()
+	for _, obj := range objs {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[appsv1.KonfigurationAnnotation] = value
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// specManager returns the field manager that most recently modified the spec
+// of the Konfiguration, or an empty string if it is not known.
+func specManager(konfig *appsv1.Konfiguration) string {
+	var manager string
+	var latest metav1.Time
+	for _, entry := range konfig.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields["f:spec"]; !ok {
+			continue
+		}
+		if manager == "" || (entry.Time != nil && latest.Before(entry.Time)) {
+			manager = entry.Manager
+			if entry.Time != nil {
+				latest = *entry.Time
+			}
+		}
+	}
+	return manager
+}
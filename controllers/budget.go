@@ -0,0 +1,241 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// budgetRetryDelay is how long the reconciliation of a Konfiguration is
+// deferred when its namespace used up its reconcile slots.
+const budgetRetryDelay = 5 * time.Second
+
+// budgetLimits are the limits of the reconciliations of the Konfigurations of
+// a namespace. Zero values are unlimited.
+type budgetLimits struct {
+	maxConcurrent int
+	qps           float32
+}
+
+// namespaceBudgets limits the reconciliations of the Konfigurations of each
+// namespace, so the Konfigurations of one tenant cannot monopolize the workers
+// of the controller or its requests to the API server.
+type namespaceBudgets struct {
+	budgets map[string]*namespaceBudget
+	mu      sync.Mutex
+}
+
+type namespaceBudget struct {
+	inflight int
+	limiter  flowcontrol.RateLimiter
+}
+
+func newNamespaceBudgets() *namespaceBudgets {
+	return &namespaceBudgets{budgets: make(map[string]*namespaceBudget)}
+}
+
+// limitsFor returns the limits of the given namespace, the given defaults as
+// overridden by its annotations.
+func limitsFor(defaults budgetLimits, ns *corev1.Namespace) (budgetLimits, error) {
+	limits := defaults
+	if ns == nil {
+		return limits, nil
+	}
+	if value, ok := ns.GetAnnotations()[appsv1.MaxConcurrentReconcilesAnnotation]; ok {
+		maxConcurrent, err := strconv.Atoi(value)
+		if err != nil || maxConcurrent < 0 {
+			return limits, fmt.Errorf("invalid %s annotation on namespace '%s': %q", appsv1.MaxConcurrentReconcilesAnnotation, ns.GetName(), value)
+		}
+		limits.maxConcurrent = maxConcurrent
+	}
+	if value, ok := ns.GetAnnotations()[appsv1.APIQPSAnnotation]; ok {
+		qps, err := strconv.ParseFloat(value, 32)
+		if err != nil || qps < 0 {
+			return limits, fmt.Errorf("invalid %s annotation on namespace '%s': %q", appsv1.APIQPSAnnotation, ns.GetName(), value)
+		}
+		limits.qps = float32(qps)
+	}
+	return limits, nil
+}
+
+// acquire takes a reconcile slot of the given namespace. It returns false if
+// the namespace has no slot left, otherwise the function releasing the slot
+// and the rate limiter for the API requests made while reconciling, nil if
+// they are unlimited. The rate limiter is shared by all the Konfigurations of
+// the namespace.
+func (b *namespaceBudgets) acquire(namespace string, limits budgetLimits) (func(), flowcontrol.RateLimiter, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	budget, ok := b.budgets[namespace]
+	if !ok {
+		budget = &namespaceBudget{}
+		b.budgets[namespace] = budget
+	}
+	if limits.maxConcurrent > 0 && budget.inflight >= limits.maxConcurrent {
+		return nil, nil, false
+	}
+	switch {
+	case limits.qps == 0:
+		budget.limiter = nil
+	case budget.limiter == nil || budget.limiter.QPS() != limits.qps:
+		burst := int(math.Ceil(float64(limits.qps)))
+		budget.limiter = flowcontrol.NewTokenBucketRateLimiter(limits.qps, burst)
+	}
+	budget.inflight++
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		budget.inflight--
+	}, budget.limiter, true
+}
+
+// budgetLimits returns the limits of the given namespace. Namespace
+// annotations are not read when running namespace-scoped.
+func (r *KonfigurationReconciler) budgetLimits(ctx context.Context, namespace string) (budgetLimits, error) {
+	defaults := r.settings.get().budgets
+	if r.namespaceScoped {
+		return limitsFor(defaults, nil)
+	}
+	ns, err := r.getNamespace(ctx, namespace)
+	if err != nil {
+		return budgetLimits{}, err
+	}
+	return limitsFor(defaults, ns)
+}
+
+// getNamespace returns the namespace with the given name from the namespace
+// cache. When running namespace-scoped, it is read from the API server, and
+// nil is returned if the controller is not allowed to read it, e.g. because
+// its role is only bound in the watched namespaces.
+func (r *KonfigurationReconciler) getNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	if r.namespaces != nil {
+		var ns corev1.Namespace
+		if err := r.namespaces.Get(ctx, client.ObjectKey{Name: name}, &ns); err != nil {
+			return nil, err
+		}
+		return &ns, nil
+	}
+	ns, err := r.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsForbidden(err) {
+		return nil, nil
+	}
+	return ns, err
+}
+
+// withClient returns a copy of the reconciler using the given client.
+func (r *KonfigurationReconciler) withClient(c client.Client) *KonfigurationReconciler {
+	rc := *r
+	rc.Client = c
+	return &rc
+}
+
+// budgetedClient is a client waiting on a rate limiter before every request.
+// Reads are limited too, since those of unstructured objects, such as the
+// applied objects, are not served from the cache. The requests kubecfg makes
+// are limited by running it against an apiProxy, while those made through the
+// clientset of the reconciler are not limited.
+type budgetedClient struct {
+	client.Client
+	limiter flowcontrol.RateLimiter
+}
+
+func (c *budgetedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *budgetedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *budgetedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *budgetedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *budgetedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *budgetedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *budgetedClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *budgetedClient) Status() client.StatusWriter {
+	return &budgetedStatusWriter{StatusWriter: c.Client.Status(), limiter: c.limiter}
+}
+
+// budgetedStatusWriter is a status writer waiting on a rate limiter before
+// every request.
+type budgetedStatusWriter struct {
+	client.StatusWriter
+	limiter flowcontrol.RateLimiter
+}
+
+func (w *budgetedStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := w.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *budgetedStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+const (
+	// maxChangedPaths is the number of changed files recorded for an apply.
+	maxChangedPaths = 20
+	// maxCommitSubjectSize is the maximum size of the commit subject recorded
+	// for an apply.
+	maxCommitSubjectSize = 256
+	// commitLookupTimeout bounds the request for the metadata of a commit to
+	// the API of its Git host.
+	commitLookupTimeout = 10 * time.Second
+)
+
+// commitInfo is the subject and author of a commit.
+type commitInfo struct {
+	subject string
+	author  string
+}
+
+// commitAPIURL returns the URL of the API endpoint describing the commit with
+// the given SHA in the Git repository at repoURL, and whether the host serves
+// the GitLab API rather than the GitHub one. Only hosts whose name tells them
+// apart are supported; an empty string is returned for others.
+func commitAPIURL(repoURL, sha string) (string, bool) {
+	host, path := repositoryHostPath(repoURL)
+	switch {
+	case host == "github.com":
+		return fmt.Sprintf("https://api.github.com/repos/%s/commits/%s", path, sha), false
+	case strings.Contains(host, "github"):
+		return fmt.Sprintf("https://%s/api/v3/repos/%s/commits/%s", host, path, sha), false
+	case strings.Contains(host, "gitlab"):
+		return fmt.Sprintf("https://%s/api/v4/projects/%s/repository/commits/%s", host, url.PathEscape(path), sha), true
+	}
+	return "", false
+}
+
+// parseCommit returns the subject and author of the commit described by the
+// response of the GitHub or GitLab API. The author is identified by email if
+// known.
+func parseCommit(r io.Reader, gitlab bool) (*commitInfo, error) {
+	var message, name, email string
+	if gitlab {
+		var commit struct {
+			Message     string `json:"message"`
+			AuthorName  string `json:"author_name"`
+			AuthorEmail string `json:"author_email"`
+		}
+		if err := json.NewDecoder(r).Decode(&commit); err != nil {
+			return nil, err
+		}
+		message, name, email = commit.Message, commit.AuthorName, commit.AuthorEmail
+	} else {
+		var commit struct {
+			Commit struct {
+				Message string `json:"message"`
+				Author  struct {
+					Name  string `json:"name"`
+					Email string `json:"email"`
+				} `json:"author"`
+			} `json:"commit"`
+		}
+		if err := json.NewDecoder(r).Decode(&commit); err != nil {
+			return nil, err
+		}
+		message, name, email = commit.Commit.Message, commit.Commit.Author.Name, commit.Commit.Author.Email
+	}
+	info := &commitInfo{subject: strings.TrimSpace(strings.SplitN(message, "\n", 2)[0]), author: email}
+	if len(info.subject) > maxCommitSubjectSize {
+		info.subject = info.subject[:maxCommitSubjectSize] + "..."
+	}
+	if info.author == "" {
+		info.author = name
+	}
+	return info, nil
+}
+
+// sourceCommit returns the subject and author of the commit the revision of the
+// source of the Konfiguration names, as reported by the API of its Git host,
+// since source artifacts carry no Git metadata. The password of the secretRef
+// of the GitRepository, if any, is used as API token. It returns nil if the
+// source is not a GitRepository on a supported host or the lookup fails.
+func (r *KonfigurationReconciler) sourceCommit(ctx context.Context, konfig *appsv1.Konfiguration, revision string) *commitInfo {
+	repository, sha := r.sourceRepository(ctx, konfig, revision)
+	if repository == nil {
+		return nil
+	}
+	apiURL, gitlab := commitAPIURL(repository.Spec.URL, sha)
+	if apiURL == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, commitLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil
+	}
+	if token := r.repositoryToken(ctx, repository); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	info, err := parseCommit(resp.Body, gitlab)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// repositoryToken returns the password of the secretRef of the GitRepository,
+// or an empty string if it has none.
+func (r *KonfigurationReconciler) repositoryToken(ctx context.Context, repository *sourcev1.GitRepository) string {
+	if repository.Spec.SecretRef == nil {
+		return ""
+	}
+	var secret corev1.Secret
+	key := client.ObjectKey{Namespace: repository.GetNamespace(), Name: repository.Spec.SecretRef.Name}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return ""
+	}
+	return string(secret.Data["password"])
+}
+
+// changedFiles returns the paths of the files that were added, removed or
+// modified between the artifacts extracted in the given directories, in
+// sorted order. Files that cannot be read are considered changed.
+func changedFiles(oldDir, newDir string) []string {
+	oldFiles, newFiles := fileDigests(oldDir), fileDigests(newDir)
+	var changed []string
+	for path, digest := range newFiles {
+		if old, ok := oldFiles[path]; !ok || old != digest || digest == "" {
+			changed = append(changed, path)
+		}
+	}
+	for path := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// fileDigests returns the SHA1 digests of the regular files below dir by their
+// slash-separated path relative to it.
+func fileDigests(dir string) map[string]string {
+	digests := make(map[string]string)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		digests[filepath.ToSlash(rel)] = fileDigest(path)
+		return nil
+	})
+	return digests
+}
+
+func fileDigest(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
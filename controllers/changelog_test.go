@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the given files, by slash-separated path, below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldFiles map[string]string
+		newFiles map[string]string
+		want     []string
+	}{
+		{
+			name:     "unchanged",
+			oldFiles: map[string]string{"main.jsonnet": "{}", "lib/app.libsonnet": "{}"},
+			newFiles: map[string]string{"main.jsonnet": "{}", "lib/app.libsonnet": "{}"},
+		},
+		{
+			name:     "modified",
+			oldFiles: map[string]string{"main.jsonnet": "{}", "lib/app.libsonnet": "{}"},
+			newFiles: map[string]string{"main.jsonnet": "{}", "lib/app.libsonnet": "{a: 1}"},
+			want:     []string{"lib/app.libsonnet"},
+		},
+		{
+			name:     "added and removed",
+			oldFiles: map[string]string{"main.jsonnet": "{}", "old.jsonnet": "{}"},
+			newFiles: map[string]string{"main.jsonnet": "{}", "new.jsonnet": "{}"},
+			want:     []string{"new.jsonnet", "old.jsonnet"},
+		},
+		{
+			name:     "no previous artifact",
+			newFiles: map[string]string{"main.jsonnet": "{}"},
+			want:     []string{"main.jsonnet"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDir, newDir := t.TempDir(), t.TempDir()
+			writeFiles(t, oldDir, tt.oldFiles)
+			writeFiles(t, newDir, tt.newFiles)
+			if got := changedFiles(oldDir, newDir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitAPIURL(t *testing.T) {
+	const sha = "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"
+	tests := []struct {
+		name       string
+		repoURL    string
+		want       string
+		wantGitLab bool
+	}{
+		{name: "GitHub", repoURL: "https://github.com/org/repo.git", want: "https://api.github.com/repos/org/repo/commits/" + sha},
+		{name: "GitHub Enterprise", repoURL: "ssh://git@github.example.com/org/repo", want: "https://github.example.com/api/v3/repos/org/repo/commits/" + sha},
+		{name: "GitLab", repoURL: "git@gitlab.com:group/sub/repo.git", want: "https://gitlab.com/api/v4/projects/group%2Fsub%2Frepo/repository/commits/" + sha, wantGitLab: true},
+		{name: "other host", repoURL: "https://git.example.com/org/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gitlab := commitAPIURL(tt.repoURL, sha)
+			if got != tt.want || gitlab != tt.wantGitLab {
+				t.Errorf("commitAPIURL() = %q, %v, want %q, %v", got, gitlab, tt.want, tt.wantGitLab)
+			}
+		})
+	}
+}
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		gitlab bool
+		want   commitInfo
+	}{
+		{
+			name: "GitHub",
+			body: `{"commit": {"message": "fix ingress timeout\n\nRaise it to 60s.", "author": {"name": "Jane", "email": "jane@example.com"}}}`,
+			want: commitInfo{subject: "fix ingress timeout", author: "jane@example.com"},
+		},
+		{
+			name:   "GitLab",
+			body:   `{"message": "fix ingress timeout", "author_name": "Jane", "author_email": "jane@example.com"}`,
+			gitlab: true,
+			want:   commitInfo{subject: "fix ingress timeout", author: "jane@example.com"},
+		},
+		{
+			name: "author without email",
+			body: `{"commit": {"message": "fix ingress timeout", "author": {"name": "Jane"}}}`,
+			want: commitInfo{subject: "fix ingress timeout", author: "Jane"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCommit(strings.NewReader(tt.body), tt.gitlab)
+			if err != nil {
+				t.Fatalf("parseCommit() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseCommit() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// maxListedChildren is the maximum number of children that are not ready
+// named in the ChildrenReady condition.
+const maxListedChildren = 10
+
+// rollupChildren sets the ChildrenReady condition of the given Konfiguration
+// from the readiness of the Konfigurations it selects as children, holding its
+// Ready condition false while any of them is not ready. The condition is
+// removed if no children are selected by the spec.
+func (r *KonfigurationReconciler) rollupChildren(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	if !konfig.HasChildren() {
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.ChildrenReadyCondition)
+		return nil
+	}
+	children, err := r.listChildren(ctx, konfig)
+	if err != nil {
+		return err
+	}
+	var notReady []string
+	for _, child := range children {
+		if !isReady(&child) {
+			notReady = append(notReady, child.GetName())
+		}
+	}
+	if len(notReady) == 0 {
+		meta.SetResourceCondition(konfig, appsv1.ChildrenReadyCondition, metav1.ConditionTrue, appsv1.AllChildrenReadyReason,
+			fmt.Sprintf("%d/%d children ready", len(children), len(children)))
+		return nil
+	}
+	sort.Strings(notReady)
+	message := fmt.Sprintf("%d/%d children ready, not ready: ", len(children)-len(notReady), len(children))
+	if len(notReady) > maxListedChildren {
+		message += fmt.Sprintf("%s and %d more", strings.Join(notReady[:maxListedChildren], ", "), len(notReady)-maxListedChildren)
+	} else {
+		message += strings.Join(notReady, ", ")
+	}
+	meta.SetResourceCondition(konfig, appsv1.ChildrenReadyCondition, metav1.ConditionFalse, appsv1.ChildrenNotReadyReason, message)
+	meta.SetResourceCondition(konfig, meta.ReadyCondition, metav1.ConditionFalse, appsv1.ChildrenNotReadyReason, message)
+	return nil
+}
+
+// listChildren returns the Konfigurations in the namespace of the given
+// Konfiguration matching any of its children selectors, excluding itself.
+func (r *KonfigurationReconciler) listChildren(ctx context.Context, konfig *appsv1.Konfiguration) ([]appsv1.Konfiguration, error) {
+	selectors := make([]labels.Selector, len(konfig.Spec.Children))
+	for i := range konfig.Spec.Children {
+		selector, err := metav1.LabelSelectorAsSelector(&konfig.Spec.Children[i])
+		if err != nil {
+			return nil, withReason(appsv1.InvalidSpecReason, fmt.Errorf("invalid children selector: %w", err))
+		}
+		selectors[i] = selector
+	}
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, client.InNamespace(konfig.GetNamespace())); err != nil {
+		return nil, err
+	}
+	var children []appsv1.Konfiguration
+	for _, item := range list.Items {
+		if item.GetName() == konfig.GetName() {
+			continue
+		}
+		for _, selector := range selectors {
+			if selector.Matches(labels.Set(item.GetLabels())) {
+				children = append(children, item)
+				break
+			}
+		}
+	}
+	return children, nil
+}
+
+// isReady returns true if the Konfiguration is ready at its current
+// generation.
+func isReady(konfig *appsv1.Konfiguration) bool {
+	return konfig.Status.ObservedGeneration == konfig.GetGeneration() &&
+		apimeta.IsStatusConditionTrue(konfig.Status.Conditions, meta.ReadyCondition)
+}
+
+// requestsForParentsOf returns requests for the Konfigurations selecting the
+// given Konfiguration as one of their children.
+func (r *KonfigurationReconciler) requestsForParentsOf(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, parent := range list.Items {
+		if parent.GetName() == obj.GetName() {
+			continue
+		}
+		for i := range parent.Spec.Children {
+			selector, err := metav1.LabelSelectorAsSelector(&parent.Spec.Children[i])
+			if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+				continue
+			}
+			reqs = append(reqs, reconcile.Request{NamespacedName: ObjectKey(&parent)})
+			break
+		}
+	}
+	return reqs
+}
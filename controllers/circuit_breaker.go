@@ -0,0 +1,76 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// circuitOpenFor returns how much longer reconciliation of the Konfiguration
+// is paused by its circuit breaker, or zero if it is not.
+func circuitOpenFor(konfig *appsv1.Konfiguration, now time.Time) time.Duration {
+	if konfig.Spec.CircuitBreaker == nil {
+		return 0
+	}
+	cond := apimeta.FindStatusCondition(konfig.Status.Conditions, appsv1.CircuitOpenCondition)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return 0
+	}
+	if remaining := cond.LastTransitionTime.Add(konfig.GetCircuitBreakerBackoff()).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// recordCircuitResult updates the consecutive failure count of the
+// Konfiguration with the outcome of a reconciliation, and opens its circuit
+// breaker once the threshold is reached. Only failures to write to the cluster
+// are counted, unless the API server throttled the controller. An attempt made
+// after the backoff that fails again reopens the circuit for another backoff.
+func recordCircuitResult(konfig *appsv1.Konfiguration, err error) {
+	if err == nil {
+		konfig.Status.ConsecutiveFailures = 0
+		if apimeta.FindStatusCondition(konfig.Status.Conditions, appsv1.CircuitOpenCondition) != nil {
+			meta.SetResourceCondition(konfig, appsv1.CircuitOpenCondition, metav1.ConditionFalse, appsv1.CircuitClosedReason,
+				"reconciliation succeeded")
+		}
+	} else if _, throttled := throttleDelay(err); !throttled {
+		switch reasonFor(err) {
+		case appsv1.ValidationFailedReason, appsv1.ApplyFailedReason, appsv1.PruneFailedReason:
+			konfig.Status.ConsecutiveFailures++
+		}
+	}
+
+	breaker := konfig.Spec.CircuitBreaker
+	if breaker == nil {
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.CircuitOpenCondition)
+		return
+	}
+	if err != nil && konfig.Status.ConsecutiveFailures >= breaker.Threshold {
+		// Remove the condition first so the backoff starts over
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.CircuitOpenCondition)
+		meta.SetResourceCondition(konfig, appsv1.CircuitOpenCondition, metav1.ConditionTrue, appsv1.ConsecutiveFailuresReason,
+			fmt.Sprintf("%d consecutive failed applies, reconciliation paused for %s", konfig.Status.ConsecutiveFailures, konfig.GetCircuitBreakerBackoff()))
+	}
+}
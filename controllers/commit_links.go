@@ -0,0 +1,108 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// commitSHARegex matches the commit SHA ending a source revision, e.g.
+// 'main/1a2b3c...'.
+var commitSHARegex = regexp.MustCompile(`(?:^|/)([0-9a-f]{40})$`)
+
+// scpLikeURLRegex matches the scp-like syntax of Git SSH URLs, e.g.
+// 'git@github.com:org/repo.git'.
+var scpLikeURLRegex = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// commitSHA returns the SHA of the commit the source revision names, or an
+// empty string if it does not name one.
+func commitSHA(revision string) string {
+	if match := commitSHARegex.FindStringSubmatch(revision); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// repositoryHostPath returns the host of the Git repository at repoURL and its
+// path on the host, without the '.git' suffix, or empty strings if repoURL
+// cannot be parsed.
+func repositoryHostPath(repoURL string) (string, string) {
+	var host, path string
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if match := scpLikeURLRegex.FindStringSubmatch(repoURL); match != nil {
+		host, path = match[1], match[2]
+	} else {
+		return "", ""
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return "", ""
+	}
+	return host, path
+}
+
+// commitURL returns the web URL of the commit with the given SHA in the Git
+// repository at repoURL, following the '/commit/<sha>' layout of GitHub, GitLab
+// and Gitea. It returns an empty string if repoURL cannot be parsed.
+func commitURL(repoURL, sha string) string {
+	host, path := repositoryHostPath(repoURL)
+	if host == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/%s/commit/%s", host, path, sha)
+}
+
+// sourceCommitURL returns the web URL of the commit the revision of the source
+// of the Konfiguration names, or an empty string if the source is not a
+// GitRepository or the revision does not name a commit. Derived sources share
+// the URL of the GitRepository they are derived from.
+func (r *KonfigurationReconciler) sourceCommitURL(ctx context.Context, konfig *appsv1.Konfiguration, revision string) string {
+	repository, sha := r.sourceRepository(ctx, konfig, revision)
+	if repository == nil {
+		return ""
+	}
+	return commitURL(repository.Spec.URL, sha)
+}
+
+// sourceRepository returns the GitRepository the Konfiguration is rendered
+// from and the SHA of the commit the revision names, or nil if the source is
+// not a GitRepository or the revision does not name a commit.
+func (r *KonfigurationReconciler) sourceRepository(ctx context.Context, konfig *appsv1.Konfiguration, revision string) (*sourcev1.GitRepository, string) {
+	sref := konfig.GetSourceRef()
+	sha := commitSHA(revision)
+	if sref == nil || sref.Kind != sourcev1.GitRepositoryKind || sha == "" {
+		return nil, ""
+	}
+	source, err := sref.GetSource(ctx, r.Client)
+	if err != nil {
+		return nil, ""
+	}
+	repository, ok := source.(*sourcev1.GitRepository)
+	if !ok {
+		return nil, ""
+	}
+	return repository, sha
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import "testing"
+
+func TestCommitURL(t *testing.T) {
+	const sha = "1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d"
+	tests := []struct {
+		name     string
+		repoURL  string
+		revision string
+		want     string
+	}{
+		{name: "HTTPS URL", repoURL: "https://github.com/org/repo", revision: "main/" + sha, want: "https://github.com/org/repo/commit/" + sha},
+		{name: "HTTPS URL with suffix", repoURL: "https://gitlab.com/group/sub/repo.git/", revision: "main/" + sha, want: "https://gitlab.com/group/sub/repo/commit/" + sha},
+		{name: "SSH URL with port", repoURL: "ssh://git@gitea.example.com:2222/org/repo.git", revision: "main/" + sha, want: "https://gitea.example.com/org/repo/commit/" + sha},
+		{name: "scp-like URL", repoURL: "git@github.com:org/repo.git", revision: "main/" + sha, want: "https://github.com/org/repo/commit/" + sha},
+		{name: "bare SHA revision", repoURL: "https://github.com/org/repo", revision: sha, want: "https://github.com/org/repo/commit/" + sha},
+		{name: "URL without path", repoURL: "https://github.com/", revision: "main/" + sha},
+		{name: "unparsable URL", repoURL: "repo", revision: "main/" + sha},
+		{name: "revision without SHA", repoURL: "https://github.com/org/repo", revision: "v1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if sha := commitSHA(tt.revision); sha != "" {
+				got = commitURL(tt.repoURL, sha)
+			}
+			if got != tt.want {
+				t.Errorf("commitURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
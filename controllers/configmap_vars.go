@@ -0,0 +1,100 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// withConfigMapVars returns a copy of the Konfiguration that passes the values
+// of its variables read from ConfigMap keys to kubecfg in files, written to a
+// new directory the returned function removes. As with JsonnetLibRefs, the
+// ConfigMaps are read directly rather than through the cache.
+func (r *KonfigurationReconciler) withConfigMapVars(ctx context.Context, konfig *appsv1.Konfiguration) (*appsv1.Konfiguration, func(), error) {
+	vars := konfig.GetVariables()
+	if vars == nil {
+		return konfig, func() {}, nil
+	}
+	flags := []struct {
+		flag string
+		refs map[string]appsv1.ConfigMapKeyRef
+	}{
+		{"--ext-str-file", vars.ExtStrFromConfigMaps},
+		{"--ext-code-file", vars.ExtCodeFromConfigMaps},
+		{"--tla-str-file", vars.TLAStrFromConfigMaps},
+		{"--tla-code-file", vars.TLACodeFromConfigMaps},
+	}
+	n := 0
+	for _, f := range flags {
+		n += len(f.refs)
+	}
+	if n == 0 {
+		return konfig, func() {}, nil
+	}
+
+	dir, err := ioutil.TempDir(r.artifacts.root, konfig.GetName()+"-vars-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := func() { os.RemoveAll(dir) }
+	ck := konfig.DeepCopy()
+	configMaps := make(map[string]map[string]string)
+	i := 0
+	for _, f := range flags {
+		for name, ref := range f.refs {
+			data, ok := configMaps[ref.Name]
+			if !ok {
+				cm, err := r.clientset.CoreV1().ConfigMaps(konfig.GetNamespace()).Get(ctx, ref.Name, metav1.GetOptions{})
+				if err != nil {
+					remove()
+					return nil, nil, unresolvedVariables([]string{name},
+						fmt.Errorf("failed to read '%s' from ConfigMap '%s/%s': %w", name, konfig.GetNamespace(), ref.Name, err))
+				}
+				data = cm.Data
+				for key, value := range cm.BinaryData {
+					if data == nil {
+						data = make(map[string]string)
+					}
+					data[key] = string(value)
+				}
+				configMaps[ref.Name] = data
+			}
+			value, ok := data[ref.Key]
+			if !ok {
+				remove()
+				return nil, nil, unresolvedVariables([]string{name},
+					fmt.Errorf("ConfigMap '%s/%s' has no key '%s' for '%s'", konfig.GetNamespace(), ref.Name, ref.Key, name))
+			}
+			path := filepath.Join(dir, fmt.Sprint(i))
+			if err := ioutil.WriteFile(path, []byte(value), 0600); err != nil {
+				remove()
+				return nil, nil, err
+			}
+			ck.Spec.KubecfgArgs = append(ck.Spec.KubecfgArgs, f.flag, fmt.Sprintf("%s=%s", name, path))
+			i++
+		}
+	}
+	return ck, remove, nil
+}
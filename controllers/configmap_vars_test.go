@@ -0,0 +1,116 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestWithConfigMapVars(t *testing.T) {
+	root, err := ioutil.TempDir("", "artifacts-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "settings"},
+		Data:       map[string]string{"replicas": "3"},
+		BinaryData: map[string][]byte{"cert": []byte("pem")},
+	}
+
+	tests := []struct {
+		name     string
+		vars     *appsv1.Variables
+		wantFlag string
+		want     string
+		wantErr  bool
+	}{
+		{name: "no variables"},
+		{
+			name:     "external string from a key",
+			vars:     &appsv1.Variables{ExtStrFromConfigMaps: map[string]appsv1.ConfigMapKeyRef{"replicas": {Name: "settings", Key: "replicas"}}},
+			wantFlag: "--ext-str-file",
+			want:     "3",
+		},
+		{
+			name:     "top level code from a binary key",
+			vars:     &appsv1.Variables{TLACodeFromConfigMaps: map[string]appsv1.ConfigMapKeyRef{"cert": {Name: "settings", Key: "cert"}}},
+			wantFlag: "--tla-code-file",
+			want:     "pem",
+		},
+		{
+			name:    "missing key",
+			vars:    &appsv1.Variables{ExtCodeFromConfigMaps: map[string]appsv1.ConfigMapKeyRef{"replicas": {Name: "settings", Key: "missing"}}},
+			wantErr: true,
+		},
+		{
+			name:    "missing ConfigMap",
+			vars:    &appsv1.Variables{TLAStrFromConfigMaps: map[string]appsv1.ConfigMapKeyRef{"replicas": {Name: "missing", Key: "replicas"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler()
+			r.clientset = kubefake.NewSimpleClientset(cm)
+			r.artifacts = newArtifactCache(root)
+			konfig := testKonfiguration()
+			konfig.Spec.Variables = tt.vars
+
+			ck, cleanup, err := r.withConfigMapVars(context.TODO(), konfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("withConfigMapVars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var ve *variablesError
+				if !errors.As(err, &ve) {
+					t.Errorf("withConfigMapVars() error = %v, want unresolved variables", err)
+				}
+				return
+			}
+			defer cleanup()
+			args := ck.Spec.KubecfgArgs
+			if tt.wantFlag == "" {
+				if len(args) != 0 {
+					t.Errorf("withConfigMapVars() args = %v, want none", args)
+				}
+				return
+			}
+			if len(args) != 2 || args[0] != tt.wantFlag {
+				t.Fatalf("withConfigMapVars() args = %v, want %s", args, tt.wantFlag)
+			}
+			path := args[1][strings.Index(args[1], "=")+1:]
+			if data, _ := ioutil.ReadFile(path); string(data) != tt.want {
+				t.Errorf("value = %q, want %q", data, tt.want)
+			}
+			if len(konfig.Spec.KubecfgArgs) != 0 {
+				t.Errorf("the Konfiguration was modified: %v", konfig.Spec.KubecfgArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// defaultDependencyRequeueInterval is how often a Konfiguration waiting on its
+// dependencies is checked again, unless set otherwise.
+const defaultDependencyRequeueInterval = 30 * time.Second
+
+// checkDependencies returns an error with the DependencyNotReadyReason naming
+// the first Konfiguration listed in DependsOn that does not exist or is not
+// ready at its current generation. Dependencies are looked up in the namespace
+// of the given Konfiguration unless they set one.
+func (r *KonfigurationReconciler) checkDependencies(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	for _, dep := range konfig.Spec.DependsOn {
+		key := client.ObjectKey{Namespace: dep.Namespace, Name: dep.Name}
+		if key.Namespace == "" {
+			key.Namespace = konfig.GetNamespace()
+		}
+		var dependency appsv1.Konfiguration
+		if err := r.Get(ctx, key, &dependency); err != nil {
+			if apierrors.IsNotFound(err) {
+				return withReason(appsv1.DependencyNotReadyReason, fmt.Errorf("dependency '%s' does not exist", key))
+			}
+			return err
+		}
+		if !isReady(&dependency) {
+			return withReason(appsv1.DependencyNotReadyReason, fmt.Errorf("dependency '%s' is not ready", key))
+		}
+	}
+	return nil
+}
+
+// checkDependencyRevisions returns an error with the DependencyNotReadyReason
+// naming the first dependency of the Konfiguration that constrains its revision
+// and has not last applied it. Dependencies with sameRevisionAs must have last
+// applied the given revision, which the Konfiguration is about to apply.
+func (r *KonfigurationReconciler) checkDependencyRevisions(ctx context.Context, konfig *appsv1.Konfiguration, revision string) error {
+	for _, dep := range konfig.Spec.DependsOn {
+		want := dep.Revision
+		if dep.SameRevisionAs {
+			want = revision
+		}
+		if want == "" {
+			continue
+		}
+		key := client.ObjectKey{Namespace: dep.Namespace, Name: dep.Name}
+		if key.Namespace == "" {
+			key.Namespace = konfig.GetNamespace()
+		}
+		var dependency appsv1.Konfiguration
+		if err := r.Get(ctx, key, &dependency); err != nil {
+			if apierrors.IsNotFound(err) {
+				return withReason(appsv1.DependencyNotReadyReason, fmt.Errorf("dependency '%s' does not exist", key))
+			}
+			return err
+		}
+		if applied := dependency.Status.LastAppliedRevision; applied != want {
+			return withReason(appsv1.DependencyNotReadyReason,
+				fmt.Errorf("dependency '%s' applied revision '%s', waiting for '%s'", key, applied, want))
+		}
+	}
+	return nil
+}
+
+// dependencyRequeueInterval returns how long to wait before checking the
+// dependencies of a Konfiguration again.
+func (r *KonfigurationReconciler) dependencyRequeueInterval() time.Duration {
+	if interval := r.settings.get().dependencyRequeue; interval > 0 {
+		return interval
+	}
+	return defaultDependencyRequeueInterval
+}
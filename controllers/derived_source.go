@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// reconcileDerivedSource ensures a copy of the GitRepository referenced by the
+// Konfiguration exists with the Git ref overridden by the source reference,
+// pinned revision or revision selector, and returns it. The copy is created in
+// the namespace of the original so that any secrets it references are still
+// resolvable, so GitRepositories of other namespaces are only copied if they
+// allow the namespace of the Konfiguration. An existing GitRepository not
+// labeled for the Konfiguration is never taken over.
+func (r *KonfigurationReconciler) reconcileDerivedSource(ctx context.Context, konfig *appsv1.Konfiguration) (sourcev1.Source, error) {
+	upstream, err := r.derivedSourceUpstream(ctx, konfig)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := &sourcev1.GitRepository{}
+	derived.SetName(konfig.GetDerivedSourceName())
+	derived.SetNamespace(upstream.GetNamespace())
+	key := client.ObjectKeyFromObject(konfig)
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, derived, func() error {
+		if derived.GetResourceVersion() != "" && !r.ownership.ownedBy(derived.GetLabels(), key) {
+			return withReason(appsv1.ArtifactFailedReason, fmt.Errorf("GitRepository exists and is not managed for the Konfiguration"))
+		}
+		labels := derived.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		r.ownership.set(labels, key)
+		derived.SetLabels(labels)
+		derived.Spec = derivedSourceSpec(upstream, konfig)
+		// Owner references cannot cross namespaces, copies in other
+		// namespaces are removed by cleanupDerivedSources instead, see
+		// requestsForOwnerOfDerivedSource.
+		if konfig.GetNamespace() == derived.GetNamespace() {
+			return controllerutil.SetControllerReference(konfig, derived, r.Scheme)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile derived source '%s/%s': %w", derived.GetNamespace(), derived.GetName(), err)
+	}
+	return derived, nil
+}
+
+// observeDerivedSource returns the copy of the GitRepository referenced by the
+// Konfiguration like reconcileDerivedSource, without writing to the cluster.
+// An error is returned if the copy does not exist or differs from the one
+// reconcileDerivedSource would write, since its artifact is not the one the
+// Konfiguration would apply.
+func (r *KonfigurationReconciler) observeDerivedSource(ctx context.Context, konfig *appsv1.Konfiguration) (sourcev1.Source, error) {
+	upstream, err := r.derivedSourceUpstream(ctx, konfig)
+	if err != nil {
+		return nil, err
+	}
+	derived := &sourcev1.GitRepository{}
+	key := client.ObjectKey{Namespace: upstream.GetNamespace(), Name: konfig.GetDerivedSourceName()}
+	if err := r.Get(ctx, key, derived); apierrors.IsNotFound(err) {
+		return nil, withReason(appsv1.ArtifactFailedReason, fmt.Errorf("derived source '%s' does not exist and is not created in observe-only mode", key))
+	} else if err != nil {
+		return nil, err
+	}
+	if !r.ownership.ownedBy(derived.GetLabels(), client.ObjectKeyFromObject(konfig)) {
+		return nil, withReason(appsv1.ArtifactFailedReason, fmt.Errorf("GitRepository '%s' exists and is not managed for the Konfiguration", key))
+	}
+	if !apiequality.Semantic.DeepEqual(derived.Spec, derivedSourceSpec(upstream, konfig)) {
+		return nil, withReason(appsv1.ArtifactFailedReason, fmt.Errorf("derived source '%s' is out of date and is not updated in observe-only mode", key))
+	}
+	return derived, nil
+}
+
+// derivedSourceUpstream returns the GitRepository referenced by the
+// Konfiguration, if it allows the Konfiguration to derive a copy of it.
+func (r *KonfigurationReconciler) derivedSourceUpstream(ctx context.Context, konfig *appsv1.Konfiguration) (*sourcev1.GitRepository, error) {
+	sourceRef := konfig.GetSourceRef()
+	if sourceRef.Kind != sourcev1.GitRepositoryKind {
+		return nil, fmt.Errorf("ref overrides, pinned revisions and revision selectors are not supported for source kind '%s'", sourceRef.Kind)
+	}
+
+	var upstream sourcev1.GitRepository
+	if err := r.Get(ctx, client.ObjectKey{Namespace: sourceRef.Namespace, Name: sourceRef.Name}, &upstream); err != nil {
+		return nil, err
+	}
+	if !allowsDerivedSources(&upstream, konfig.GetNamespace()) {
+		return nil, withReason(appsv1.ArtifactFailedReason, fmt.Errorf("GitRepository '%s/%s' does not allow Konfigurations of namespace '%s' to override its ref in its %s annotation",
+			upstream.GetNamespace(), upstream.GetName(), konfig.GetNamespace(), appsv1.AllowDerivedSourcesAnnotation))
+	}
+	return &upstream, nil
+}
+
+// derivedSourceSpec returns the spec of the copy of the given GitRepository
+// derived for the Konfiguration.
+func derivedSourceSpec(upstream *sourcev1.GitRepository, konfig *appsv1.Konfiguration) sourcev1.GitRepositorySpec {
+	spec := *upstream.Spec.DeepCopy()
+	spec.Reference = konfig.GetSourceGitRef(upstream.Spec.Reference)
+	return spec
+}
+
+// allowsDerivedSources returns true if Konfigurations of the given namespace
+// may derive sources from the GitRepository.
+func allowsDerivedSources(repository *sourcev1.GitRepository, namespace string) bool {
+	if repository.GetNamespace() == namespace {
+		return true
+	}
+	for _, allowed := range strings.Split(repository.GetAnnotations()[appsv1.AllowDerivedSourcesAnnotation], ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// requestsForOwnerOfDerivedSource returns a request for the Konfiguration a
+// GitRepository of another namespace was derived for, so the copy is removed
+// by the reconciliation if the Konfiguration no longer exists, e.g. because it
+// was deleted while the controller was down.
+func (r *KonfigurationReconciler) requestsForOwnerOfDerivedSource(obj client.Object) []reconcile.Request {
+	key, ok := r.ownership.owner(obj.GetLabels())
+	if !ok || key.Namespace == obj.GetNamespace() {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+// cleanupDerivedSources removes any GitRepositories created on behalf of the
+// given Konfiguration, except the one named by keep (if any).
+func (r *KonfigurationReconciler) cleanupDerivedSources(ctx context.Context, key client.ObjectKey, keep string) error {
+	for _, selector := range r.ownership.selectors(key) {
+		var list sourcev1.GitRepositoryList
+		if err := r.List(ctx, &list, selector); err != nil {
+			// Nothing to clean up if source-controller is not installed
+			if apimeta.IsNoMatchError(err) {
+				return nil
+			}
+			return err
+		}
+		for i := range list.Items {
+			if list.Items[i].GetName() == keep {
+				continue
+			}
+			if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestReconcileDerivedSource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, sourcev1.AddToScheme, appsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ownership, err := newOwnershipLabels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "repo"}}
+	konfiguration := func() *appsv1.Konfiguration {
+		konfig := testKonfiguration()
+		konfig.Spec.SourceRef = &appsv1.CrossNamespaceSourceReference{
+			Kind: sourcev1.GitRepositoryKind,
+			Name: "repo",
+			Ref:  &sourcev1.GitRepositoryRef{Branch: "preview"},
+		}
+		return konfig
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		exists  bool
+		wantErr bool
+	}{
+		{name: "new copy"},
+		{name: "copy of the Konfiguration", exists: true, labels: ownership.labels(client.ObjectKey{Namespace: "team", Name: "app"})},
+		{name: "GitRepository of another Konfiguration", exists: true, labels: ownership.labels(client.ObjectKey{Namespace: "team", Name: "other"}), wantErr: true},
+		{name: "unlabeled GitRepository", exists: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []client.Object{upstream.DeepCopy()}
+			if tt.exists {
+				objs = append(objs, &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{
+					Namespace: "team",
+					Name:      konfiguration().GetDerivedSourceName(),
+					Labels:    tt.labels,
+				}})
+			}
+			r := &KonfigurationReconciler{
+				Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				Scheme:    scheme,
+				ownership: ownership,
+			}
+			derived, err := r.reconcileDerivedSource(context.TODO(), konfiguration())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileDerivedSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			ref := derived.(*sourcev1.GitRepository).Spec.Reference
+			if ref == nil || ref.Branch != "preview" {
+				t.Errorf("reference = %v, want branch 'preview'", ref)
+			}
+		})
+	}
+}
+
+func TestRequestsForOwnerOfDerivedSource(t *testing.T) {
+	ownership, err := newOwnershipLabels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &KonfigurationReconciler{ownership: ownership}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   int
+	}{
+		{name: "unlabeled GitRepository"},
+		{name: "copy in the namespace of the Konfiguration", labels: ownership.labels(client.ObjectKey{Namespace: "flux-system", Name: "app"})},
+		{name: "copy in another namespace", labels: ownership.labels(client.ObjectKey{Namespace: "team", Name: "app"}), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "repo-team-app", Labels: tt.labels}}
+			if got := r.requestsForOwnerOfDerivedSource(repo); len(got) != tt.want {
+				t.Errorf("requestsForOwnerOfDerivedSource() = %v, want %d requests", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveDerivedSource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, sourcev1.AddToScheme, appsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ownership, err := newOwnershipLabels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "repo"},
+		Spec:       sourcev1.GitRepositorySpec{URL: "https://example.com/repo"},
+	}
+	konfig := testKonfiguration()
+	konfig.Spec.SourceRef = &appsv1.CrossNamespaceSourceReference{
+		Kind: sourcev1.GitRepositoryKind,
+		Name: "repo",
+		Ref:  &sourcev1.GitRepositoryRef{Branch: "preview"},
+	}
+	copyOf := func(labels map[string]string, spec sourcev1.GitRepositorySpec) *sourcev1.GitRepository {
+		return &sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: konfig.GetDerivedSourceName(), Labels: labels},
+			Spec:       spec,
+		}
+	}
+	owned := ownership.labels(client.ObjectKeyFromObject(konfig))
+
+	tests := []struct {
+		name    string
+		derived *sourcev1.GitRepository
+		wantErr bool
+	}{
+		{name: "missing copy", wantErr: true},
+		{name: "up to date copy", derived: copyOf(owned, derivedSourceSpec(upstream, konfig))},
+		{name: "out of date copy", derived: copyOf(owned, upstream.Spec), wantErr: true},
+		{name: "GitRepository of another Konfiguration", derived: copyOf(nil, derivedSourceSpec(upstream, konfig)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []client.Object{upstream.DeepCopy()}
+			if tt.derived != nil {
+				objs = append(objs, tt.derived)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+			r := &KonfigurationReconciler{Client: c, Scheme: scheme, ownership: ownership}
+			if _, err := r.observeDerivedSource(context.TODO(), konfig.DeepCopy()); (err != nil) != tt.wantErr {
+				t.Fatalf("observeDerivedSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var list sourcev1.GitRepositoryList
+			if err := c.List(context.TODO(), &list); err != nil {
+				t.Fatal(err)
+			}
+			if want := len(objs); len(list.Items) != want {
+				t.Errorf("%d GitRepositories exist, want %d", len(list.Items), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// newTestDownloader returns a reconciler downloading artifacts into a
+// temporary directory without retries.
+func newTestDownloader(t *testing.T) *KonfigurationReconciler {
+	httpClient := retryablehttp.NewClient()
+	httpClient.RetryMax = 0
+	httpClient.Logger = nil
+	return &KonfigurationReconciler{httpClient: httpClient, artifacts: newArtifactCache(t.TempDir())}
+}
+
+func TestDownloadAndExtractToDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	r := newTestDownloader(t)
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := r.downloadAndExtractTo(ctx, server.URL, "", t.TempDir(), nil); err == nil {
+		t.Fatalf("downloadAndExtractTo() succeeded past the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("downloadAndExtractTo() returned after %s, past the deadline", elapsed)
+	}
+}
+
+func TestDownloadAndExtractToChecksum(t *testing.T) {
+	artifact := testArtifact(t, &tar.Header{Name: "main.jsonnet", Typeflag: tar.TypeReg, Mode: 0644})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(artifact)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{name: "no checksum"},
+		{name: "sha1 checksum", checksum: fmt.Sprintf("%x", sha1.Sum(artifact))},
+		{name: "sha256 checksum", checksum: fmt.Sprintf("%x", sha256.Sum256(artifact))},
+		{name: "prefixed sha256 checksum", checksum: fmt.Sprintf("sha256:%x", sha256.Sum256(artifact))},
+		{name: "checksum of an unknown algorithm", checksum: "md5:0123"},
+		{name: "mismatching sha1 checksum", checksum: fmt.Sprintf("%x", sha1.Sum(nil)), wantErr: true},
+		{name: "mismatching sha256 checksum", checksum: fmt.Sprintf("%x", sha256.Sum256(nil)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestDownloader(t)
+			if err := r.downloadAndExtractTo(context.TODO(), server.URL, tt.checksum, t.TempDir(), nil); (err != nil) != tt.wantErr {
+				t.Errorf("downloadAndExtractTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// defaultThrottleDelay is how long to wait before retrying after the API server
+// rejected a request for too many requests without suggesting a delay.
+const defaultThrottleDelay = 5 * time.Second
+
+// throttleJitter is the maximum fraction of the delay added to it when
+// retrying after the API server throttled the controller, so throttled
+// Konfigurations do not all retry at once.
+const throttleJitter = 0.5
+
+// throttledRegex matches the errors reported by kubecfg for requests rejected
+// with 429 Too Many Requests, including by API priority and fairness.
+var throttledRegex = regexp.MustCompile(`(?i)too many requests`)
+
+// reconcileError is a failure of a stage of the reconciliation. The reason is
+// recorded on the Ready condition of the Konfiguration, and the reconciliation
+// is retried on the RetryInterval rather than returned to the work queue, or
+// after the delay suggested by the API server if it throttled the controller.
+type reconcileError struct {
+	reason string
+	err    error
+}
+
+func (e *reconcileError) Error() string { return e.err.Error() }
+
+func (e *reconcileError) Unwrap() error { return e.err }
+
+// withReason wraps err in a reconcileError with the given reason, unless it
+// already carries one.
+func withReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var re *reconcileError
+	if errors.As(err, &re) {
+		return err
+	}
+	return &reconcileError{reason: reason, err: err}
+}
+
+// isReconcileError returns true if err is a failure of a reconciliation stage.
+func isReconcileError(err error) bool {
+	var re *reconcileError
+	return errors.As(err, &re)
+}
+
+// reasonFor returns the condition reason for the given error.
+func reasonFor(err error) string {
+	var re *reconcileError
+	if errors.As(err, &re) {
+		return re.reason
+	}
+	return meta.ReconciliationFailedReason
+}
+
+// throttleDelay returns the delay suggested by the API server and true if err
+// is the rejection of a request with 429 Too Many Requests, such as by API
+// priority and fairness. Rejections of the requests of kubecfg are told from
+// what it wrote to stderr, and carry no suggested delay, so the default one is
+// returned.
+func throttleDelay(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	if apierrors.IsTooManyRequests(err) {
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		return defaultThrottleDelay, true
+	}
+	if throttledRegex.MatchString(kubecfgStderr(err)) {
+		return defaultThrottleDelay, true
+	}
+	return 0, false
+}
+
+// retryAfter returns how long to wait before retrying a reconciliation of the
+// Konfiguration that failed with err: the delay suggested by the API server
+// plus jitter if it throttled the controller, or the retry interval otherwise.
+func retryAfter(konfig *appsv1.Konfiguration, err error) time.Duration {
+	if delay, ok := throttleDelay(err); ok {
+		return wait.Jitter(delay, throttleJitter)
+	}
+	return konfig.GetRetryInterval()
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// kubecfgUpdateFailure returns the error of a failed kubecfg update that wrote
+// the given output to stderr.
+func kubecfgUpdateFailure(t *testing.T, stderr string) error {
+	err := exec.Command("sh", "-c", "exit 1").Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected an exit error, got %v", err)
+	}
+	return &kubecfgError{err: exitErr, stderr: sanitizeStderr(bytes.NewBufferString(stderr))}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	throttled := `time="2021-06-01T12:00:00Z" level=info msg="Updating deployments web (default)"
+W0601 12:00:00.000000       1 warnings.go:70] extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+
+time="2021-06-01T12:00:01Z" level=fatal msg="Error updating deployments web (default): the server has received too many requests and has asked us to try again later (patch deployments.apps web)"
+`
+	denied := `time="2021-06-01T12:00:00Z" level=info msg="Updating deployments web (default)"
+time="2021-06-01T12:00:01Z" level=fatal msg="Error updating deployments web (default): deployments.apps \"web\" is forbidden: User \"system:serviceaccount:default:kubecfg\" cannot patch resource \"deployments\" in API group \"apps\" in the namespace \"default\""
+`
+	for _, tc := range []struct {
+		name      string
+		err       error
+		delay     time.Duration
+		throttled bool
+	}{
+		{name: "nil"},
+		{name: "kubecfg update throttled", err: kubecfgUpdateFailure(t, throttled), delay: defaultThrottleDelay, throttled: true},
+		{name: "kubecfg update throttled while pruning", err: withReason(appsv1.PruneFailedReason, kubecfgUpdateFailure(t, throttled)),
+			delay: defaultThrottleDelay, throttled: true},
+		{name: "kubecfg update denied", err: kubecfgUpdateFailure(t, denied)},
+		{name: "API server throttled with delay", err: apierrors.NewTooManyRequests("too many requests", 3), delay: 3 * time.Second, throttled: true},
+		{name: "API server throttled without delay", err: apierrors.NewTooManyRequests("too many requests", 0), delay: defaultThrottleDelay, throttled: true},
+		{name: "not found", err: apierrors.NewNotFound(appsv1.GroupVersion.WithResource("konfigurations").GroupResource(), "web")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, throttled := throttleDelay(tc.err)
+			if throttled != tc.throttled || delay != tc.delay {
+				t.Errorf("throttleDelay() = %v, %v, want %v, %v", delay, throttled, tc.delay, tc.throttled)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// evaluateManifests renders the manifests at path, in the controller if the
+// Konfiguration enabled in-process evaluation or with kubecfg otherwise, and
+// passes the output to decode. It returns the output of any std.trace calls
+// made during the evaluation, even if it failed.
+func evaluateManifests(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, libDirs []string, path string, decode func(io.Reader) error) (string, error) {
+	if konfig.FeatureGateEnabled(appsv1.FeatureGateInProcessEvaluation) {
+		return evaluateInProcess(ctx, log, konfig, libDirs, path, decode)
+	}
+	return runKubecfgShow(ctx, log, konfig, libDirs, path, decode)
+}
+
+// evaluateInProcess renders the manifests at path with the Jsonnet VM kubecfg
+// is built on, given the same libraries and variables as kubecfg show. The
+// evaluation cannot be interrupted, so once the timeout of the Konfiguration
+// expires it is left to complete in the background.
+func evaluateInProcess(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, libDirs []string, path string, decode func(io.Reader) error) (string, error) {
+	if httpPathRegex.MatchString(path) {
+		return "", fmt.Errorf("%s only evaluates local files, not '%s'", appsv1.FeatureGateInProcessEvaluation, path)
+	}
+	args := konfig.ToShowArgs(libDirs, path)
+	err := kubecfgFlags(args, func(name, value string) error {
+		if name == "resolve-images" && value != "noop" {
+			return fmt.Errorf("%s cannot resolve images with '%s'", appsv1.FeatureGateInProcessEvaluation, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	vm, err := newJsonnetVM(args)
+	if err != nil {
+		return "", err
+	}
+	var traceBuf bytes.Buffer
+	vm.SetTraceOut(&traceBuf)
+
+	evalCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
+	defer cancel()
+
+	type result struct {
+		out   string
+		trace string
+		err   error
+	}
+	done := make(chan result, 1)
+	log.Info("Evaluating manifests in-process", "Path", path)
+	go func() {
+		out, err := vm.EvaluateFile(path)
+		done <- result{out: out, trace: traceBuf.String(), err: err}
+	}()
+
+	select {
+	case <-evalCtx.Done():
+		return "", fmt.Errorf("evaluation did not complete: %w", evalCtx.Err())
+	case res := <-done:
+		trace := evaluationTrace(res.trace)
+		if res.err != nil {
+			return trace, fmt.Errorf("evaluation failed: %w", res.err)
+		}
+		return trace, decode(strings.NewReader(res.out))
+	}
+}
+
+// newJsonnetVM returns a Jsonnet VM configured with the libraries, external
+// variables and top-level arguments of the given kubecfg arguments, and the
+// native functions kubecfg provides. Flags that have no effect on the
+// evaluation, or like resolve-images only on its output, are skipped.
+func newJsonnetVM(args []string) (*jsonnet.VM, error) {
+	vm := jsonnet.MakeVM()
+	var jpaths []string
+	err := kubecfgFlags(args, func(name, value string) error {
+		switch name {
+		case "jpath", "J":
+			jpaths = append(jpaths, value)
+		case "ext-str", "V", "ext-code", "tla-str", "A", "tla-code":
+			key, val, err := variableArg(value)
+			if err != nil {
+				return fmt.Errorf("--%s: %w", name, err)
+			}
+			setVariable(vm, name, key, val)
+		case "ext-str-file", "ext-code-file", "tla-str-file", "tla-code-file":
+			key, file, err := variableArg(value)
+			if err != nil {
+				return fmt.Errorf("--%s: %w", name, err)
+			}
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("--%s: %w", name, err)
+			}
+			setVariable(vm, strings.TrimSuffix(name, "-file"), key, string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: jpaths})
+	for _, f := range kubecfgNativeFuncs() {
+		vm.NativeFunction(f)
+	}
+	return vm, nil
+}
+
+// kubecfgFlags calls fn with the name and value of each flag in the given
+// kubecfg arguments, in order, stopping at the first error.
+func kubecfgFlags(args []string, fn func(name, value string) error) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value := strings.TrimLeft(arg, "-"), ""
+		if idx := strings.Index(name, "="); idx != -1 {
+			name, value = name[:idx], name[idx+1:]
+		} else if takesValue(name) && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if err := fn(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// takesValue returns true if the kubecfg flag of the given name takes a value.
+func takesValue(name string) bool {
+	switch name {
+	case "ignore-unknown", "verbose", "v", "validate", "dry-run", "skip-gc":
+		return false
+	}
+	return true
+}
+
+// variableArg splits a variable argument of the form key=value. A bare key
+// takes its value from the environment variable of the same name, as with
+// kubecfg.
+func variableArg(arg string) (string, string, error) {
+	if idx := strings.Index(arg, "="); idx != -1 {
+		return arg[:idx], arg[idx+1:], nil
+	}
+	val, ok := os.LookupEnv(arg)
+	if !ok {
+		return "", "", fmt.Errorf("environment variable '%s' is not set", arg)
+	}
+	return arg, val, nil
+}
+
+// setVariable sets the external variable or top-level argument of the kind
+// named by the given kubecfg flag.
+func setVariable(vm *jsonnet.VM, flag, key, val string) {
+	switch flag {
+	case "ext-str", "V":
+		vm.ExtVar(key, val)
+	case "ext-code":
+		vm.ExtCode(key, val)
+	case "tla-str", "A":
+		vm.TLAVar(key, val)
+	case "tla-code":
+		vm.TLACode(key, val)
+	}
+}
+
+// kubecfgNativeFuncs returns the native functions kubecfg makes available to
+// the manifests through std.native. Images are never resolved, so
+// resolveImage returns the image as is.
+func kubecfgNativeFuncs() []*jsonnet.NativeFunction {
+	return []*jsonnet.NativeFunction{
+		{
+			Name:   "parseJson",
+			Params: ast.Identifiers{"json"},
+			Func: func(args []interface{}) (res interface{}, err error) {
+				data, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("parseJson: json must be a string")
+				}
+				err = json.Unmarshal([]byte(data), &res)
+				return res, err
+			},
+		},
+		{
+			Name:   "parseYaml",
+			Params: ast.Identifiers{"yaml"},
+			Func: func(args []interface{}) (interface{}, error) {
+				data, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("parseYaml: yaml must be a string")
+				}
+				docs := []interface{}{}
+				reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(data)))
+				for {
+					doc, err := reader.Read()
+					if err == io.EOF {
+						return docs, nil
+					} else if err != nil {
+						return nil, err
+					}
+					var v interface{}
+					if err := yaml.Unmarshal(doc, &v); err != nil {
+						return nil, err
+					}
+					if v != nil {
+						docs = append(docs, v)
+					}
+				}
+			},
+		},
+		{
+			Name:   "manifestJsonFromJson",
+			Params: ast.Identifiers{"json", "indent"},
+			Func: func(args []interface{}) (interface{}, error) {
+				data, ok := args[0].(string)
+				indent, ok2 := args[1].(float64)
+				if !ok || !ok2 {
+					return nil, fmt.Errorf("manifestJsonFromJson: json must be a string and indent a number")
+				}
+				var buf bytes.Buffer
+				if err := json.Indent(&buf, []byte(data), "", strings.Repeat(" ", int(indent))); err != nil {
+					return nil, err
+				}
+				buf.WriteString("\n")
+				return buf.String(), nil
+			},
+		},
+		{
+			Name:   "manifestYamlFromJson",
+			Params: ast.Identifiers{"json"},
+			Func: func(args []interface{}) (interface{}, error) {
+				data, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("manifestYamlFromJson: json must be a string")
+				}
+				out, err := yaml.JSONToYAML([]byte(data))
+				return string(out), err
+			},
+		},
+		{
+			Name:   "resolveImage",
+			Params: ast.Identifiers{"image"},
+			Func: func(args []interface{}) (interface{}, error) {
+				return args[0], nil
+			},
+		},
+		{
+			Name:   "escapeStringRegex",
+			Params: ast.Identifiers{"str"},
+			Func: func(args []interface{}) (interface{}, error) {
+				str, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("escapeStringRegex: str must be a string")
+				}
+				return regexp.QuoteMeta(str), nil
+			},
+		},
+		{
+			Name:   "regexMatch",
+			Params: ast.Identifiers{"regex", "string"},
+			Func: func(args []interface{}) (interface{}, error) {
+				expr, ok := args[0].(string)
+				str, ok2 := args[1].(string)
+				if !ok || !ok2 {
+					return nil, fmt.Errorf("regexMatch: regex and string must be strings")
+				}
+				return regexp.MatchString(expr, str)
+			},
+		},
+		{
+			Name:   "regexSubst",
+			Params: ast.Identifiers{"regex", "src", "repl"},
+			Func: func(args []interface{}) (interface{}, error) {
+				expr, ok := args[0].(string)
+				src, ok2 := args[1].(string)
+				repl, ok3 := args[2].(string)
+				if !ok || !ok2 || !ok3 {
+					return nil, fmt.Errorf("regexSubst: regex, src and repl must be strings")
+				}
+				re, err := regexp.Compile(expr)
+				if err != nil {
+					return nil, err
+				}
+				return re.ReplaceAllString(src, repl), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestEvaluateInProcess(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		args      []string
+		wantNames []string
+		wantTrace bool
+		wantErr   bool
+	}{
+		{
+			name:      "nested objects",
+			source:    `{ b: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: "b" } }, a: [{ apiVersion: "v1", kind: "ConfigMap", metadata: { name: "a" } }] }`,
+			wantNames: []string{"a", "b"},
+		},
+		{
+			name:      "external variables and libraries",
+			source:    `local lib = import "lib.libsonnet"; lib.configMap(std.extVar("name"))`,
+			args:      []string{"--ext-str", "name=from-var"},
+			wantNames: []string{"from-var"},
+		},
+		{
+			name:      "top-level arguments",
+			source:    `function(name) { apiVersion: "v1", kind: "ConfigMap", metadata: { name: name } }`,
+			args:      []string{"--tla-str=name=from-tla"},
+			wantNames: []string{"from-tla"},
+		},
+		{
+			name:      "native functions",
+			source:    `std.native("parseYaml")("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: parsed\n")`,
+			wantNames: []string{"parsed"},
+		},
+		{
+			name:      "trace",
+			source:    `std.trace("rendering", [])`,
+			wantTrace: true,
+		},
+		{
+			name:    "missing variable",
+			source:  `std.extVar("missing")`,
+			wantErr: true,
+		},
+		{
+			name:    "resolving images",
+			source:  `[]`,
+			args:    []string{"--resolve-images", "registry"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"main.jsonnet":      tt.source,
+				"lib/lib.libsonnet": `{ configMap(name):: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: name } } }`,
+			})
+			konfig := testKonfiguration()
+			konfig.Spec.FeatureGates = map[string]bool{appsv1.FeatureGateInProcessEvaluation: true}
+			konfig.Spec.KubecfgArgs = tt.args
+
+			var objs []*unstructured.Unstructured
+			trace, err := evaluateManifests(context.TODO(), logr.Discard(), konfig, []string{filepath.Join(dir, "lib")}, filepath.Join(dir, "main.jsonnet"), func(out io.Reader) (err error) {
+				objs, err = decodeManifests(out)
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluateManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.GetName())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+			if got := strings.HasPrefix(trace, "TRACE: "); got != tt.wantTrace {
+				t.Errorf("trace = %q, wantTrace %v", trace, tt.wantTrace)
+			}
+		})
+	}
+}
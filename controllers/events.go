@@ -0,0 +1,242 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-retryablehttp"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// controllerName is the name the controller reports events as.
+const controllerName = "kubecfg-operator"
+
+// signatureHeader is the header holding the HMAC signature of posted events.
+const signatureHeader = "X-Signature"
+
+// sinkEvent is the payload posted to event sinks.
+type sinkEvent struct {
+	InvolvedObject      corev1.ObjectReference `json:"involvedObject"`
+	Severity            string                 `json:"severity"`
+	Timestamp           metav1.Time            `json:"timestamp"`
+	Reason              string                 `json:"reason"`
+	Message             string                 `json:"message"`
+	Revision            string                 `json:"revision,omitempty"`
+	CommitURL           string                 `json:"commitURL,omitempty"`
+	ReportingController string                 `json:"reportingController"`
+}
+
+// eventQueueSize is the number of events waiting to be posted to event sinks
+// beyond which further events are dropped.
+const eventQueueSize = 100
+
+// eventSink posts events to an external HTTP endpoint.
+type eventSink struct {
+	address string
+	token   []byte
+}
+
+// eventPost is an event waiting to be posted to a sink.
+type eventPost struct {
+	sink *eventSink
+	evt  *sinkEvent
+}
+
+// eventQueue posts events to event sinks in the background, so an unavailable
+// endpoint does not hold up reconciliations. Events are dropped when the queue
+// is full.
+type eventQueue struct {
+	posts      chan eventPost
+	httpClient *retryablehttp.Client
+	log        logr.Logger
+}
+
+// newEventQueue returns a queue posting events once started by the manager.
+// Retries are kept short so an unavailable endpoint does not delay the events
+// queued after it for long.
+func newEventQueue(log logr.Logger) *eventQueue {
+	httpClient := retryablehttp.NewClient()
+	httpClient.RetryWaitMin = time.Second
+	httpClient.RetryWaitMax = 5 * time.Second
+	httpClient.RetryMax = 2
+	httpClient.Logger = nil
+	return &eventQueue{posts: make(chan eventPost, eventQueueSize), httpClient: httpClient, log: log}
+}
+
+// enqueue queues the event to be posted to the sink, returning false if the
+// queue is full.
+func (q *eventQueue) enqueue(sink *eventSink, evt *sinkEvent) bool {
+	select {
+	case q.posts <- eventPost{sink: sink, evt: evt}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start posts the queued events until the context is done.
+func (q *eventQueue) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case post := <-q.posts:
+			if err := q.post(ctx, post.sink, post.evt); err != nil {
+				q.log.Error(err, "Failed to post event", "Address", post.sink.address)
+			}
+		}
+	}
+}
+
+// eventSinkAllowed returns whether events may be posted to the given address
+// of the event sink of a Konfiguration, that is whether its host, with or
+// without its port, is one of the allowed hosts.
+func eventSinkAllowed(address string, allowedHosts []string) bool {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	for _, host := range allowedHosts {
+		if host == u.Host || host == u.Hostname() {
+			return true
+		}
+	}
+	return false
+}
+
+// notify emits an event for the outcome of a reconciliation when the Ready
+// condition of updated differs from the one recorded on konfig, so that
+// repeated outcomes on every interval are only reported once. The event is
+// recorded as a Kubernetes Event and posted to the controller and Konfiguration
+// event sinks, if configured, linking the commit reconciled when known. Events
+// are posted in the background, and failures to post them are logged and
+// otherwise ignored. The event sink of a Konfiguration is only posted to if its
+// host is allowed by the operator.
+func (r *KonfigurationReconciler) notify(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, updated appsv1.Konfiguration, revision string) {
+	cond := apimeta.FindStatusCondition(updated.Status.Conditions, meta.ReadyCondition)
+	if cond == nil {
+		return
+	}
+	if prev := apimeta.FindStatusCondition(konfig.Status.Conditions, meta.ReadyCondition); prev != nil &&
+		prev.Status == cond.Status && prev.Reason == cond.Reason && prev.Message == cond.Message {
+		return
+	}
+
+	// Waiting, e.g. on dependencies, is reported with an unknown status and
+	// is not an error.
+	eventType, severity := corev1.EventTypeNormal, "info"
+	if cond.Status == metav1.ConditionFalse {
+		eventType, severity = corev1.EventTypeWarning, "error"
+	}
+	// The commit of the revision is linked if it was reconciled, rather than
+	// the reconciliation failing before reaching it.
+	var commitURL string
+	if s := updated.Status.LastRunSummary; s != nil && s.Revision == revision {
+		commitURL = s.CommitURL
+	}
+	if r.recorder != nil {
+		annotations := make(map[string]string)
+		if revision != "" {
+			annotations[appsv1.EventRevisionAnnotation] = revision
+		}
+		if commitURL != "" {
+			annotations[appsv1.EventCommitURLAnnotation] = commitURL
+		}
+		r.recorder.AnnotatedEventf(konfig, annotations, eventType, cond.Reason, "%s", cond.Message)
+	}
+
+	evt := &sinkEvent{
+		InvolvedObject: corev1.ObjectReference{
+			Kind:            appsv1.KonfigurationKind,
+			APIVersion:      appsv1.GroupVersion.String(),
+			Namespace:       konfig.GetNamespace(),
+			Name:            konfig.GetName(),
+			UID:             konfig.GetUID(),
+			ResourceVersion: konfig.GetResourceVersion(),
+		},
+		Severity:            severity,
+		Timestamp:           metav1.Now(),
+		Reason:              cond.Reason,
+		Message:             cond.Message,
+		Revision:            revision,
+		CommitURL:           commitURL,
+		ReportingController: controllerName,
+	}
+
+	sinks := make([]*eventSink, 0, 2)
+	if r.eventSink != nil {
+		sinks = append(sinks, r.eventSink)
+	}
+	if spec := konfig.GetEventSink(); spec != nil && !eventSinkAllowed(spec.Address, r.eventSinkHosts) {
+		log.Info("Not posting event to event sink, its host is not allowed", "Address", spec.Address)
+	} else if spec != nil {
+		token, err := spec.FetchToken(ctx, r.Client, konfig.GetNamespace())
+		if err != nil {
+			log.Error(err, "Failed to fetch event sink token")
+		} else {
+			sinks = append(sinks, &eventSink{address: spec.Address, token: token})
+		}
+	}
+	for _, sink := range sinks {
+		if !r.events.enqueue(sink, evt) {
+			log.Info("Dropped event, too many events are waiting to be posted", "Address", sink.address)
+		}
+	}
+}
+
+// post posts the given event to the sink, signing it if the sink has a token.
+func (q *eventQueue) post(ctx context.Context, sink *eventSink, evt *sinkEvent) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	req, err := retryablehttp.NewRequest(http.MethodPost, sink.address, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(sink.token) != 0 {
+		mac := hmac.New(sha256.New, sink.token)
+		mac.Write(body)
+		req.Header.Set(signatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink returned %s", resp.Status)
+	}
+	return nil
+}
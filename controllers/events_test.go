@@ -0,0 +1,61 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestEventSinkAllowed(t *testing.T) {
+	tests := []struct {
+		name         string
+		address      string
+		allowedHosts []string
+		want         bool
+	}{
+		{name: "no allowed hosts", address: "https://events.example.com/hook"},
+		{name: "allowed host", address: "https://events.example.com/hook", allowedHosts: []string{"events.example.com"}, want: true},
+		{name: "allowed host with any port", address: "http://events.example.com:8080/hook", allowedHosts: []string{"events.example.com"}, want: true},
+		{name: "allowed host and port", address: "http://events.example.com:8080/hook", allowedHosts: []string{"events.example.com:8080"}, want: true},
+		{name: "other port", address: "http://events.example.com:9090/hook", allowedHosts: []string{"events.example.com:8080"}},
+		{name: "other host", address: "http://169.254.169.254/latest", allowedHosts: []string{"events.example.com"}},
+		{name: "other scheme", address: "file://events.example.com/hook", allowedHosts: []string{"events.example.com"}},
+		{name: "invalid address", address: "://events.example.com", allowedHosts: []string{"events.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventSinkAllowed(tt.address, tt.allowedHosts); got != tt.want {
+				t.Errorf("eventSinkAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventQueueFull(t *testing.T) {
+	q := newEventQueue(logr.Discard())
+	sink := &eventSink{address: "http://events.example.com"}
+	for i := 0; i < eventQueueSize; i++ {
+		if !q.enqueue(sink, &sinkEvent{}) {
+			t.Fatalf("event %d dropped before the queue is full", i)
+		}
+	}
+	if q.enqueue(sink, &sinkEvent{}) {
+		t.Errorf("event queued beyond the size of the queue")
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fluxcd/pkg/runtime/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// expeditedReconciler reconciles Konfigurations from a separate queue with its
+// own workers, so requested reconciliations and new source revisions do not
+// wait behind the interval-based reconciliations of a busy controller. Its
+// results are not requeued, since the regular queue keeps the interval of
+// every Konfiguration.
+type expeditedReconciler struct {
+	*KonfigurationReconciler
+}
+
+// Reconcile reconciles the Konfiguration, dropping any requeue on success.
+// Reconciliations deferred by the budget of the namespace are requeued.
+func (r expeditedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	result, err := r.KonfigurationReconciler.Reconcile(ctx, req)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if result.Requeue {
+		return result, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileRequestedPredicate passes only updates that request a
+// reconciliation through the reconcile annotation.
+var reconcileRequestedPredicate = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  predicates.ReconcileRequestedPredicate{}.Update,
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// keyLocks serializes reconciliations of the same Konfiguration across the
+// regular and expedited queues.
+type keyLocks struct {
+	locks map[string]*keyLock
+	mu    sync.Mutex
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newKeyLocks() *keyLocks {
+	return &keyLocks{locks: make(map[string]*keyLock)}
+}
+
+// Lock locks the given key and returns the function that unlocks it.
+func (l *keyLocks) Lock(key string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &keyLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// kubecfgFieldManager is the field manager of the updates made by kubecfg,
+// derived by the API server from its user agent.
+const kubecfgFieldManager = "kubecfg"
+
+// checkFieldManagers compares the objects in the manifests at path with their
+// live state, and returns an error listing the fields the manifests would
+// change that are owned by a field manager other than kubecfg and those the
+// Konfiguration allows to be overridden. Fields owned by the latter are taken
+// over by the update, since the API server moves the ownership of the fields
+// changed by an update to its manager.
+func (r *KonfigurationReconciler) checkFieldManagers(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string) error {
+	override := map[string]bool{kubecfgFieldManager: true}
+	for _, manager := range konfig.GetOverrideManagers() {
+		override[manager] = true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	objs, err := decodeManifests(f)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if apimeta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		key := client.ObjectKey{Name: obj.GetName()}
+		if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+			key.Namespace = namespaceOrDefault(obj, konfig)
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(gvk)
+		if err := r.Get(ctx, key, live); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return err
+		}
+
+		for _, entry := range live.GetManagedFields() {
+			if entry.FieldsV1 == nil || entry.Manager == kubecfgFieldManager {
+				continue
+			}
+			changed, err := changedFields(entry.FieldsV1.Raw, obj.Object, live.Object)
+			if err != nil {
+				return fmt.Errorf("failed to read the fields of %s '%s' managed by '%s': %w", gvk.Kind, key, entry.Manager, err)
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			if override[entry.Manager] {
+				log.Info("Taking over fields", "Manager", entry.Manager, "Kind", gvk.Kind, "Object", key.String(), "Fields", changed)
+				continue
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s '%s' fields %s managed by '%s'", gvk.Kind, key, strings.Join(changed, ", "), entry.Manager))
+		}
+	}
+	if len(conflicts) != 0 {
+		return fmt.Errorf("conflicts with field managers not in overrideManagers: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// changedFields returns the paths of the fields in the given managed fields
+// set whose value in rendered differs from their value in live. Only scalar
+// fields are compared, since the members of maps and lists are in the set as
+// well.
+func changedFields(raw []byte, rendered, live map[string]interface{}) ([]string, error) {
+	set := &fieldpath.Set{}
+	if err := set.FromJSON(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	var changed []string
+	set.Iterate(func(path fieldpath.Path) {
+		want, ok := fieldAt(rendered, path)
+		if !ok || !isScalar(want) {
+			return
+		}
+		if got, ok := fieldAt(live, path); ok && value.Equals(value.NewValueInterface(want), value.NewValueInterface(got)) {
+			return
+		}
+		changed = append(changed, path.String())
+	})
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// fieldAt returns the value at the given path of obj, if it is set.
+func fieldAt(obj interface{}, path fieldpath.Path) (interface{}, bool) {
+	cur := obj
+	for _, pe := range path {
+		switch {
+		case pe.FieldName != nil:
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if cur, ok = m[*pe.FieldName]; !ok {
+				return nil, false
+			}
+		case pe.Index != nil:
+			l, ok := cur.([]interface{})
+			if !ok || *pe.Index >= len(l) {
+				return nil, false
+			}
+			cur = l[*pe.Index]
+		case pe.Key != nil || pe.Value != nil:
+			l, ok := cur.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			var found bool
+			for _, item := range l {
+				if listItemMatches(item, pe) {
+					cur, found = item, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// listItemMatches returns true if item is the member of an associative list
+// selected by pe.
+func listItemMatches(item interface{}, pe fieldpath.PathElement) bool {
+	if pe.Value != nil {
+		return value.Equals(value.NewValueInterface(item), *pe.Value)
+	}
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, field := range *pe.Key {
+		v, ok := m[field.Name]
+		if !ok || !value.Equals(value.NewValueInterface(v), field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// isScalar returns true if v is neither a map nor a list.
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
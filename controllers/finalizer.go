@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// reconcileFinalizer adds the finalizer to Konfigurations whose managed
+// objects are removed on deletion, and removes it from those whose objects
+// are orphaned.
+func (r *KonfigurationReconciler) reconcileFinalizer(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	want := konfig.GetDeletionPolicy() != appsv1.DeletionPolicyOrphan
+	if want == controllerutil.ContainsFinalizer(konfig, appsv1.KonfigurationFinalizer) {
+		return nil
+	}
+	patch := client.MergeFrom(konfig.DeepCopy())
+	if want {
+		controllerutil.AddFinalizer(konfig, appsv1.KonfigurationFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(konfig, appsv1.KonfigurationFinalizer)
+	}
+	return r.Patch(ctx, konfig, patch)
+}
+
+// finalize removes the managed objects of a deleted Konfiguration as
+// configured by its deletion policy, then releases its finalizer. With the
+// WaitForDependents policy the finalizer is kept, and the remaining objects
+// reported in the status, until every object of the inventory is gone.
+func (r *KonfigurationReconciler) finalize(ctx context.Context, log logr.Logger, req ctrl.Request, konfig *appsv1.Konfiguration) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(konfig, appsv1.KonfigurationFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	switch policy := konfig.GetDeletionPolicy(); {
+	case policy == appsv1.DeletionPolicyOrphan:
+	case r.observeOnly(konfig):
+		log.Info("Konfiguration is observe-only, leaving managed objects in place", "DeletionPolicy", policy)
+	case !konfig.GCEnabled():
+		log.Info("Prune is not enabled, leaving managed objects in place", "DeletionPolicy", policy)
+		r.recorder.Event(konfig, corev1.EventTypeWarning, appsv1.PruneFailedReason,
+			"managed objects were left in place, removing them on deletion requires prune to be enabled")
+	default:
+		log.Info("Konfiguration is deleted, removing managed objects", "DeletionPolicy", policy)
+		r.pipelines.Evict(req.NamespacedName.String())
+		err := r.pruneAll(ctx, log, konfig)
+		if policy == appsv1.DeletionPolicyWaitForDependents {
+			remaining, listErr := r.remainingObjects(ctx, konfig)
+			if listErr != nil {
+				return ctrl.Result{}, listErr
+			}
+			if err != nil || len(remaining) != 0 {
+				if err == nil {
+					err = fmt.Errorf("waiting for the deletion of %s", strings.Join(remaining, ", "))
+				}
+				log.Error(err, "Managed objects remain")
+				notReady := appsv1.KonfigurationNotReady(*konfig, "", appsv1.DeletionBlockedReason, err.Error())
+				notReady.Status.RemainingObjects = remaining
+				r.notify(ctx, log, konfig, notReady, "")
+				if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+					log.Error(err, "Unable to update status")
+				}
+				return ctrl.Result{
+					RequeueAfter: retryAfter(konfig, err),
+				}, nil
+			}
+		} else if err != nil {
+			log.Error(err, "Failed to remove managed objects, leaving them in place")
+			r.recorder.Event(konfig, corev1.EventTypeWarning, appsv1.PruneFailedReason, err.Error())
+		}
+	}
+
+	patch := client.MergeFrom(konfig.DeepCopy())
+	controllerutil.RemoveFinalizer(konfig, appsv1.KonfigurationFinalizer)
+	return ctrl.Result{}, r.Patch(ctx, konfig, patch)
+}
+
+// remainingObjects returns the objects of the inventory of the Konfiguration
+// that still exist and are subject to its garbage collection.
+func (r *KonfigurationReconciler) remainingObjects(ctx context.Context, konfig *appsv1.Konfiguration) ([]string, error) {
+	inventory, err := r.readInventory(ctx, konfig)
+	if err != nil || inventory == nil {
+		return nil, err
+	}
+	var remaining []string
+	for _, entry := range inventory.Entries {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(entry.APIVersion)
+		obj.SetKind(entry.Kind)
+		obj.SetNamespace(entry.Namespace)
+		obj.SetName(entry.Name)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		annotations := obj.GetAnnotations()
+		if annotations[gcTagAnnotation] != konfig.GetGCTag() || gcProtected(konfig, obj) {
+			continue
+		}
+		remaining = append(remaining, fmt.Sprintf("%s '%s'", obj.GetKind(), client.ObjectKeyFromObject(obj)))
+	}
+	return remaining, nil
+}
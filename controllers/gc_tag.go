@@ -0,0 +1,64 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// checkGCTag returns an InvalidSpec error if the garbage collection tag of the
+// Konfiguration is already used by another Konfiguration, so objects tagged by
+// one are never pruned by the other.
+func (r *KonfigurationReconciler) checkGCTag(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	if !konfig.GCEnabled() {
+		return nil
+	}
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, client.MatchingFields{appsv1.GCTagIndexKey: konfig.GetGCTag()}); err != nil {
+		return err
+	}
+	if owner := gcTagClaimedBy(konfig, list.Items); owner != nil {
+		return withReason(appsv1.InvalidSpecReason,
+			fmt.Errorf("gcTag: '%s' is already used by Konfiguration '%s'", konfig.GetGCTag(), client.ObjectKeyFromObject(owner)))
+	}
+	return nil
+}
+
+// gcTagClaimedBy returns the Konfiguration among the given ones that claimed
+// the garbage collection tag of the Konfiguration before it, or nil if it is
+// the first to use it. Konfigurations created at the same time are ordered by
+// namespace and name.
+func gcTagClaimedBy(konfig *appsv1.Konfiguration, konfigs []appsv1.Konfiguration) *appsv1.Konfiguration {
+	key := client.ObjectKeyFromObject(konfig).String()
+	for i := range konfigs {
+		other := &konfigs[i]
+		otherKey := client.ObjectKeyFromObject(other).String()
+		if otherKey == key || other.GetGCTag() != konfig.GetGCTag() {
+			continue
+		}
+		created, otherCreated := konfig.GetCreationTimestamp(), other.GetCreationTimestamp()
+		if otherCreated.Before(&created) || (otherCreated.Equal(&created) && otherKey < key) {
+			return other
+		}
+	}
+	return nil
+}
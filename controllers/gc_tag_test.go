@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestGCTagClaimedBy(t *testing.T) {
+	now := time.Now()
+	konfiguration := func(namespace, name, gcTag string, created time.Time) appsv1.Konfiguration {
+		k := appsv1.Konfiguration{}
+		k.SetNamespace(namespace)
+		k.SetName(name)
+		k.SetCreationTimestamp(metav1.NewTime(created))
+		k.Spec.GCTag = gcTag
+		return k
+	}
+	tests := []struct {
+		name   string
+		konfig appsv1.Konfiguration
+		others []appsv1.Konfiguration
+		want   string
+	}{
+		{
+			name:   "unique tag",
+			konfig: konfiguration("team", "app", "", now),
+			others: []appsv1.Konfiguration{konfiguration("other", "app", "", now.Add(-time.Hour))},
+		},
+		{
+			name:   "itself",
+			konfig: konfiguration("team", "app", "", now),
+			others: []appsv1.Konfiguration{konfiguration("team", "app", "", now)},
+		},
+		{
+			name:   "default tag of an earlier Konfiguration",
+			konfig: konfiguration("other", "app", "team_app", now),
+			others: []appsv1.Konfiguration{konfiguration("team", "app", "", now.Add(-time.Hour))},
+			want:   "team/app",
+		},
+		{
+			name:   "tag of a later Konfiguration",
+			konfig: konfiguration("team", "app", "legacy", now),
+			others: []appsv1.Konfiguration{konfiguration("other", "app", "legacy", now.Add(time.Hour))},
+		},
+		{
+			name:   "tag of a Konfiguration created at the same time",
+			konfig: konfiguration("team", "app", "legacy", now),
+			others: []appsv1.Konfiguration{konfiguration("other", "app", "legacy", now)},
+			want:   "other/app",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if owner := gcTagClaimedBy(&tt.konfig, tt.others); owner != nil {
+				got = owner.GetNamespace() + "/" + owner.GetName()
+			}
+			if got != tt.want {
+				t.Errorf("gcTagClaimedBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// healthCheckFunc checks the object of the given inventory entry, returning
+// why it is not healthy yet, or an empty string if it is.
+type healthCheckFunc func(ctx context.Context, reader client.Reader, entry appsv1.InventoryEntry) (string, error)
+
+// healthCheckFor returns the health check of the given checks that applies to
+// the object of the given inventory entry, or nil if none does.
+func healthCheckFor(checks []appsv1.HealthCheck, entry appsv1.InventoryEntry) healthCheckFunc {
+	gv, err := schema.ParseGroupVersion(entry.APIVersion)
+	if err != nil {
+		return nil
+	}
+	for _, check := range checks {
+		switch {
+		case check == appsv1.HealthCheckServiceEndpoints && gv.Group == "" && entry.Kind == "Service":
+			return checkServiceEndpoints
+		case check == appsv1.HealthCheckIngressAddress && (gv.Group == "networking.k8s.io" || gv.Group == "extensions") && entry.Kind == "Ingress":
+			return checkIngressAddress
+		}
+	}
+	return nil
+}
+
+// checkHealth runs the health checks of the Konfiguration against the objects
+// of the given inventory entries, retrying until all of them pass or the
+// timeout of the Konfiguration expires.
+func checkHealth(ctx context.Context, log logr.Logger, reader client.Reader, konfig *appsv1.Konfiguration, entries []appsv1.InventoryEntry) error {
+	checks := konfig.GetHealthChecks()
+	pending := make(map[appsv1.InventoryEntry]string)
+	for _, entry := range entries {
+		if healthCheckFor(checks, entry) != nil {
+			pending[entry] = "has not been checked"
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	checked := len(pending)
+
+	err := wait.PollImmediate(verifyPollInterval, konfig.GetTimeout(), func() (bool, error) {
+		for entry := range pending {
+			reason, err := healthCheckFor(checks, entry)(ctx, reader, entry)
+			if err != nil {
+				return false, err
+			}
+			if reason == "" {
+				delete(pending, entry)
+				continue
+			}
+			pending[entry] = reason
+		}
+		return len(pending) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		unhealthy := make([]string, 0, len(pending))
+		for entry, reason := range pending {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s '%s' %s", entry.Kind, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, reason))
+		}
+		sort.Strings(unhealthy)
+		return fmt.Errorf("applied objects not serving after %s: %s", konfig.GetTimeout(), strings.Join(unhealthy, ", "))
+	} else if err != nil {
+		return err
+	}
+	log.Info("Health checks passed", "Count", checked)
+	return nil
+}
+
+// checkServiceEndpoints checks that a Service with a selector has ready
+// endpoints, and that a LoadBalancer Service was assigned an address.
+func checkServiceEndpoints(ctx context.Context, reader client.Reader, entry appsv1.InventoryEntry) (string, error) {
+	svc, err := getEntry(ctx, reader, entry)
+	if err != nil || svc == nil {
+		return "does not exist", err
+	}
+	svcType, _, _ := unstructured.NestedString(svc.Object, "spec", "type")
+	if svcType == "ExternalName" {
+		return "", nil
+	}
+	if svcType == "LoadBalancer" {
+		if ingress, _, _ := unstructured.NestedSlice(svc.Object, "status", "loadBalancer", "ingress"); len(ingress) == 0 {
+			return "has no load balancer address", nil
+		}
+	}
+	if selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector"); len(selector) == 0 {
+		return "", nil
+	}
+
+	endpoints, err := getEntry(ctx, reader, appsv1.InventoryEntry{APIVersion: "v1", Kind: "Endpoints", Namespace: entry.Namespace, Name: entry.Name})
+	if err != nil || endpoints == nil {
+		return "has no ready endpoints", err
+	}
+	subsets, _, _ := unstructured.NestedSlice(endpoints.Object, "subsets")
+	for _, subset := range subsets {
+		if subset, ok := subset.(map[string]interface{}); ok {
+			if addresses, _, _ := unstructured.NestedSlice(subset, "addresses"); len(addresses) != 0 {
+				return "", nil
+			}
+		}
+	}
+	return "has no ready endpoints", nil
+}
+
+// checkIngressAddress checks that an Ingress was assigned an address.
+func checkIngressAddress(ctx context.Context, reader client.Reader, entry appsv1.InventoryEntry) (string, error) {
+	ing, err := getEntry(ctx, reader, entry)
+	if err != nil || ing == nil {
+		return "does not exist", err
+	}
+	if ingress, _, _ := unstructured.NestedSlice(ing.Object, "status", "loadBalancer", "ingress"); len(ingress) == 0 {
+		return "has no address", nil
+	}
+	return "", nil
+}
+
+// getEntry reads the object of the given inventory entry, returning nil if it
+// does not exist.
+func getEntry(ctx context.Context, reader client.Reader, entry appsv1.InventoryEntry) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(entry.APIVersion)
+	obj.SetKind(entry.Kind)
+	err := reader.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, obj)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return obj, err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// hibernationLookback is how far back the start and end of hibernation windows
+// are searched for. It covers schedules that fire at least weekly.
+const hibernationLookback = 8 * 24 * time.Hour
+
+// updateHibernation records on the HibernatingCondition of the Konfiguration
+// whether any of its hibernation windows is active at now, and returns the time
+// of the next window boundary. The condition is removed if hibernation is not
+// configured.
+func updateHibernation(konfig *appsv1.Konfiguration, now time.Time) (next time.Time, err error) {
+	if konfig.Spec.Hibernation == nil {
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.HibernatingCondition)
+		return time.Time{}, nil
+	}
+
+	var active bool
+	for i, window := range konfig.Spec.Hibernation.Schedules {
+		start, err := cron.ParseStandard(window.Start)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid start of hibernation schedule %d: %w", i, err)
+		}
+		end, err := cron.ParseStandard(window.End)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid end of hibernation schedule %d: %w", i, err)
+		}
+
+		// The window is active if it last started more recently than it
+		// last ended.
+		lastStart, lastEnd := lastActivation(start, now), lastActivation(end, now)
+		if !lastStart.IsZero() && lastStart.After(lastEnd) {
+			active = true
+		}
+
+		for _, t := range []time.Time{start.Next(now), end.Next(now)} {
+			if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+
+	if active {
+		meta.SetResourceCondition(konfig, appsv1.HibernatingCondition, metav1.ConditionTrue, appsv1.HibernationActiveReason,
+			"workloads are scaled to zero by a hibernation window")
+	} else {
+		meta.SetResourceCondition(konfig, appsv1.HibernatingCondition, metav1.ConditionFalse, appsv1.HibernationInactiveReason,
+			"no hibernation window is active")
+	}
+	return next, nil
+}
+
+// lastActivation returns the last time the schedule fired at or before now,
+// within the hibernationLookback. It returns the zero time if it did not fire.
+func lastActivation(schedule cron.Schedule, now time.Time) time.Time {
+	var last time.Time
+	for t := schedule.Next(now.Add(-hibernationLookback)); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		last = t
+	}
+	return last
+}
+
+// hibernateWorkloads scales the rendered Deployments and StatefulSets to zero.
+func hibernateWorkloads(log logr.Logger, objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		if obj.GroupVersionKind().Group != "apps" || !scalableKinds[obj.GetKind()] {
+			continue
+		}
+		log.Info("Scaling workload to zero for hibernation", "Kind", obj.GetKind(), "Name", obj.GetName())
+		if err := unstructured.SetNestedField(obj.Object, int64(0), "spec", "replicas"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// runHooks runs the hooks of the Konfiguration at the given stage in order,
+// waiting for each to finish before the next is started. Each Job is named
+// after a hash of its hook, the revision and the checksum of the rendered
+// manifests, so a hook that already succeeded is not run again until either
+// changes. Failed Jobs are removed after their logs are recorded, unless the
+// hook only warns. Hook Jobs of the stage from previous runs are removed.
+func (r *KonfigurationReconciler) runHooks(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, stage, revision, checksum string) error {
+	var hooks []appsv1.Hook
+	for _, hook := range konfig.Spec.Hooks {
+		if hook.Stage == stage {
+			hooks = append(hooks, hook)
+		}
+	}
+	jobs := make([]*batchv1.Job, 0, len(hooks))
+	keep := make(map[client.ObjectKey]struct{}, len(hooks))
+	for _, hook := range hooks {
+		job, err := r.newHookJob(konfig, hook, revision, checksum)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+		keep[client.ObjectKeyFromObject(job)] = struct{}{}
+	}
+	if err := r.cleanupHookJobs(ctx, client.ObjectKeyFromObject(konfig), stage, keep); err != nil {
+		return err
+	}
+
+	for i, hook := range hooks {
+		if err := r.runHook(ctx, log, konfig, hook, jobs[i]); err != nil {
+			if hook.FailurePolicy != appsv1.HookFailurePolicyWarn {
+				return fmt.Errorf("%s hook '%s' failed: %w", stage, hook.Name, err)
+			}
+			log.Info("Ignoring failed hook", "Hook", hook.Name, "Stage", stage, "Error", err.Error())
+		}
+	}
+	return nil
+}
+
+// runHook runs the Job of the given hook and waits for it to finish, unless it
+// already succeeded.
+func (r *KonfigurationReconciler) runHook(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, hook appsv1.Hook, job *batchv1.Job) error {
+	key := client.ObjectKeyFromObject(job)
+	var existing batchv1.Job
+	err := r.Get(ctx, key, &existing)
+	switch {
+	case err == nil:
+		// A failed Job is kept for hooks that only warn, so they are not run
+		// again on every reconciliation.
+		if jobSucceeded(&existing) || (jobFinished(&existing) && hook.FailurePolicy == appsv1.HookFailurePolicyWarn) {
+			return nil
+		}
+	case apierrors.IsNotFound(err):
+		log.Info("Running hook Job", "Hook", hook.Name, "Job", key)
+		if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create Job '%s': %w", key, err)
+		}
+	default:
+		return err
+	}
+
+	timeout := konfig.GetTimeout()
+	if hook.Timeout != nil {
+		timeout = hook.Timeout.Duration
+	}
+	var result batchv1.Job
+	err = wait.PollImmediate(testPollInterval, timeout, func() (bool, error) {
+		if err := r.Get(ctx, key, &result); err != nil {
+			// The cache may not have caught up with a new Job yet
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return jobFinished(&result), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		err = fmt.Errorf("hook Job '%s' did not finish after %s", key, timeout)
+	}
+	if err == nil && jobSucceeded(&result) {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("hook Job '%s' did not succeed", key)
+	}
+
+	if r.recorder != nil {
+		r.recorder.Eventf(konfig, corev1.EventTypeWarning, appsv1.HookFailedReason, "%s hook '%s' failed: %s%s", hook.Stage, hook.Name, err, r.jobLogs(ctx, log, job))
+	}
+	if hook.FailurePolicy == appsv1.HookFailurePolicyWarn && jobFinished(&result) {
+		return err
+	}
+	if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to remove failed hook Job", "Job", key)
+	}
+	return err
+}
+
+// newHookJob returns the Job to create for the given hook of the Konfiguration.
+func (r *KonfigurationReconciler) newHookJob(konfig *appsv1.Konfiguration, hook appsv1.Hook, revision, checksum string) (*batchv1.Job, error) {
+	data, err := json.Marshal(hook)
+	if err != nil {
+		return nil, err
+	}
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s\x00%s\x00%s", data, revision, checksum))))[:10]
+
+	labels := r.ownership.labels(client.ObjectKeyFromObject(konfig))
+	labels[appsv1.HookLabel] = hook.Stage
+
+	var backoffLimit int32
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", hook.Name, hash),
+			Namespace: konfig.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hook.ServiceAccountName,
+					Containers: []corev1.Container{{
+						Name:    "hook",
+						Image:   hook.Image,
+						Command: hook.Command,
+						Args:    hook.Args,
+						Env: []corev1.EnvVar{
+							{Name: "KUBECFG_KONFIGURATION", Value: client.ObjectKeyFromObject(konfig).String()},
+							{Name: "KUBECFG_STAGE", Value: hook.Stage},
+							{Name: "KUBECFG_REVISION", Value: revision},
+							{Name: "KUBECFG_CHECKSUM", Value: checksum},
+						},
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+// cleanupHookJobs removes the hook Jobs created on behalf of the given
+// Konfiguration at the given stage, or at every stage if it is empty, except
+// those in keep.
+func (r *KonfigurationReconciler) cleanupHookJobs(ctx context.Context, key client.ObjectKey, stage string, keep map[client.ObjectKey]struct{}) error {
+	for _, selector := range r.ownership.selectors(key) {
+		opts := []client.ListOption{client.InNamespace(key.Namespace), selector}
+		if stage != "" {
+			selector[appsv1.HookLabel] = stage
+		} else {
+			opts = append(opts, client.HasLabels{appsv1.HookLabel})
+		}
+		var list batchv1.JobList
+		if err := r.List(ctx, &list, opts...); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			if _, ok := keep[client.ObjectKeyFromObject(&list.Items[i])]; ok {
+				continue
+			}
+			if err := r.Delete(ctx, &list.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
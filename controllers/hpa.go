@@ -0,0 +1,93 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// scalableKinds are the kinds whose replica counts are left to a targeting
+// HorizontalPodAutoscaler.
+var scalableKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+}
+
+// releaseHPAReplicas checks the rendered objects for Deployments and
+// StatefulSets targeted by a HorizontalPodAutoscaler. The replicas field of
+// those objects is set to the live value, or dropped if the object does not
+// exist yet. A live value of zero is dropped as well, since autoscalers do not
+// scale to zero and it is left behind by hibernation.
+func (r *KonfigurationReconciler) releaseHPAReplicas(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) error {
+	var hpas autoscalingv1.HorizontalPodAutoscalerList
+	if err := r.List(ctx, &hpas); err != nil {
+		return fmt.Errorf("failed to list HorizontalPodAutoscalers: %w", err)
+	}
+	if len(hpas.Items) == 0 {
+		return nil
+	}
+	targeted := make(map[string]bool, len(hpas.Items))
+	for _, hpa := range hpas.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		targeted[hpaTargetKey(gv.Group, ref.Kind, hpa.GetNamespace(), ref.Name)] = true
+	}
+
+	for _, obj := range objs {
+		if !scalableKinds[obj.GetKind()] {
+			continue
+		}
+		namespace := namespaceOrDefault(obj, konfig)
+		gvk := obj.GroupVersionKind()
+		if !targeted[hpaTargetKey(gvk.Group, gvk.Kind, namespace, obj.GetName())] {
+			continue
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(gvk)
+		err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: obj.GetName()}, live)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		replicas, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas")
+		if err == nil && found && replicas > 0 {
+			if err := unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas"); err != nil {
+				return err
+			}
+		} else {
+			unstructured.RemoveNestedField(obj.Object, "spec", "replicas")
+		}
+		log.Info("Leaving replicas to HorizontalPodAutoscaler", "Kind", gvk.Kind, "Namespace", namespace, "Name", obj.GetName())
+	}
+	return nil
+}
+
+func hpaTargetKey(group, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+}
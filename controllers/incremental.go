@@ -0,0 +1,84 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// changedObjects returns whether each object of the manifests changed since
+// the previous inventory, comparing their digests. It returns false if there
+// is no previous inventory or either lacks digests to compare.
+func changedObjects(previous *appsv1.Inventory, manifests *renderedManifests) ([]bool, bool) {
+	if previous == nil || len(previous.Digests) != len(previous.Entries) || len(manifests.digests) != len(manifests.inventory) {
+		return nil, false
+	}
+	applied := make(map[appsv1.InventoryEntry]string, len(previous.Entries))
+	for i, entry := range previous.Entries {
+		applied[entry] = previous.Digests[i]
+	}
+	changed := make([]bool, len(manifests.inventory))
+	for i, entry := range manifests.inventory {
+		changed[i] = applied[entry] != manifests.digests[i]
+	}
+	return changed, true
+}
+
+// changedManifests writes the objects of the manifests that changed since the
+// previous inventory to a new file, returning its path and the number of
+// objects in it. The path of the manifests is returned as is if the changes
+// cannot be told, and an empty path if no object changed. The caller is
+// responsible for removing the new file.
+func (r *KonfigurationReconciler) changedManifests(konfig *appsv1.Konfiguration, manifests *renderedManifests, previous *appsv1.Inventory) (string, int, error) {
+	changed, ok := changedObjects(previous, manifests)
+	if !ok {
+		return manifests.path, len(manifests.inventory), nil
+	}
+
+	f, err := os.Open(manifests.path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	objs, err := decodeManifests(f)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(objs) != len(changed) {
+		return "", 0, fmt.Errorf("manifests hold %d objects, their inventory %d", len(objs), len(changed))
+	}
+
+	var selected []*unstructured.Unstructured
+	for i, obj := range objs {
+		if changed[i] {
+			selected = append(selected, obj)
+		}
+	}
+	if len(selected) == 0 {
+		return "", 0, nil
+	}
+	written, err := r.writeManifests(konfig, selected)
+	if err != nil {
+		return "", 0, err
+	}
+	return written.path, len(selected), nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestChangedObjects(t *testing.T) {
+	a := appsv1.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "a"}
+	b := appsv1.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "b"}
+	manifests := &renderedManifests{inventory: []appsv1.InventoryEntry{a, b}, digests: []string{"1", "2"}}
+	tests := []struct {
+		name     string
+		previous *appsv1.Inventory
+		want     []bool
+		wantOK   bool
+	}{
+		{name: "no previous inventory"},
+		{
+			name:     "previous inventory without digests",
+			previous: &appsv1.Inventory{Entries: []appsv1.InventoryEntry{a, b}},
+		},
+		{
+			name:     "unchanged",
+			previous: &appsv1.Inventory{Entries: []appsv1.InventoryEntry{a, b}, Digests: []string{"1", "2"}},
+			want:     []bool{false, false},
+			wantOK:   true,
+		},
+		{
+			name:     "changed and new objects",
+			previous: &appsv1.Inventory{Entries: []appsv1.InventoryEntry{a}, Digests: []string{"0"}},
+			want:     []bool{true, true},
+			wantOK:   true,
+		},
+		{
+			name:     "reordered objects",
+			previous: &appsv1.Inventory{Entries: []appsv1.InventoryEntry{b, a}, Digests: []string{"2", "1"}},
+			want:     []bool{false, false},
+			wantOK:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := changedObjects(tt.previous, manifests)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedObjects() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
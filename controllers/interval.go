@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// updateEffectiveInterval adapts the interval of the Konfiguration to the
+// activity of its source once the given revision is reconciled. The interval
+// is doubled if the revision was already applied, up to the maximum of the
+// adaptive interval, and reset to the spec interval otherwise. The spec
+// interval is not recorded, so the status only changes while the interval
+// grows or when it is reset. It must be called before the revision is
+// recorded as applied.
+func updateEffectiveInterval(konfig *appsv1.Konfiguration, revision string) {
+	adaptive := konfig.Spec.AdaptiveInterval
+	if adaptive == nil {
+		konfig.Status.EffectiveInterval = nil
+		return
+	}
+	interval := konfig.GetInterval()
+	if revision == konfig.Status.LastAppliedRevision {
+		interval = 2 * konfig.GetEffectiveInterval()
+	}
+	if interval > adaptive.Max.Duration {
+		interval = adaptive.Max.Duration
+	}
+	if interval <= konfig.GetInterval() {
+		konfig.Status.EffectiveInterval = nil
+		return
+	}
+	konfig.Status.EffectiveInterval = &metav1.Duration{Duration: interval}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestUpdateEffectiveInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		revision string
+		current  time.Duration
+		want     time.Duration
+	}{
+		{name: "new revision", revision: "main/def", current: 4 * time.Minute},
+		{name: "applied revision at the interval", revision: "main/abc", want: 2 * time.Minute},
+		{name: "applied revision", revision: "main/abc", current: 2 * time.Minute, want: 4 * time.Minute},
+		{name: "applied revision at the maximum", revision: "main/abc", current: 5 * time.Minute, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			konfig := testKonfiguration()
+			konfig.Spec.Interval = metav1.Duration{Duration: time.Minute}
+			konfig.Spec.AdaptiveInterval = &appsv1.AdaptiveInterval{Max: metav1.Duration{Duration: 5 * time.Minute}}
+			konfig.Status.LastAppliedRevision = "main/abc"
+			if tt.current != 0 {
+				konfig.Status.EffectiveInterval = &metav1.Duration{Duration: tt.current}
+			}
+			updateEffectiveInterval(konfig, tt.revision)
+			var got time.Duration
+			if konfig.Status.EffectiveInterval != nil {
+				got = konfig.Status.EffectiveInterval.Duration
+			}
+			if got != tt.want {
+				t.Errorf("EffectiveInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// inventoryName returns the name of the ConfigMap holding the inventory of the
+// Konfiguration.
+func inventoryName(konfig *appsv1.Konfiguration) string {
+	return konfig.GetName() + "-inventory"
+}
+
+// inventoryEntries returns the inventory entries for the given objects.
+func (r *KonfigurationReconciler) inventoryEntries(konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) []appsv1.InventoryEntry {
+	entries := make([]appsv1.InventoryEntry, 0, len(objs))
+	for _, obj := range objs {
+		entry := appsv1.InventoryEntry{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+		}
+		if r.isNamespaced(obj) {
+			entry.Namespace = namespaceOrDefault(obj, konfig)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// inventoryPartSize is the maximum size of the inventory stored in a single
+// ConfigMap, leaving room for its metadata below the 1MiB limit. Larger
+// inventories are compressed and split across a chain of ConfigMaps.
+const inventoryPartSize = 900 * 1024
+
+// inventoryPartName returns the name of the ConfigMap holding the part with the
+// given index of the inventory stored under name.
+func inventoryPartName(name string, part int) string {
+	if part == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s-%d", name, part)
+}
+
+// writeInventory stores the inventory of the applied manifests in ConfigMaps
+// owned by the Konfiguration and records a reference to the first one in the
+// status. The health of the objects is read back to be recorded along with
+// them. The ConfigMaps are accessed directly rather than through the cache,
+// to avoid watching every ConfigMap in the cluster.
+func (r *KonfigurationReconciler) writeInventory(ctx context.Context, konfig *appsv1.Konfiguration, revision string, manifests *renderedManifests) error {
+	data, err := json.Marshal(&appsv1.Inventory{
+		Revision: revision,
+		Checksum: manifests.checksum,
+		Entries:  manifests.inventory,
+		Digests:  manifests.digests,
+		Health:   inventoryHealth(ctx, r.Client, manifests.inventory),
+	})
+	if err != nil {
+		return err
+	}
+
+	parts, err := r.inventoryParts(konfig, inventoryName(konfig), data)
+	if err != nil {
+		return err
+	}
+	for _, cm := range parts {
+		if err := controllerutil.SetControllerReference(konfig, cm, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.writeInventoryPart(ctx, konfig, cm, true); err != nil {
+			return fmt.Errorf("failed to write inventory '%s/%s': %w", cm.GetNamespace(), cm.GetName(), err)
+		}
+	}
+	if err := r.deleteInventoryParts(ctx, konfig, inventoryName(konfig), len(parts)); err != nil {
+		return err
+	}
+
+	konfig.Status.InventoryRef = &corev1.LocalObjectReference{Name: inventoryName(konfig)}
+	konfig.Status.InventoryParts = 0
+	if len(parts) > 1 {
+		konfig.Status.InventoryParts = int32(len(parts))
+	}
+	konfig.Status.InventoryCount = int32(len(manifests.inventory))
+	konfig.Status.ClusterScopedCount = int32(countClusterScoped(manifests.inventory))
+	return r.writeInventoryBackup(ctx, konfig, data)
+}
+
+// inventoryParts returns the ConfigMaps holding the given JSON encoded
+// inventory under name. An inventory that fits in a single ConfigMap is stored
+// as is under the InventoryKey, a larger one is gzip-compressed and split
+// across a chain of ConfigMaps linked by the InventoryNextAnnotation.
+func (r *KonfigurationReconciler) inventoryParts(konfig *appsv1.Konfiguration, name string, data []byte) ([]*corev1.ConfigMap, error) {
+	newPart := func(part int) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      inventoryPartName(name, part),
+				Namespace: konfig.GetNamespace(),
+				Labels:    r.inventoryLabels(konfig),
+			},
+		}
+	}
+	if len(data) <= inventoryPartSize {
+		cm := newPart(0)
+		cm.Data = map[string]string{appsv1.InventoryKey: string(data)}
+		return []*corev1.ConfigMap{cm}, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	compressed := buf.Bytes()
+	count := (len(compressed) + inventoryPartSize - 1) / inventoryPartSize
+	parts := make([]*corev1.ConfigMap, 0, count)
+	for part := 0; part < count; part++ {
+		end := (part + 1) * inventoryPartSize
+		if end > len(compressed) {
+			end = len(compressed)
+		}
+		cm := newPart(part)
+		cm.BinaryData = map[string][]byte{appsv1.InventoryCompressedKey: compressed[part*inventoryPartSize : end]}
+		if part < count-1 {
+			cm.Annotations = map[string]string{appsv1.InventoryNextAnnotation: inventoryPartName(name, part+1)}
+		}
+		parts = append(parts, cm)
+	}
+	return parts, nil
+}
+
+// writeInventoryPart creates or replaces a ConfigMap holding the inventory, or
+// a part of it. Existing ConfigMaps are only replaced if they hold an inventory
+// labeled for the Konfiguration. Unless owned is true, they keep their owner
+// references.
+func (r *KonfigurationReconciler) writeInventoryPart(ctx context.Context, konfig *appsv1.Konfiguration, cm *corev1.ConfigMap, owned bool) error {
+	configMaps := r.clientset.CoreV1().ConfigMaps(cm.GetNamespace())
+	existing, err := configMaps.Get(ctx, cm.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	case err == nil:
+		if !r.isInventoryOf(existing, konfig) {
+			return fmt.Errorf("ConfigMap '%s/%s' is not an inventory of this Konfiguration", existing.GetNamespace(), existing.GetName())
+		}
+		if owned {
+			existing.SetLabels(cm.GetLabels())
+			existing.SetOwnerReferences(cm.GetOwnerReferences())
+		} else {
+			r.ownership.set(existing.Labels, client.ObjectKeyFromObject(konfig))
+		}
+		existing.SetAnnotations(cm.GetAnnotations())
+		existing.Data = cm.Data
+		existing.BinaryData = cm.BinaryData
+		_, err = configMaps.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// deleteInventoryParts removes the ConfigMaps of the inventory stored under
+// name, from the part with the given index on. ConfigMaps that do not hold an
+// inventory labeled for the Konfiguration are left in place.
+func (r *KonfigurationReconciler) deleteInventoryParts(ctx context.Context, konfig *appsv1.Konfiguration, name string, from int) error {
+	configMaps := r.clientset.CoreV1().ConfigMaps(konfig.GetNamespace())
+	for part := from; ; part++ {
+		cm, err := configMaps.Get(ctx, inventoryPartName(name, part), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !r.isInventoryOf(cm, konfig) {
+			return nil
+		}
+		if err := configMaps.Delete(ctx, cm.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+}
+
+// inventoryLabels returns the labels of the ConfigMaps holding the inventory of
+// the Konfiguration.
+func (r *KonfigurationReconciler) inventoryLabels(konfig *appsv1.Konfiguration) map[string]string {
+	return r.ownership.labels(client.ObjectKeyFromObject(konfig))
+}
+
+// inventoryBackupName returns the name of the ConfigMap the Konfiguration keeps
+// a copy of its inventory in, or an empty string if it keeps none.
+func inventoryBackupName(konfig *appsv1.Konfiguration) string {
+	name := konfig.GetAnnotations()[appsv1.InventoryBackupAnnotation]
+	if name == inventoryName(konfig) {
+		return ""
+	}
+	return name
+}
+
+// writeInventoryBackup copies the given encoded inventory to the ConfigMap
+// named by the InventoryBackupAnnotation of the Konfiguration, if set, split
+// across a chain of ConfigMaps like the inventory when it is too large. The
+// copy is labeled like the inventory, but not owned by the Konfiguration.
+// ConfigMaps labeled for another Konfiguration are not overwritten.
+func (r *KonfigurationReconciler) writeInventoryBackup(ctx context.Context, konfig *appsv1.Konfiguration, data []byte) error {
+	name := inventoryBackupName(konfig)
+	if name == "" {
+		return nil
+	}
+	parts, err := r.inventoryParts(konfig, name, data)
+	if err != nil {
+		return err
+	}
+	for _, cm := range parts {
+		if err := r.writeInventoryPart(ctx, konfig, cm, false); err != nil {
+			return fmt.Errorf("failed to write inventory backup '%s/%s': %w", cm.GetNamespace(), cm.GetName(), err)
+		}
+	}
+	return r.deleteInventoryParts(ctx, konfig, name, len(parts))
+}
+
+// isInventoryOf returns true if the ConfigMap holds an inventory, or a part of
+// one, and is labeled for the Konfiguration.
+func (r *KonfigurationReconciler) isInventoryOf(cm *corev1.ConfigMap, konfig *appsv1.Konfiguration) bool {
+	_, ok := cm.Data[appsv1.InventoryKey]
+	if !ok {
+		_, ok = cm.BinaryData[appsv1.InventoryCompressedKey]
+	}
+	return ok && r.ownership.ownedBy(cm.GetLabels(), client.ObjectKeyFromObject(konfig))
+}
+
+// readInventory returns the inventory recorded for the Konfiguration, or nil if
+// there is none. If the Konfiguration has no inventory but keeps a backup of
+// it, the backup is returned instead, so a Konfiguration recreated with the
+// same name resumes pruning where the previous one left off.
+func (r *KonfigurationReconciler) readInventory(ctx context.Context, konfig *appsv1.Konfiguration) (*appsv1.Inventory, error) {
+	configMaps := r.clientset.CoreV1().ConfigMaps(konfig.GetNamespace())
+	cm, err := configMaps.Get(ctx, inventoryName(konfig), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		name := inventoryBackupName(konfig)
+		if name == "" {
+			return nil, nil
+		}
+		if cm, err = configMaps.Get(ctx, name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !r.isInventoryOf(cm, konfig) {
+			return nil, fmt.Errorf("ConfigMap '%s/%s' is not an inventory backup of this Konfiguration", cm.GetNamespace(), name)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := r.readInventoryParts(ctx, konfig, cm)
+	if err != nil {
+		return nil, err
+	}
+	var inventory appsv1.Inventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("failed to decode inventory '%s/%s': %w", cm.GetNamespace(), cm.GetName(), err)
+	}
+	return &inventory, nil
+}
+
+// readInventoryParts returns the JSON encoded inventory starting at the given
+// ConfigMap, following and decompressing its chain if it was split.
+func (r *KonfigurationReconciler) readInventoryParts(ctx context.Context, konfig *appsv1.Konfiguration, first *corev1.ConfigMap) ([]byte, error) {
+	if data, ok := first.Data[appsv1.InventoryKey]; ok {
+		return []byte(data), nil
+	}
+	configMaps := r.clientset.CoreV1().ConfigMaps(first.GetNamespace())
+	var buf bytes.Buffer
+	seen := make(map[string]bool)
+	for cm := first; ; {
+		seen[cm.GetName()] = true
+		buf.Write(cm.BinaryData[appsv1.InventoryCompressedKey])
+		next := cm.GetAnnotations()[appsv1.InventoryNextAnnotation]
+		if next == "" {
+			break
+		}
+		if seen[next] {
+			return nil, fmt.Errorf("inventory '%s/%s' has a cycle at '%s'", first.GetNamespace(), first.GetName(), next)
+		}
+		var err error
+		if cm, err = configMaps.Get(ctx, next, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to read inventory '%s/%s': %w", first.GetNamespace(), next, err)
+		}
+		if !r.isInventoryOf(cm, konfig) {
+			return nil, fmt.Errorf("ConfigMap '%s/%s' is not a part of the inventory of this Konfiguration", cm.GetNamespace(), next)
+		}
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress inventory '%s/%s': %w", first.GetNamespace(), first.GetName(), err)
+	}
+	defer zr.Close()
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress inventory '%s/%s': %w", first.GetNamespace(), first.GetName(), err)
+	}
+	return data, nil
+}
+
+// staleEntries returns the entries of previous that are not in current, in
+// their original order.
+func staleEntries(previous, current []appsv1.InventoryEntry) []appsv1.InventoryEntry {
+	keep := make(map[appsv1.InventoryEntry]struct{}, len(current))
+	for _, entry := range current {
+		keep[entry] = struct{}{}
+	}
+	var stale []appsv1.InventoryEntry
+	for _, entry := range previous {
+		if _, ok := keep[entry]; !ok {
+			stale = append(stale, entry)
+		}
+	}
+	return stale
+}
+
+// deleteInventory removes the inventory of the Konfiguration, its backup and
+// the reference to it.
+func (r *KonfigurationReconciler) deleteInventory(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	if err := r.deleteInventoryParts(ctx, konfig, inventoryName(konfig), 0); err != nil {
+		return err
+	}
+	if name := inventoryBackupName(konfig); name != "" {
+		if err := r.deleteInventoryParts(ctx, konfig, name, 0); err != nil {
+			return err
+		}
+	}
+	konfig.Status.InventoryRef = nil
+	konfig.Status.InventoryParts = 0
+	konfig.Status.InventoryCount = 0
+	konfig.Status.ClusterScopedCount = 0
+	return nil
+}
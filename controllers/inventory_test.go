@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestInventoryParts(t *testing.T) {
+	// Random data does not compress, so it takes as many parts as its size
+	// requires.
+	random := make([]byte, 2*inventoryPartSize+1)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		parts int
+	}{
+		{name: "small inventory", data: []byte(`{"entries":[]}`), parts: 1},
+		{name: "compressible inventory", data: bytes.Repeat([]byte(`{"kind":"ConfigMap"},`), inventoryPartSize), parts: 1},
+		{name: "incompressible inventory", data: random, parts: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ownership, err := newOwnershipLabels("", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newTestReconciler()
+			r.ownership = ownership
+			r.clientset = kubefake.NewSimpleClientset()
+			konfig := testKonfiguration()
+
+			parts, err := r.inventoryParts(konfig, inventoryName(konfig), tt.data)
+			if err != nil {
+				t.Fatalf("inventoryParts() error = %v", err)
+			}
+			if len(parts) != tt.parts {
+				t.Fatalf("inventoryParts() = %d parts, want %d", len(parts), tt.parts)
+			}
+			for i, cm := range parts {
+				if cm.GetName() != inventoryPartName(inventoryName(konfig), i) {
+					t.Errorf("part %d name = %s", i, cm.GetName())
+				}
+				if next, last := cm.GetAnnotations()[appsv1.InventoryNextAnnotation], i == len(parts)-1; (next == "") != last {
+					t.Errorf("part %d next = %q", i, next)
+				}
+				if _, err := r.clientset.CoreV1().ConfigMaps(cm.GetNamespace()).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, err := r.readInventoryParts(context.TODO(), konfig, parts[0])
+			if err != nil {
+				t.Fatalf("readInventoryParts() error = %v", err)
+			}
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("readInventoryParts() returned %d bytes, want the %d bytes written", len(data), len(tt.data))
+			}
+		})
+	}
+}
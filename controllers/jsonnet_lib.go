@@ -0,0 +1,100 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// jsonnetLib holds the jsonnet libraries shipped with the controller. They are
+// added to the library search path when rendering, so sources can use e.g.
+// `import 'kubecfg.libsonnet'` without vendoring it. A file of the same name
+// next to the importing file takes precedence. The image embeds the
+// kubecfg.libsonnet of the kubecfg it ships, and `make kubecfg-lib` updates the
+// copy in the tree.
+//
+//go:embed lib/*.libsonnet
+var jsonnetLib embed.FS
+
+// installJsonnetLib writes the embedded jsonnet libraries to a "lib" directory
+// under root and returns its path.
+func installJsonnetLib(root string) (string, error) {
+	dir := filepath.Join(root, "lib")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	files, err := fs.Glob(jsonnetLib, "lib/*.libsonnet")
+	if err != nil {
+		return "", err
+	}
+	for _, name := range files {
+		data, err := jsonnetLib.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(name)), data, 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// jsonnetLibDirs returns the library search path for evaluating the manifests
+// of the Konfiguration: the embedded libraries followed by the libraries of its
+// JsonnetLibRefs, which are written to a new directory. The returned function
+// removes that directory. The ConfigMaps are read directly rather than through
+// the cache, to avoid watching every ConfigMap in the cluster.
+func (r *KonfigurationReconciler) jsonnetLibDirs(ctx context.Context, konfig *appsv1.Konfiguration) ([]string, func(), error) {
+	var libDirs []string
+	if r.libDir != "" {
+		libDirs = append(libDirs, r.libDir)
+	}
+	if len(konfig.Spec.JsonnetLibRefs) == 0 {
+		return libDirs, func() {}, nil
+	}
+
+	dir, err := ioutil.TempDir(r.artifacts.root, konfig.GetName()+"-lib-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := func() { os.RemoveAll(dir) }
+	for _, ref := range konfig.Spec.JsonnetLibRefs {
+		cm, err := r.clientset.CoreV1().ConfigMaps(konfig.GetNamespace()).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			remove()
+			return nil, nil, fmt.Errorf("failed to read jsonnet libraries from ConfigMap '%s/%s': %w", konfig.GetNamespace(), ref.Name, err)
+		}
+		// ConfigMap keys cannot contain path separators
+		for name, data := range cm.Data {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+				remove()
+				return nil, nil, err
+			}
+		}
+	}
+	return append(libDirs, dir), remove, nil
+}
@@ -18,7 +18,9 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -26,16 +28,28 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/predicates"
 	"github.com/fluxcd/pkg/untar"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -51,10 +65,92 @@ type KonfigurationReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
 	httpClient *retryablehttp.Client
+	artifacts  *artifactCache
+	pipelines  *pipelineCache
+	locks      *keyLocks
+	batches    *statusBatcher
+	budgets    *namespaceBudgets
+	settings   *settingsStore
+	ownership  ownershipLabels
+	clientset  kubernetes.Interface
+	restConfig *rest.Config
+	namespaces client.Reader
+	libDir     string
+
+	secretProviderDir string
+	sealingKeyDir     string
+	volumeSourceDir   string
+	transformerDir    string
+
+	namespaceSelector labels.Selector
+	namespaceScoped   bool
+
+	recorder       record.EventRecorder
+	events         *eventQueue
+	eventSink      *eventSink
+	eventSinkHosts []string
+
+	debugLog logr.Logger
 }
 
 type ReconcilerOptions struct {
-	FluxEnabled bool
+	FluxEnabled       bool
+	ArtifactCacheDir  string
+	NamespaceSelector string
+	NamespaceScoped   bool
+	EventsAddr        string
+	EventsToken       string
+	EventSinkHosts    []string
+	ExpeditedWorkers  int
+	SecretProviderDir string
+	SealingKeyDir     string
+	VolumeSourceDir   string
+	TransformerDir    string
+	AuditAnnotations  bool
+
+	// NetworkPreconditions enables the preconditions of Konfigurations, which
+	// connect to addresses of their choosing from the controller.
+	NetworkPreconditions bool
+
+	// ObserveOnly keeps the controller from writing to the cluster on behalf
+	// of any Konfiguration, as if they all set observeOnly.
+	ObserveOnly bool
+
+	NamespaceMaxConcurrentReconciles int
+	NamespaceAPIQPS                  float64
+
+	NamespaceMaxObjects              int
+	NamespaceMaxClusterScopedObjects int
+	NamespaceMaxTargets              int
+
+	DependencyRequeueInterval time.Duration
+
+	// OwnershipLabelDomain is the domain of the labels tracking the objects
+	// created on behalf of Konfigurations. Objects labeled under one of the
+	// LegacyOwnershipLabelDomains are recognized as well, and relabeled.
+	OwnershipLabelDomain        string
+	LegacyOwnershipLabelDomains []string
+
+	// Shadow runs the controller as the shadow of the active controller,
+	// comparing the manifests it renders for the Konfigurations matching
+	// ShadowSelector with those the active controller applied, without
+	// writing to the cluster.
+	Shadow         bool
+	ShadowSelector string
+
+	// ShadowInterval is the interval at which Konfigurations are compared
+	// again in shadow mode, in addition to whenever the active controller
+	// applies them. Zero disables periodic comparisons.
+	ShadowInterval time.Duration
+
+	// SettingsFile overrides the settings above that can be changed at
+	// runtime, and is applied again whenever it changes. The others, such as
+	// the number of workers, are only read at startup.
+	SettingsFile string
+
+	// DebugLogger logs the reconciliations of Konfigurations annotated to
+	// be logged at the debug level.
+	DebugLogger logr.Logger
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -65,8 +161,142 @@ func (r *KonfigurationReconciler) SetupWithManager(log logr.Logger, mgr ctrl.Man
 	httpClient.RetryWaitMax = 30 * time.Second
 	httpClient.RetryMax = 5
 	httpClient.Logger = nil
+	httpClient.HTTPClient.Transport = &userAgentTransport{userAgent: mgr.GetConfig().UserAgent, rt: httpClient.HTTPClient.Transport}
 	r.httpClient = httpClient
 
+	// Set up a cache for extracted source artifacts
+	r.artifacts = newArtifactCache(opts.ArtifactCacheDir)
+
+	// Set up a clientset for APIs the controller-runtime client does not
+	// cover, such as pod logs
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+	r.clientset = clientset
+	r.restConfig = mgr.GetConfig()
+
+	// Set up a cache for Namespaces, since the cache of the manager cannot
+	// serve cluster-scoped objects when it is restricted to several
+	// namespaces. Namespaces are only read from the API server when running
+	// namespace-scoped, see getNamespace.
+	if !opts.NamespaceScoped {
+		namespaces, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+		if err != nil {
+			return fmt.Errorf("failed to create namespace cache: %w", err)
+		}
+		if err := mgr.Add(namespaces); err != nil {
+			return err
+		}
+		r.namespaces = namespaces
+	}
+
+	// Install the jsonnet libraries shipped with the controller
+	libDir, err := installJsonnetLib(opts.ArtifactCacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to install jsonnet libraries: %w", err)
+	}
+	r.libDir = libDir
+
+	// Variables may be resolved from secret providers mounted in this directory
+	r.secretProviderDir = opts.SecretProviderDir
+
+	// Sealed variables are unsealed with the keypair in this directory, whose
+	// certificate is served on the metrics endpoint
+	r.sealingKeyDir = opts.SealingKeyDir
+	if r.sealingKeyDir != "" {
+		if err := mgr.AddMetricsExtraHandler(sealingCertPath, http.HandlerFunc(r.serveSealingCert)); err != nil {
+			return fmt.Errorf("failed to serve the sealing certificate: %w", err)
+		}
+	}
+
+	// Summarize the Konfigurations of the fleet in the metrics of the
+	// controller
+	if err := metrics.Registry.Register(&fleetCollector{reader: mgr.GetClient()}); err != nil {
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
+
+	// Serve the metrics along with their exemplars, linking runs to the
+	// commits they reconciled, in the OpenMetrics format
+	if err := mgr.AddMetricsExtraHandler(openMetricsPath, openMetricsHandler()); err != nil {
+		return fmt.Errorf("failed to serve OpenMetrics: %w", err)
+	}
+
+	// Artifact sources may be read from volumes mounted in this directory
+	r.volumeSourceDir = opts.VolumeSourceDir
+
+	// Executable transformers are looked up in this directory
+	r.transformerDir = opts.TransformerDir
+
+	// Set up a cache for the progress of failed reconciliations
+	r.pipelines = newPipelineCache()
+
+	// Serialize reconciliations of a Konfiguration across queues
+	r.locks = newKeyLocks()
+
+	// Batch the status patches only reporting progress
+	r.batches = newStatusBatcher(statusBatchInterval)
+
+	// Limit the reconciliations of each namespace, so one tenant cannot
+	// monopolize the controller
+	r.budgets = newNamespaceBudgets()
+
+	// Observe-only mode, audit annotations, the limits of each namespace and
+	// the interval at which Konfigurations waiting on their dependencies are
+	// checked again are given by the flags, and may be changed at runtime by the settings
+	// file
+	r.settings = &settingsStore{}
+	r.settings.set(settingsFromOptions(opts))
+	if opts.SettingsFile != "" {
+		watcher := &settingsWatcher{
+			log:   log.WithName("settings"),
+			path:  opts.SettingsFile,
+			flags: settingsFromOptions(opts),
+			store: r.settings,
+		}
+		if err := watcher.load(); err != nil {
+			return err
+		}
+		if err := mgr.Add(watcher); err != nil {
+			return fmt.Errorf("failed to watch settings file: %w", err)
+		}
+	}
+
+	// Objects created on behalf of Konfigurations are labeled under the
+	// configured domain, and recognized under the legacy domains
+	r.ownership, err = newOwnershipLabels(opts.OwnershipLabelDomain, opts.LegacyOwnershipLabelDomains)
+	if err != nil {
+		return err
+	}
+
+	// Konfigurations may ask for their reconciliations to be logged at the
+	// debug level
+	r.debugLog = opts.DebugLogger
+
+	// Parse the selector for namespaces that opted in to reconciliation
+	r.namespaceScoped = opts.NamespaceScoped
+	if opts.NamespaceSelector != "" {
+		if opts.NamespaceScoped {
+			return fmt.Errorf("a namespace selector cannot be used when running namespace-scoped")
+		}
+		selector, err := labels.Parse(opts.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid namespace selector: %w", err)
+		}
+		r.namespaceSelector = selector
+	}
+
+	// Set up event recording and the controller-wide event sink
+	r.recorder = mgr.GetEventRecorderFor(controllerName)
+	r.events = newEventQueue(ctrl.Log.WithName("events"))
+	if err := mgr.Add(r.events); err != nil {
+		return err
+	}
+	if opts.EventsAddr != "" {
+		r.eventSink = &eventSink{address: opts.EventsAddr, token: []byte(opts.EventsToken)}
+	}
+	r.eventSinkHosts = opts.EventSinkHosts
+
 	// Index the Kustomizations by the GitRepository references they (may) point at.
 	if err := mgr.GetCache().IndexField(context.TODO(), &appsv1.Konfiguration{}, appsv1.GitRepositoryIndexKey,
 		r.indexBy(sourcev1.GitRepositoryKind)); err != nil {
@@ -79,27 +309,104 @@ func (r *KonfigurationReconciler) SetupWithManager(log logr.Logger, mgr ctrl.Man
 		return fmt.Errorf("failed setting index fields: %w", err)
 	}
 
+	// Index the Konfigurations by the Konfigurations they depend on.
+	if err := mgr.GetCache().IndexField(context.TODO(), &appsv1.Konfiguration{}, appsv1.DependsOnIndexKey,
+		r.indexByDependsOn); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	// Index the Konfigurations by their garbage collection tag.
+	if err := mgr.GetCache().IndexField(context.TODO(), &appsv1.Konfiguration{}, appsv1.GCTagIndexKey,
+		r.indexByGCTag); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	// In shadow mode, the selected Konfigurations are compared with the
+	// results of the active controller whenever it applies a new revision,
+	// and periodically only if ShadowInterval is set.
+	if opts.Shadow {
+		selector, err := labels.Parse(opts.ShadowSelector)
+		if err != nil {
+			return fmt.Errorf("invalid shadow selector: %w", err)
+		}
+		log.Info("Setting up shadow Konfigurations subscription", "Selector", selector.String())
+		return ctrl.NewControllerManagedBy(mgr).
+			Named("konfiguration-shadow").
+			For(&appsv1.Konfiguration{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, ReadyRevisionChangePredicate{}))).
+			Complete(shadowReconciler{KonfigurationReconciler: r, selector: selector, interval: opts.ShadowInterval})
+	}
+
+	// Requested reconciliations and new source revisions are handled from a
+	// separate queue if expedited workers are configured.
+	var forPredicate predicate.Predicate = predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{})
+	if opts.ExpeditedWorkers > 0 {
+		forPredicate = predicate.GenerationChangedPredicate{}
+	}
+
 	log.Info("Setting up Konfigurations subscription")
 	c := ctrl.NewControllerManagedBy(mgr).
-		For(&appsv1.Konfiguration{}, builder.WithPredicates(
-			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
-		))
+		For(&appsv1.Konfiguration{}, builder.WithPredicates(forPredicate))
 
+	// GitRepositories derived in other namespaces cannot be owned by their
+	// Konfiguration, so those left behind are removed once seen.
 	if opts.FluxEnabled {
-		log.Info("Subscribing to changes to GitRepositories")
 		c = c.Watches(
+			&source.Kind{Type: &sourcev1.GitRepository{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForOwnerOfDerivedSource),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc:  func(event.UpdateEvent) bool { return false },
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		)
+	}
+
+	expedited := c
+	if opts.ExpeditedWorkers > 0 {
+		log.Info("Setting up expedited Konfigurations subscription", "Workers", opts.ExpeditedWorkers)
+		expedited = ctrl.NewControllerManagedBy(mgr).
+			Named("konfiguration-expedited").
+			For(&appsv1.Konfiguration{}, builder.WithPredicates(reconcileRequestedPredicate)).
+			WithOptions(controller.Options{MaxConcurrentReconciles: opts.ExpeditedWorkers})
+	}
+
+	// Dependents are reconciled as soon as a Konfiguration they depend on is
+	// applied at a new revision, instead of waiting for their interval.
+	log.Info("Subscribing to new revisions of dependencies")
+	expedited = expedited.Watches(
+		&source.Kind{Type: &appsv1.Konfiguration{}},
+		handler.EnqueueRequestsFromMapFunc(r.requestsForDependentsOf),
+		builder.WithPredicates(ReadyRevisionChangePredicate{}),
+	)
+
+	// Parents roll up the readiness of their children as soon as it changes.
+	log.Info("Subscribing to readiness changes of children")
+	expedited = expedited.Watches(
+		&source.Kind{Type: &appsv1.Konfiguration{}},
+		handler.EnqueueRequestsFromMapFunc(r.requestsForParentsOf),
+		builder.WithPredicates(ReadyStatusChangePredicate{}),
+	)
+
+	if opts.FluxEnabled {
+		log.Info("Subscribing to changes to GitRepositories")
+		expedited = expedited.Watches(
 			&source.Kind{Type: &sourcev1.GitRepository{}},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForRevisionChangeOf(appsv1.GitRepositoryIndexKey)),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
 		)
 		log.Info("Subscribing to changes to Buckets")
-		c = c.Watches(
+		expedited = expedited.Watches(
 			&source.Kind{Type: &sourcev1.Bucket{}},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForRevisionChangeOf(appsv1.BucketIndexKey)),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
 		)
 	}
 
+	if opts.ExpeditedWorkers > 0 {
+		if err := expedited.Complete(expeditedReconciler{r}); err != nil {
+			return err
+		}
+	}
 	return c.Complete(r)
 }
 
@@ -109,10 +416,21 @@ func (r *KonfigurationReconciler) SetupWithManager(log logr.Logger, mgr ctrl.Man
 // +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurations/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurations/finalizers,verbs=update
-// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=buckets;gitrepositories,verbs=get;list;watch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=buckets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=buckets/status;gitrepositories/status,verbs=get
-// +kubebuilder:rbac:groups="",resources=secrets;serviceaccounts,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update;delete
 
 var httpPathRegex = regexp.MustCompile("(https?)://")
 
@@ -122,7 +440,37 @@ var httpPathRegex = regexp.MustCompile("(https?)://")
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *KonfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	reqLogger := log.FromContext(ctx)
+	reconcileID := newReconcileID()
+	reqLogger := log.FromContext(ctx).WithValues("reconcileID", reconcileID)
+
+	unlock := r.locks.Lock(req.NamespacedName.String())
+	defer unlock()
+
+	// Defer the reconciliation if the namespace used up its reconcile slots,
+	// and rate limit the API requests made on its behalf.
+	limits, err := r.budgetLimits(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	release, limiter, ok := r.budgets.acquire(req.Namespace, limits)
+	if !ok {
+		reqLogger.Info("Namespace reconcile budget is used up, deferring", "MaxConcurrentReconciles", limits.maxConcurrent)
+		return ctrl.Result{Requeue: true, RequeueAfter: budgetRetryDelay}, nil
+	}
+	defer release()
+	if limiter != nil {
+		r = r.withClient(&budgetedClient{Client: r.Client, limiter: limiter})
+	}
+	// kubecfg has no option to set its user agent, so its requests go through
+	// a proxy setting that of the Konfiguration.
+	if r.restConfig != nil {
+		proxy, err := startAPIProxy(r.restConfig, limiter, konfigurationUserAgent(r.restConfig.UserAgent, req.NamespacedName), r.artifacts.root)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to start API proxy: %w", err)
+		}
+		defer proxy.Close()
+		ctx = withKubecfgKubeconfig(ctx, proxy.kubeconfig)
+	}
 
 	reqLogger.Info("Reconciling konfiguration")
 
@@ -132,115 +480,878 @@ func (r *KonfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		// Check if object was deleted
 		// TODO: Optional ownership of created resources?
 		if client.IgnoreNotFound(err) == nil {
-			return ctrl.Result{}, nil
+			r.batches.forget(req.NamespacedName)
+			r.artifacts.Evict(req.NamespacedName.String())
+			r.pipelines.Evict(req.NamespacedName.String())
+			if err := r.cleanupPreviewNamespaces(ctx, req.NamespacedName, ""); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.cleanupTestJobs(ctx, req.NamespacedName, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.cleanupHookJobs(ctx, req.NamespacedName, "", nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, r.cleanupDerivedSources(ctx, req.NamespacedName, "")
 		}
 		return ctrl.Result{}, err
 	}
+	reqLogger = r.loggerFor(reqLogger, konfig, reconcileID)
+	ctx = log.IntoContext(ctx, reqLogger)
+	reqLogger.V(1).Info("Fetched konfiguration", "Generation", konfig.GetGeneration(), "ResourceVersion", konfig.GetResourceVersion())
 
-	// Check if the konfiguration is suspended
-	if konfig.IsSuspended() {
+	// Remove the managed objects of a deleted konfiguration if requested,
+	// otherwise make sure it will be finalized according to its deletion
+	// policy.
+	if !konfig.GetDeletionTimestamp().IsZero() {
+		return r.finalize(ctx, reqLogger, req, konfig)
+	}
+	if err := r.reconcileFinalizer(ctx, konfig); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Check if the namespace of the konfiguration opted in to reconciliation
+	if r.namespaceSelector != nil {
+		ns, err := r.getNamespace(ctx, konfig.GetNamespace())
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if ns == nil {
+			return ctrl.Result{}, fmt.Errorf("not allowed to read namespace '%s' to match it against the namespace selector", konfig.GetNamespace())
+		}
+		if !r.namespaceSelector.Matches(labels.Set(ns.GetLabels())) {
+			reqLogger.Info("Namespace does not match the namespace selector, skipping")
+			return ctrl.Result{
+				RequeueAfter: konfig.GetPollInterval(),
+			}, nil
+		}
+	}
+
+	// Skip spec edits that leave the canonical spec unchanged, such as a
+	// duration written in other units, only recording the generation as
+	// observed. The spec checksum is recorded before the spec is modified,
+	// e.g. by resolving variable files.
+	specChecksum := konfig.GetSpecChecksum()
+	if konfig.GetGeneration() != konfig.Status.ObservedGeneration && specChecksum == konfig.Status.ObservedSpecChecksum &&
+		apimeta.IsStatusConditionTrue(konfig.Status.Conditions, meta.ReadyCondition) {
+		reqLogger.Info("Spec is unchanged, skipping")
+		konfig.Status.ObservedGeneration = konfig.GetGeneration()
+		if err := r.patchStatus(ctx, req, konfig.Status); err != nil {
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{
 			RequeueAfter: konfig.GetInterval(),
 		}, nil
 	}
+	konfig.Status.ObservedSpecChecksum = specChecksum
 
-	// Initially set paths to those defined in spec. If we are running
-	// against a source archive, they will be turned into absolute paths.
-	// Otherwises they are probably http(s):// paths.
-	path := konfig.GetPath()
-
-	// Check if there is a reference to a source. This is a stop-gap solution
-	// before full integration with source-controller.
-	if sourceRef := konfig.GetSourceRef(); sourceRef != nil {
-		source, err := sourceRef.GetSource(ctx, r.Client)
-		if client.IgnoreNotFound(err) == nil {
-			if err != nil {
-				reqLogger.Error(err, "Failed to fetch source for Konfiguration")
+	// Check if the konfiguration is suspended, removing the objects it manages
+	// if requested. They are applied again on resume, since the diff will
+	// find them missing.
+	if konfig.IsSuspended() {
+		if konfig.PruneOnSuspend() && konfig.Status.Snapshot != nil && !r.observeOnly(konfig) {
+			reqLogger.Info("Konfiguration is suspended, removing managed objects")
+			r.pipelines.Evict(req.NamespacedName.String())
+			if err := r.pruneAll(ctx, reqLogger, konfig); err != nil {
+				reqLogger.Error(err, "Failed to remove managed objects")
+				notReady := appsv1.KonfigurationNotReady(*konfig, "", appsv1.PruneFailedReason, err.Error())
+				r.notify(ctx, reqLogger, konfig, notReady, "")
+				if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+					reqLogger.Error(err, "Unable to update status")
+				}
 				return ctrl.Result{
-					RequeueAfter: konfig.GetRetryInterval(),
+					RequeueAfter: retryAfter(konfig, err),
 				}, nil
 			}
-		} else {
+			if err := r.cleanupTestJobs(ctx, req.NamespacedName, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.cleanupHookJobs(ctx, req.NamespacedName, "", nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.deleteInventory(ctx, konfig); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.deleteManifestStore(ctx, konfig); err != nil {
+				return ctrl.Result{}, err
+			}
+			konfig.Status.Snapshot = nil
+			if err := r.patchStatus(ctx, req, konfig.Status); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{
+			RequeueAfter: konfig.GetPollInterval(),
+		}, nil
+	}
+
+	// Check if reconciliation is paused after repeated failures
+	if remaining := circuitOpenFor(konfig, time.Now()); remaining > 0 {
+		reqLogger.Info("Circuit breaker is open, skipping", "Remaining", remaining.String())
+		return ctrl.Result{
+			RequeueAfter: remaining,
+		}, nil
+	}
+
+	// Reject flags outside the allow-list, unknown feature gates and
+	// conflicting revision constraints of dependencies, which may have been
+	// set while the validating webhook was not enabled, as well as garbage
+	// collection tags already used by another Konfiguration.
+	err = konfig.ValidateSpec()
+	if err == nil {
+		if err = r.checkGCTag(ctx, konfig); err != nil && !isReconcileError(err) {
 			return ctrl.Result{}, err
 		}
+	}
+	if err != nil {
+		reqLogger.Error(err, "Invalid spec")
+		notReady := appsv1.KonfigurationNotReady(*konfig, "", appsv1.InvalidSpecReason, err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, "")
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: konfig.GetRetryInterval(),
+		}, nil
+	}
 
-		// Check if the artifact is not ready yet
-		if source.GetArtifact() == nil {
-			// TODO: status updates
-			reqLogger.Info("Source is not ready, artifact not found")
-			return ctrl.Result{RequeueAfter: konfig.GetRetryInterval()}, nil
+	// The deprecated kubeConfig is only rejected when it is set, so
+	// Konfigurations created with it keep being reconciled.
+	if konfig.Spec.KubeConfig != nil {
+		reqLogger.Info("Ignoring the deprecated kubeConfig, set targets to apply the manifests to remote clusters")
+	}
+
+	// Wait for the dependencies to be ready. This is not a failure, so it is
+	// neither reported with a warning nor counted by the circuit breaker, and
+	// the reconciliation is retried at a fixed interval rather than backing
+	// off. Dependents are also requeued as soon as a dependency turns ready.
+	if err := r.checkDependencies(ctx, konfig); err != nil {
+		if !isReconcileError(err) {
+			return ctrl.Result{}, err
 		}
+		reqLogger.Info("Waiting for dependencies", "Reason", err.Error())
+		waiting := appsv1.KonfigurationWaiting(*konfig, "", reasonFor(err), err.Error())
+		r.notify(ctx, reqLogger, konfig, waiting, "")
+		if err := r.patchStatus(ctx, req, waiting.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: r.dependencyRequeueInterval(),
+		}, nil
+	}
 
-		// Create a temp directory for the artifact
-		tmpDir, err := ioutil.TempDir("", konfig.GetName())
-		if err != nil {
-			reqLogger.Error(err, "Could not allocate a temp directory for source artifact")
-			return ctrl.Result{
-				RequeueAfter: konfig.GetRetryInterval(),
-			}, nil
+	// Resolve the path and revision to render, fetching the source artifact
+	// if necessary.
+	path, revision, err := r.prepareSource(ctx, reqLogger, req, konfig)
+	if err != nil {
+		if !isReconcileError(err) {
+			return ctrl.Result{}, err
 		}
-		defer os.RemoveAll(tmpDir)
+		notReady := appsv1.KonfigurationNotReady(*konfig, "", reasonFor(err), err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, "")
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: retryAfter(konfig, err),
+		}, nil
+	}
+	reqLogger = reqLogger.WithValues("revision", revision)
+	ctx = log.IntoContext(ctx, reqLogger)
+	reqLogger.V(1).Info("Resolved source", "Path", path)
 
-		// Download and extract the artifact
-		if err := r.downloadAndExtractTo(source.GetArtifact().URL, tmpDir); err != nil {
-			reqLogger.Error(err, "Failed to download source artifact")
-			return ctrl.Result{
-				RequeueAfter: konfig.GetRetryInterval(),
-			}, nil
+	// Wait for the dependencies constraining their revision to have applied
+	// it, which can only be checked once the revision to apply is known.
+	if err := r.checkDependencyRevisions(ctx, konfig, revision); err != nil {
+		if !isReconcileError(err) {
+			return ctrl.Result{}, err
+		}
+		reqLogger.Info("Waiting for dependencies", "Reason", err.Error())
+		waiting := appsv1.KonfigurationWaiting(*konfig, "", reasonFor(err), err.Error())
+		r.notify(ctx, reqLogger, konfig, waiting, "")
+		if err := r.patchStatus(ctx, req, waiting.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
 		}
+		return ctrl.Result{
+			RequeueAfter: r.dependencyRequeueInterval(),
+		}, nil
+	}
 
-		path, err = securejoin.SecureJoin(tmpDir, path)
+	// Render into the preview namespace for the source branch if enabled,
+	// otherwise remove any left over from a previous preview. Preview
+	// namespaces are left alone in observe-only mode.
+	observeOnly := r.observeOnly(konfig)
+	if konfig.PreviewEnabled() && !observeOnly {
+		ns, err := r.reconcilePreviewNamespace(ctx, konfig, revision)
 		if err != nil {
-			reqLogger.Error(err, "Failed to format path relative to tmp directory")
+			reqLogger.Error(err, "Failed to prepare preview namespace")
+			notReady := appsv1.KonfigurationNotReady(*konfig, revision, appsv1.ApplyFailedReason, err.Error())
+			r.notify(ctx, reqLogger, konfig, notReady, revision)
+			if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+				reqLogger.Error(err, "Unable to update status")
+			}
 			return ctrl.Result{
-				RequeueAfter: konfig.GetRetryInterval(),
+				RequeueAfter: retryAfter(konfig, err),
 			}, nil
 		}
+		konfig.Status.PreviewNamespace = ns
+	} else if !observeOnly {
+		if err := r.cleanupPreviewNamespaces(ctx, req.NamespacedName, ""); err != nil {
+			return ctrl.Result{}, err
+		}
+		konfig.Status.PreviewNamespace = ""
+	}
 
+	// Check whether a hibernation window is active, so the workloads are
+	// scaled accordingly, and reconcile again when the next window starts or
+	// ends.
+	nextHibernation, err := updateHibernation(konfig, time.Now())
+	if err != nil {
+		reqLogger.Error(err, "Failed to evaluate hibernation schedules")
+		notReady := appsv1.KonfigurationNotReady(*konfig, revision, appsv1.EvaluationFailedReason, err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, revision)
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: konfig.GetRetryInterval(),
+		}, nil
 	}
 
-	// Do reconciliation
-	if err := r.reconcile(ctx, reqLogger, konfig, path); err != nil {
-		reqLogger.Error(err, "Error during reconciliation")
+	// Hold back the apply until the preconditions are met
+	if err := r.checkPreconditions(ctx, konfig); err != nil {
+		reqLogger.Info("Precondition not met", "Reason", err.Error())
+		if konfig.PreRenderEnabled() && !konfig.HasTargets() && !observeOnly {
+			r.preRender(ctx, reqLogger, konfig, path, revision)
+		}
+		notReady := appsv1.KonfigurationNotReady(*konfig, revision, appsv1.PreconditionNotMetReason, err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, revision)
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
 		return ctrl.Result{
 			RequeueAfter: konfig.GetRetryInterval(),
 		}, nil
 	}
 
-	// TODO: Update status
+	// Do reconciliation, or only observe the cluster in observe-only mode,
+	// summarizing it for tools polling the status
+	summary := newRunSummary(revision, time.Now())
+	summary.CommitURL = r.sourceCommitURL(ctx, konfig, revision)
+	var observed string
+	switch {
+	case observeOnly:
+		observed, err = r.observe(ctx, reqLogger, konfig, path, revision, summary)
+	case konfig.HasTargets():
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.DriftedCondition)
+		err = r.reconcileTargets(ctx, reqLogger, konfig, path, revision)
+	default:
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.DriftedCondition)
+		konfig.Status.Targets = nil
+		err = r.reconcile(ctx, reqLogger, konfig, path, revision, summary)
+		if err == nil {
+			err = r.pruneRemovedTargets(ctx, reqLogger, konfig, nil)
+		}
+	}
+	summary.finish(konfig, err)
+	if !observeOnly {
+		recordCircuitResult(konfig, err)
+	}
+	if err != nil {
+		reqLogger.Error(err, "Error during reconciliation")
+		notReady := appsv1.KonfigurationNotReady(*konfig, revision, reasonFor(err), err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, revision)
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: retryAfter(konfig, err),
+		}, nil
+	}
+
+	// Report the observation without marking the revision as applied
+	if observeOnly {
+		notReady := appsv1.KonfigurationNotReady(*konfig, revision, appsv1.ObserveOnlyReason, observed)
+		r.notify(ctx, reqLogger, konfig, notReady, revision)
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{
+			RequeueAfter: konfig.GetInterval(),
+		}, nil
+	}
+
+	// Adapt the interval to the activity of the source
+	updateEffectiveInterval(konfig, revision)
+	ready := appsv1.KonfigurationReady(*konfig, revision, meta.ReconciliationSucceededReason, appliedMessage(konfig, revision))
+	if err := r.rollupChildren(ctx, &ready); err != nil {
+		reqLogger.Error(err, "Failed to roll up the readiness of children")
+		notReady := appsv1.KonfigurationNotReady(ready, revision, reasonFor(err), err.Error())
+		r.notify(ctx, reqLogger, konfig, notReady, revision)
+		if err := r.patchStatus(ctx, req, notReady.Status); err != nil {
+			reqLogger.Error(err, "Unable to update status")
+		}
+		return ctrl.Result{
+			RequeueAfter: retryAfter(konfig, err),
+		}, nil
+	}
+	r.notify(ctx, reqLogger, konfig, ready, revision)
+	if err := r.patchStatus(ctx, req, ready.Status); err != nil {
+		return ctrl.Result{}, err
+	}
 
+	// Event-driven Konfigurations are not requeued, unless a hibernation
+	// window or the grace period of a cordoned object is due
+	requeueAfter := ready.GetEffectiveInterval()
+	if !nextHibernation.IsZero() {
+		if untilNext := time.Until(nextHibernation); requeueAfter == 0 || untilNext < requeueAfter {
+			requeueAfter = untilNext
+		}
+	}
+	if expiry := nextCordonExpiry(&ready); !expiry.IsZero() {
+		if untilExpiry := time.Until(expiry); requeueAfter == 0 || untilExpiry < requeueAfter {
+			requeueAfter = untilExpiry
+		}
+	}
 	return ctrl.Result{
-		RequeueAfter: konfig.GetInterval(),
+		RequeueAfter: requeueAfter,
 	}, nil
 }
 
-func (r *KonfigurationReconciler) reconcile(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path string) error {
-	// Run a diff first to determine if any actions are necessary
-	updateRequired, err := runKubecfgDiff(ctx, reqLogger, konfig, path)
+// prepareSource returns the path kubecfg should be invoked against along with
+// the revision it represents. Paths are initially those defined in spec. If we
+// are running against a source archive, they will be turned into absolute paths
+// inside the extracted artifact. Otherwise they are probably http(s):// paths
+// and the revision is the path itself.
+func (r *KonfigurationReconciler) prepareSource(ctx context.Context, reqLogger logr.Logger, req ctrl.Request, konfig *appsv1.Konfiguration) (path, revision string, err error) {
+	path = konfig.GetPath()
+
+	// Check if there is a reference to a source. This is a stop-gap solution
+	// before full integration with source-controller.
+	sourceRef := konfig.GetSourceRef()
+	if konfig.Spec.ArtifactSource != nil {
+		if sourceRef != nil {
+			return "", "", withReason(appsv1.InvalidSpecReason, fmt.Errorf("sourceRef and artifactSource cannot be set together"))
+		}
+		return r.prepareArtifactSource(ctx, reqLogger, req, konfig)
+	}
+	if sourceRef == nil {
+		if vars := konfig.GetVariables(); vars != nil && vars.HasFiles() {
+			return "", "", withReason(appsv1.EvaluationFailedReason, fmt.Errorf("variable files require a sourceRef"))
+		}
+		if konfig.GetPinnedRevision() != "" {
+			return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("pinned revisions require a sourceRef"))
+		}
+		if konfig.GetRevisionSelector() != nil {
+			return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("revision selectors require a sourceRef"))
+		}
+		entrypoint, err := resolveEntrypoint(path, konfig.GetEntrypoints())
+		if err != nil {
+			return "", "", withReason(appsv1.ArtifactFailedReason, err)
+		}
+		return entrypoint, path, nil
+	}
+
+	// Remove any derived sources that are no longer referenced, e.g. because
+	// the ref override, pinned revision or revision selector was removed from
+	// the spec. In observe-only mode derived sources are only read.
+	observeOnly := r.observeOnly(konfig)
+	if !observeOnly {
+		var keep string
+		if konfig.UsesDerivedSource() {
+			keep = konfig.GetDerivedSourceName()
+		}
+		if err := r.cleanupDerivedSources(ctx, req.NamespacedName, keep); err != nil {
+			return "", "", err
+		}
+	}
+
+	var source sourcev1.Source
+	switch {
+	case konfig.UsesDerivedSource() && observeOnly:
+		source, err = r.observeDerivedSource(ctx, konfig)
+	case konfig.UsesDerivedSource():
+		source, err = r.reconcileDerivedSource(ctx, konfig)
+	default:
+		source, err = sourceRef.GetSource(ctx, r.Client)
+	}
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return "", "", err
+		}
+		reqLogger.Error(err, "Failed to fetch source for Konfiguration")
+		return "", "", withReason(appsv1.ArtifactFailedReason, err)
+	}
+
+	// Tear down the preview environment if the branch it was created for no
+	// longer exists.
+	if konfig.PreviewEnabled() && !observeOnly && sourceBranchGone(source) {
+		reqLogger.Info("Source branch no longer exists, removing preview namespace")
+		if err := r.cleanupPreviewNamespaces(ctx, req.NamespacedName, ""); err != nil {
+			return "", "", err
+		}
+		konfig.Status.PreviewNamespace = ""
+		return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("source '%s/%s' branch no longer exists, preview namespace removed", sourceRef.Namespace, sourceRef.Name))
+	}
+
+	// Check if the artifact is not ready yet
+	artifact := source.GetArtifact()
+	if artifact == nil {
+		reqLogger.Info("Source is not ready, artifact not found")
+		return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("source '%s/%s' is not ready, artifact not found", sourceRef.Namespace, sourceRef.Name))
+	}
+
+	// Hold off until the source has fetched the pinned revision, rather than
+	// applying whatever it had before the pin changed.
+	if !konfig.MatchesPinnedRevision(artifact.Revision) {
+		reqLogger.Info("Source artifact does not match the pinned revision yet", "Revision", artifact.Revision)
+		return "", "", withReason(appsv1.ArtifactFailedReason, fmt.Errorf("source '%s/%s' is at revision '%s', waiting for pinned revision '%s'",
+			sourceRef.Namespace, sourceRef.Name, artifact.Revision, konfig.GetPinnedRevision()))
+	}
+
+	// Reuse a previously extracted artifact if the source revision has not
+	// changed since the last reconcile, e.g. when only variables were updated,
+	// or if another Konfiguration extracted it already. Artifacts extracted
+	// sparsely are only shared for the same paths.
+	if err := checkQuarantine(konfig, artifact.Revision); err != nil {
+		return "", "", err
+	}
+
+	sparsePaths := konfig.GetSparsePaths()
+	cacheKey, artifactID := req.NamespacedName.String(), sparseArtifactKey(artifactKey(artifact), sparsePaths)
+	dir, ok := r.artifacts.Get(cacheKey, artifactID)
+	if ok {
+		reqLogger.Info("Reusing extracted artifact", "Revision", artifact.Revision)
+	} else {
+		// Allocate a directory for the artifact
+		dir, err = r.artifacts.Allocate(konfig.GetName())
+		if err != nil {
+			reqLogger.Error(err, "Could not allocate a directory for source artifact")
+			return "", "", withReason(appsv1.ArtifactFailedReason, err)
+		}
+
+		// Download and extract the artifact, quarantining its revision if it
+		// cannot be extracted safely
+		if err := r.downloadAndExtractTo(ctx, artifact.URL, artifact.Checksum, dir, sparsePaths); err != nil {
+			reqLogger.Error(err, "Failed to download source artifact")
+			os.RemoveAll(dir)
+			var unsafe *unsafeArtifactError
+			if errors.As(err, &unsafe) {
+				return "", "", r.quarantineRevision(konfig, artifact.Revision, err)
+			}
+			return "", "", withReason(appsv1.ArtifactFailedReason, err)
+		}
+		dir = r.artifacts.Put(cacheKey, artifactID, dir)
+	}
+	releaseQuarantine(konfig)
+
+	if path, err = resolveArtifactPaths(reqLogger, konfig, dir); err != nil {
+		return "", "", err
+	}
+	return path, artifact.Revision, nil
+}
+
+// resolveArtifactPaths returns the entrypoint to evaluate within the artifact
+// extracted to dir, and resolves the variable files of the Konfiguration and
+// its targets relative to it. Variable files missing from the artifact are
+// reported with the VariablesUnresolvedReason rather than as a failure of the
+// artifact.
+func resolveArtifactPaths(reqLogger logr.Logger, konfig *appsv1.Konfiguration, dir string) (string, error) {
+	path, err := securejoin.SecureJoin(dir, konfig.GetPath())
 	if err != nil {
+		reqLogger.Error(err, "Failed to format path relative to artifact directory")
+		return "", withReason(appsv1.ArtifactFailedReason, err)
+	}
+	if path, err = resolveEntrypoint(path, konfig.GetEntrypoints()); err != nil {
+		return "", withReason(appsv1.ArtifactFailedReason, err)
+	}
+
+	// Resolve any variable files relative to the artifact directory
+	if vars := konfig.GetVariables(); vars != nil && vars.HasFiles() {
+		if err := resolveVariableFiles(dir, vars); err != nil {
+			reqLogger.Error(err, "Failed to format variable file paths relative to artifact directory")
+			return "", recordVariables(konfig, err)
+		}
+	}
+	for _, target := range konfig.Spec.Targets {
+		if vars := target.Variables; vars != nil && vars.HasFiles() {
+			if err := resolveVariableFiles(dir, vars); err != nil {
+				reqLogger.Error(err, "Failed to format variable file paths relative to artifact directory", "Target", target.Name)
+				return "", recordVariables(konfig, err)
+			}
+		}
+	}
+	return path, nil
+}
+
+// resolveVariableFiles rewrites the paths of variable files to be absolute
+// paths inside the given artifact directory.
+func resolveVariableFiles(dir string, vars *appsv1.Variables) error {
+	for _, files := range []map[string]string{vars.ExtStrFiles, vars.ExtCodeFiles, vars.TLAStrFiles, vars.TLACodeFiles} {
+		for name, file := range files {
+			path, err := securejoin.SecureJoin(dir, file)
+			if err != nil {
+				return unresolvedVariables([]string{name}, err)
+			}
+			if _, err := os.Stat(path); err != nil {
+				return unresolvedVariables([]string{name}, fmt.Errorf("variable file '%s' for '%s' not found in artifact: %w", file, name, err))
+			}
+			files[name] = path
+		}
+	}
+	return nil
+}
+
+// patchStatus patches the status of the Konfiguration if it changed. Patches
+// only reporting progress are batched, see statusBatcher.
+func (r *KonfigurationReconciler) patchStatus(ctx context.Context, req ctrl.Request, newStatus appsv1.KonfigurationStatus) error {
+	var konfig appsv1.Konfiguration
+	if err := r.Get(ctx, req.NamespacedName, &konfig); err != nil {
 		return err
 	}
+	if r.batches != nil && progressOnly(konfig.Status, newStatus) &&
+		r.batches.deferPatch(req.NamespacedName, newStatus, func() { r.flushStatus(req.NamespacedName) }) {
+		return nil
+	}
+	return r.writeStatus(ctx, &konfig, newStatus)
+}
 
-	// If no update required, check on the next interval.
-	// TODO: check status
-	if !updateRequired {
+// writeStatus patches the status of the Konfiguration, unless nothing changed
+// to avoid needless writes.
+func (r *KonfigurationReconciler) writeStatus(ctx context.Context, konfig *appsv1.Konfiguration, newStatus appsv1.KonfigurationStatus) error {
+	key := client.ObjectKeyFromObject(konfig)
+	if apiequality.Semantic.DeepEqual(konfig.Status, newStatus) {
+		if r.batches != nil {
+			r.batches.take(key)
+		}
 		return nil
 	}
+	patch := client.MergeFrom(konfig.DeepCopy())
+	konfig.Status = newStatus
+	if err := r.Status().Patch(ctx, konfig, patch); err != nil {
+		return err
+	}
+	if r.batches != nil {
+		r.batches.patched(key)
+	}
+	return nil
+}
+
+// renderPipeline runs the pre-render hooks, then lints and renders the
+// manifests of the Konfiguration at the given revision, unless they were
+// pre-rendered, and stores them as the start of a new pipeline.
+func (r *KonfigurationReconciler) renderPipeline(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path, revision string) (*pipelineState, error) {
+	key := client.ObjectKeyFromObject(konfig).String()
+	objs, ok := r.pipelines.TakePreRendered(key, revision, konfig.Status.ObservedSpecChecksum)
+	if ok {
+		reqLogger.Info("Using pre-rendered manifests", "Revision", revision)
+	} else {
+		if err := r.runHooks(ctx, reqLogger, konfig, appsv1.HookStagePreRender, revision, ""); err != nil {
+			return nil, withReason(appsv1.HookFailedReason, err)
+		}
+		if err := r.lint(ctx, reqLogger, konfig, path); err != nil {
+			return nil, err
+		}
+		var err error
+		if objs, err = r.renderObjects(ctx, reqLogger, konfig, path, revision); err != nil {
+			return nil, withReason(appsv1.EvaluationFailedReason, err)
+		}
+	}
+	manifests, err := r.prepareObjects(ctx, reqLogger, konfig, objs)
+	if err != nil {
+		return nil, withReason(appsv1.EvaluationFailedReason, err)
+	}
+	state := &pipelineState{
+		revision:     revision,
+		specChecksum: konfig.Status.ObservedSpecChecksum,
+		hibernating:  konfig.IsHibernating(),
+		manifests:    manifests,
+	}
+	r.pipelines.Put(key, state)
+	return state, nil
+}
+
+// preRender renders the manifests of the Konfiguration at the given revision
+// ahead of their apply, unless they were rendered already. Only the rendered
+// objects are kept; they are modified for the cluster when they are applied,
+// since its state may change in between. Hooks only run when the manifests
+// are applied, so Konfigurations with pre-render hooks are not pre-rendered.
+// Failures are only logged, since they are reported once the manifests are
+// applied.
+func (r *KonfigurationReconciler) preRender(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path, revision string) {
+	if konfig.HasHooks(appsv1.HookStagePreRender) {
+		return
+	}
+	key := client.ObjectKeyFromObject(konfig).String()
+	specChecksum := konfig.Status.ObservedSpecChecksum
+	if r.pipelines.HasPreRendered(key, revision, specChecksum) {
+		return
+	}
+	if _, ok := r.pipelines.Get(key, revision, specChecksum); ok {
+		return
+	}
+	if err := r.lint(ctx, reqLogger, konfig, path); err != nil {
+		reqLogger.Error(err, "Failed to lint manifests")
+		return
+	}
+	objs, err := r.renderObjects(ctx, reqLogger, konfig, path, revision)
+	if err != nil {
+		reqLogger.Error(err, "Failed to pre-render manifests")
+		return
+	}
+	r.pipelines.PutPreRendered(key, &preRendered{revision: revision, specChecksum: specChecksum, objs: objs})
+	reqLogger.Info("Pre-rendered manifests", "Revision", revision, "Objects", len(objs))
+}
 
-	// Run a dry-run
-	if err := runKubecfgUpdate(ctx, reqLogger, konfig, path, true); err != nil {
+func (r *KonfigurationReconciler) reconcile(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path, revision string, summary *runSummary) error {
+	// Resume from the stage that failed if the previous attempt was at the same
+	// revision and spec. Otherwise render the manifests, modifying them
+	// if necessary, e.g. to leave replica counts to HorizontalPodAutoscalers,
+	// roll out configuration changes or to protect cluster-scoped objects from
+	// garbage collection.
+	key := client.ObjectKeyFromObject(konfig).String()
+	state, ok := r.pipelines.Get(key, revision, konfig.Status.ObservedSpecChecksum)
+	if ok && state.hibernating != konfig.IsHibernating() {
+		ok = false
+	}
+	if ok {
+		reqLogger.Info("Resuming from previous attempt", "Checksum", state.manifests.checksum, "Validated", state.validated, "Applied", state.applied)
+	} else {
+		start := time.Now()
+		var err error
+		if state, err = r.renderPipeline(ctx, reqLogger.WithValues("stage", "render"), konfig, path, revision); err != nil {
+			return err
+		}
+		summary.stage("render", start)
+	}
+	konfig.Status.LastAttemptedChecksum = state.manifests.checksum
+
+	if !state.applied {
+		start := time.Now()
+		applyLog := reqLogger.WithValues("stage", "apply")
+		if err := r.runHooks(ctx, applyLog, konfig, appsv1.HookStagePreApply, revision, state.manifests.checksum); err != nil {
+			return withReason(appsv1.HookFailedReason, err)
+		}
+		if err := r.apply(ctx, applyLog, konfig, state); err != nil {
+			return err
+		}
+		state.applied = true
+		summary.stage("apply", start)
+	}
+
+	// Remove the objects the Konfiguration lists for deletion
+	if len(konfig.Spec.Deletes) != 0 {
+		if err := r.deleteTombstones(ctx, reqLogger, konfig, state.manifests.inventory); err != nil {
+			return withReason(appsv1.PruneFailedReason, err)
+		}
+	}
+
+	// Restore the cordoned objects rendered again, and delete the ones whose
+	// prune grace period expired
+	if err := r.reconcileCordoned(ctx, reqLogger, konfig, state.manifests.inventory); err != nil {
+		return withReason(appsv1.PruneFailedReason, err)
+	}
+
+	// Read back the applied objects if requested, before running any tests
+	if konfig.VerifyAppliedEnabled() {
+		start := time.Now()
+		entries, err := r.healthCheckEntries(ctx, konfig, state.manifests)
+		if err != nil {
+			return withReason(appsv1.VerificationFailedReason, err)
+		}
+		if err := verifyApplied(ctx, reqLogger.WithValues("stage", "verify"), r.Client, konfig, entries); err != nil {
+			return withReason(appsv1.VerificationFailedReason, err)
+		}
+		summary.stage("verify", start)
+	}
+
+	// Wait for the applied objects to be serving if requested
+	if len(konfig.GetHealthChecks()) != 0 {
+		start := time.Now()
+		entries, err := r.healthCheckEntries(ctx, konfig, state.manifests)
+		if err != nil {
+			return withReason(appsv1.HealthCheckFailedReason, err)
+		}
+		if err := checkHealth(ctx, reqLogger.WithValues("stage", "health"), r.Client, konfig, entries); err != nil {
+			return withReason(appsv1.HealthCheckFailedReason, err)
+		}
+		summary.stage("health", start)
+	}
+
+	if err := r.runHooks(ctx, reqLogger.WithValues("stage", "post-apply"), konfig, appsv1.HookStagePostApply, revision, state.manifests.checksum); err != nil {
+		return withReason(appsv1.HookFailedReason, err)
+	}
+
+	// Run the test Jobs once the manifests are applied, or remove those of
+	// previous runs if test hooks were disabled.
+	if konfig.TestHooksEnabled() {
+		start := time.Now()
+		if err := r.runTestHooks(ctx, reqLogger.WithValues("stage", "test"), konfig, state.manifests.tests, state.manifests.checksum); err != nil {
+			return withReason(appsv1.TestFailedReason, err)
+		}
+		summary.stage("test", start)
+	} else if err := r.cleanupTestJobs(ctx, client.ObjectKeyFromObject(konfig), nil); err != nil {
 		return err
 	}
 
-	// Run an update
-	if err := runKubecfgUpdate(ctx, reqLogger, konfig, path, false); err != nil {
+	if err := r.countChanges(ctx, konfig, state, summary); err != nil {
 		return err
 	}
+	if err := r.writeInventory(ctx, konfig, revision, state.manifests); err != nil {
+		return err
+	}
+	// The previous manifests are read back from the store on the next render
+	if konfig.StoreManifestsEnabled() || konfig.PreviousManifestsEnabled() {
+		if err := r.writeManifestStore(ctx, konfig, state.manifests); err != nil {
+			return err
+		}
+	} else if konfig.Status.ManifestsRef != nil {
+		if err := r.deleteManifestStore(ctx, konfig); err != nil {
+			return err
+		}
+	}
 
+	r.pipelines.Evict(key)
+	konfig.Status.Snapshot = state.manifests.snapshot
 	return nil
 }
 
-func (r *KonfigurationReconciler) downloadAndExtractTo(artifactURL, tmpDir string) error {
+// apply applies the rendered manifests of the given state if they differ from
+// the cluster, validating them first with a dry-run unless that was already
+// done by a previous attempt. If missing kinds are ignored, the objects of
+// kinds that are not registered yet are applied once they are. With
+// incremental apply, only the objects changed since the previous inventory are
+// applied.
+func (r *KonfigurationReconciler) apply(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, state *pipelineState) error {
+	incremental := konfig.FeatureGateEnabled(appsv1.FeatureGateIncrementalApply)
+
+	// The objects of the previous inventory no longer rendered are removed in
+	// order once the manifests are applied, rather than garbage collected by
+	// kubecfg in no particular order. Without any, kubecfg collects the
+	// objects no longer rendered, unless only the changed objects are applied.
+	var previous *appsv1.Inventory
+	var stale []appsv1.InventoryEntry
+	if konfig.GCEnabled() || incremental {
+		var err error
+		if previous, err = r.readInventory(ctx, konfig); err != nil {
+			return withReason(appsv1.PruneFailedReason, err)
+		}
+		if previous != nil && konfig.GCEnabled() {
+			stale = staleEntries(previous.Entries, state.manifests.inventory)
+		}
+	}
+	skipGC := len(stale) != 0 || incremental
+
+	applied := state.manifests.path
+	if incremental {
+		changed, count, err := r.changedManifests(konfig, state.manifests, previous)
+		if err != nil {
+			return withReason(appsv1.ApplyFailedReason, err)
+		}
+		if changed != applied && changed != "" {
+			defer os.Remove(changed)
+		}
+		reqLogger.Info("Applying the objects changed since the previous inventory", "Count", count)
+		if count == 0 {
+			if len(stale) == 0 {
+				return nil
+			}
+			if err := r.pruneStale(ctx, reqLogger, konfig, stale); err != nil {
+				return withReason(appsv1.PruneFailedReason, err)
+			}
+			state.updated = true
+			r.recordApply(ctx, konfig, state)
+			return nil
+		}
+		applied = changed
+	}
+	path := applied
+
+	var deferred []schema.GroupVersionKind
+	if konfig.IgnoreMissingKindsEnabled() {
+		ready, missing, err := r.splitMissingKinds(konfig, path)
+		if err != nil {
+			return withReason(appsv1.ApplyFailedReason, err)
+		}
+		if len(missing) != 0 {
+			defer os.Remove(ready)
+			reqLogger.Info("Deferring objects of kinds not registered yet", "Kinds", missing)
+			path, deferred = ready, missing
+		}
+	}
+
+	if !state.validated || len(deferred) != 0 {
+		// Run a diff first to determine if any actions are necessary
+		updateRequired, err := runKubecfgDiff(ctx, reqLogger, konfig, path)
+		if err != nil {
+			return withReason(appsv1.EvaluationFailedReason, err)
+		}
+
+		// If no update required, check on the next interval.
+		// TODO: check status
+		if !updateRequired && len(deferred) == 0 {
+			return nil
+		}
+
+		// Run a dry-run
+		if err := runKubecfgUpdate(ctx, reqLogger, konfig, path, true, false); err != nil {
+			return withReason(appsv1.ValidationFailedReason, err)
+		}
+		// The deferred objects are validated when they are applied
+		state.validated = len(deferred) == 0
+	}
+
+	// Fail on fields owned by other field managers unless they may be taken
+	// over. Server-side apply detects the conflicts itself.
+	if len(konfig.GetOverrideManagers()) != 0 && !konfig.FeatureGateEnabled(appsv1.FeatureGateServerSideApply) {
+		if err := r.checkFieldManagers(ctx, reqLogger, konfig, path); err != nil {
+			return withReason(appsv1.FieldManagerConflictReason, err)
+		}
+	}
+
+	// Run an update
+	if err := r.update(ctx, reqLogger, konfig, path, skipGC); err != nil {
+		return withReason(appsv1.ApplyFailedReason, err)
+	}
+
+	// Apply all the manifests again once the deferred kinds are registered.
+	// Objects of unregistered kinds cannot exist, so garbage collection of the
+	// first update did not remove any of them.
+	if len(deferred) != 0 {
+		if err := r.waitForKinds(ctx, reqLogger, konfig, deferred); err != nil {
+			return withReason(appsv1.ApplyFailedReason, err)
+		}
+		if err := r.update(ctx, reqLogger, konfig, applied, skipGC); err != nil {
+			return withReason(appsv1.ApplyFailedReason, err)
+		}
+		state.validated = true
+	}
+
+	// Remove the objects no longer rendered only once their replacements are
+	// applied, then let kubecfg collect any the inventory missed, e.g. because
+	// an earlier apply failed before it was recorded. kubecfg cannot collect
+	// garbage from the changed objects alone, since it would remove the others.
+	if len(stale) != 0 {
+		if err := r.pruneStale(ctx, reqLogger, konfig, stale); err != nil {
+			return withReason(appsv1.PruneFailedReason, err)
+		}
+		if !konfig.FeatureGateEnabled(appsv1.FeatureGateServerSideApply) && !incremental {
+			if err := runKubecfgUpdate(ctx, reqLogger, konfig, state.manifests.path, false, false); err != nil {
+				return withReason(appsv1.PruneFailedReason, err)
+			}
+		}
+	}
+	state.updated = true
+	r.recordApply(ctx, konfig, state)
+	return nil
+}
+
+// downloadAndExtractTo extracts the artifact at the given URL into tmpDir,
+// skipping any entries outside of sparsePaths if set. The artifact is
+// downloaded in full and verified against its checksum if set and computed
+// with a known algorithm, then inspected before it is extracted. An artifact
+// that fails inspection is reported with an unsafeArtifactError.
+func (r *KonfigurationReconciler) downloadAndExtractTo(ctx context.Context, artifactURL, checksum, tmpDir string, sparsePaths []string) error {
 	if hostname := os.Getenv("SOURCE_CONTROLLER_LOCALHOST"); hostname != "" {
 		u, err := url.Parse(artifactURL)
 		if err != nil {
@@ -254,6 +1365,7 @@ func (r *KonfigurationReconciler) downloadAndExtractTo(artifactURL, tmpDir strin
 	if err != nil {
 		return fmt.Errorf("failed to create a new request: %w", err)
 	}
+	req = req.WithContext(ctx)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
@@ -265,7 +1377,47 @@ func (r *KonfigurationReconciler) downloadAndExtractTo(artifactURL, tmpDir strin
 		return fmt.Errorf("failed to download artifact from %s, status: %s", artifactURL, resp.Status)
 	}
 
-	if _, err = untar.Untar(resp.Body, tmpDir); err != nil {
+	f, err := ioutil.TempFile(r.artifacts.root, "artifact-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var w io.Writer = f
+	hash, digest, ok := checksumHash(checksum)
+	if ok {
+		w = io.MultiWriter(f, hash)
+	} else if checksum != "" {
+		log.FromContext(ctx).Info("Not verifying artifact, the algorithm of its checksum is unknown", "Checksum", checksum)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download artifact, error: %w", err)
+	}
+	if ok {
+		if sum := fmt.Sprintf("%x", hash.Sum(nil)); sum != digest {
+			return fmt.Errorf("failed to verify artifact, checksum %s does not match %s", sum, digest)
+		}
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := inspectArtifact(f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var body io.Reader = f
+	if len(sparsePaths) != 0 {
+		filtered := filterArtifact(f, sparsePaths)
+		defer filtered.Close()
+		body = filtered
+	}
+
+	if _, err = untar.Untar(body, tmpDir); err != nil {
 		return fmt.Errorf("failed to untar artifact, error: %w", err)
 	}
 
@@ -84,13 +84,71 @@ func (r *KonfigurationReconciler) indexBy(kind string) func(o client.Object) []s
 			if k.Spec.SourceRef.Namespace != "" {
 				namespace = k.Spec.SourceRef.Namespace
 			}
-			return []string{fmt.Sprintf("%s/%s", namespace, k.Spec.SourceRef.Name)}
+			keys := []string{fmt.Sprintf("%s/%s", namespace, k.Spec.SourceRef.Name)}
+			// Konfigurations overriding the ref or selecting a revision are
+			// also interested in the GitRepository managed on their behalf.
+			if k.UsesDerivedSource() {
+				keys = append(keys, fmt.Sprintf("%s/%s", namespace, k.GetDerivedSourceName()))
+			}
+			return keys
 		}
 
 		return nil
 	}
 }
 
+// requestsForDependentsOf returns requests for the Konfigurations that depend
+// on the given Konfiguration, in dependency order.
+func (r *KonfigurationReconciler) requestsForDependentsOf(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		appsv1.DependsOnIndexKey: ObjectKey(obj).String(),
+	}); err != nil {
+		return nil
+	}
+	dd := make([]dependency.Dependent, len(list.Items))
+	for i, d := range list.Items {
+		dd[i] = d
+	}
+	sorted, err := dependency.Sort(dd)
+	if err != nil {
+		return nil
+	}
+	reqs := make([]reconcile.Request, len(sorted))
+	for i := range sorted {
+		reqs[i].NamespacedName.Name = sorted[i].Name
+		reqs[i].NamespacedName.Namespace = sorted[i].Namespace
+	}
+	return reqs
+}
+
+func (r *KonfigurationReconciler) indexByDependsOn(o client.Object) []string {
+	k, ok := o.(*appsv1.Konfiguration)
+	if !ok {
+		panic(fmt.Sprintf("Expected a Konfiguration, got %T", o))
+	}
+
+	keys := make([]string, 0, len(k.Spec.DependsOn))
+	for _, d := range k.Spec.DependsOn {
+		namespace := k.GetNamespace()
+		if d.Namespace != "" {
+			namespace = d.Namespace
+		}
+		keys = append(keys, fmt.Sprintf("%s/%s", namespace, d.Name))
+	}
+	return keys
+}
+
+func (r *KonfigurationReconciler) indexByGCTag(o client.Object) []string {
+	k, ok := o.(*appsv1.Konfiguration)
+	if !ok {
+		panic(fmt.Sprintf("Expected a Konfiguration, got %T", o))
+	}
+
+	return []string{k.GetGCTag()}
+}
+
 // ObjectKey returns client.ObjectKey for the object.
 func ObjectKey(object metav1.Object) client.ObjectKey {
 	return client.ObjectKey{
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// errKonfigurationNotControlled is returned when the Konfiguration of an
+// instance exists and is not controlled by it.
+var errKonfigurationNotControlled = errors.New("Konfiguration is not controlled by the instance")
+
+// KonfigurationInstanceReconciler reconciles a KonfigurationInstance object
+type KonfigurationInstanceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KonfigurationInstanceReconciler) SetupWithManager(log logr.Logger, mgr ctrl.Manager) error {
+	log.Info("Setting up KonfigurationInstances subscription")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1.KonfigurationInstance{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&appsv1.Konfiguration{}).
+		Watches(
+			&source.Kind{Type: &appsv1.KonfigurationTemplate{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForTemplateChange),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurationinstances,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurationinstances/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurationtemplates,verbs=get;list;watch
+
+// Reconcile creates or updates the Konfiguration of a KonfigurationInstance
+// from its template and parameters, and reports its readiness.
+func (r *KonfigurationInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	var instance appsv1.KonfigurationInstance
+	if err := r.Get(ctx, req.NamespacedName, &instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	patch := client.MergeFrom(instance.DeepCopy())
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+
+	var template appsv1.KonfigurationTemplate
+	if err := r.Get(ctx, client.ObjectKey{Name: instance.Spec.TemplateName}, &template); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		meta.SetResourceCondition(&instance, meta.ReadyCondition, metav1.ConditionFalse, appsv1.TemplateNotFoundReason,
+			fmt.Sprintf("KonfigurationTemplate '%s' does not exist", instance.Spec.TemplateName))
+		return ctrl.Result{}, r.Status().Patch(ctx, &instance, patch)
+	}
+
+	args, err := bindParameters(template.Spec.Parameters, instance.Spec.Parameters)
+	if err != nil {
+		reqLogger.Info("Invalid parameters", "Template", template.GetName(), "Error", err.Error())
+		meta.SetResourceCondition(&instance, meta.ReadyCondition, metav1.ConditionFalse, appsv1.InvalidParametersReason, err.Error())
+		return ctrl.Result{}, r.Status().Patch(ctx, &instance, patch)
+	}
+
+	konfig := &appsv1.Konfiguration{}
+	konfig.SetName(instance.GetName())
+	konfig.SetNamespace(instance.GetNamespace())
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, konfig, func() error {
+		// Never take over a Konfiguration created by someone else
+		if konfig.GetResourceVersion() != "" && !metav1.IsControlledBy(konfig, &instance) {
+			return errKonfigurationNotControlled
+		}
+		labels := konfig.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[appsv1.TemplateLabel] = template.GetName()
+		konfig.SetLabels(labels)
+
+		spec := template.Spec.Konfiguration.DeepCopy()
+		if len(args) != 0 {
+			if spec.Variables == nil {
+				spec.Variables = &appsv1.Variables{}
+			}
+			if spec.Variables.TLACode == nil {
+				spec.Variables.TLACode = make(map[string]string, len(args))
+			}
+			for name, code := range args {
+				spec.Variables.TLACode[name] = code
+			}
+		}
+		konfig.Spec = *spec
+		return controllerutil.SetControllerReference(&instance, konfig, r.Scheme)
+	})
+	if errors.Is(err, errKonfigurationNotControlled) {
+		reqLogger.Info("Konfiguration exists and is not controlled by the instance")
+		meta.SetResourceCondition(&instance, meta.ReadyCondition, metav1.ConditionFalse, appsv1.KonfigurationConflictReason,
+			fmt.Sprintf("Konfiguration '%s' exists and is not controlled by the instance", instance.GetName()))
+		return ctrl.Result{}, r.Status().Patch(ctx, &instance, patch)
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create or update Konfiguration: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		reqLogger.Info("Konfiguration "+string(op), "Template", template.GetName())
+	}
+
+	if ready := apimeta.FindStatusCondition(konfig.Status.Conditions, meta.ReadyCondition); ready != nil {
+		meta.SetResourceCondition(&instance, meta.ReadyCondition, ready.Status, ready.Reason, ready.Message)
+	} else {
+		meta.SetResourceCondition(&instance, meta.ReadyCondition, metav1.ConditionUnknown, appsv1.KonfigurationPendingReason,
+			"Waiting for the Konfiguration to be reconciled")
+	}
+	return ctrl.Result{}, r.Status().Patch(ctx, &instance, patch)
+}
+
+// requestsForTemplateChange enqueues the KonfigurationInstances of a
+// KonfigurationTemplate when it changes.
+func (r *KonfigurationInstanceReconciler) requestsForTemplateChange(obj client.Object) []reconcile.Request {
+	var list appsv1.KonfigurationInstanceList
+	if err := r.List(context.Background(), &list); err != nil {
+		return nil
+	}
+	var reqs []reconcile.Request
+	for i := range list.Items {
+		if list.Items[i].Spec.TemplateName == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+		}
+	}
+	return reqs
+}
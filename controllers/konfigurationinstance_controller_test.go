@@ -0,0 +1,120 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestBindParameters(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"replicas": {Type: "integer", Default: &apiextensionsv1.JSON{Raw: []byte("1")}},
+			"image":    {Type: "string"},
+		},
+		Required: []string{"image"},
+	}
+	tests := []struct {
+		name    string
+		schema  *apiextensionsv1.JSONSchemaProps
+		params  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "defaulted parameters",
+			schema: schema,
+			params: `{"image":"nginx"}`,
+			want:   map[string]string{"image": `"nginx"`, "replicas": "1"},
+		},
+		{
+			name:   "set parameters",
+			schema: schema,
+			params: `{"image":"nginx","replicas":3}`,
+			want:   map[string]string{"image": `"nginx"`, "replicas": "3"},
+		},
+		{name: "missing required parameter", schema: schema, params: `{}`, wantErr: true},
+		{name: "invalid parameter type", schema: schema, params: `{"image":"nginx","replicas":"3"}`, wantErr: true},
+		{name: "parameters not an object", schema: schema, params: `[]`, wantErr: true},
+		{
+			name:   "no schema",
+			params: `{"labels":{"app":"web"}}`,
+			want:   map[string]string{"labels": `{"app":"web"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bindParameters(tt.schema, &apiextensionsv1.JSON{Raw: []byte(tt.params)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bindParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bindParameters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileInstanceNotControlled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, appsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	template := &appsv1.KonfigurationTemplate{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+	instance := &appsv1.KonfigurationInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "app", UID: "uid"},
+		Spec:       appsv1.KonfigurationInstanceSpec{TemplateName: "web"},
+	}
+	existing := testKonfiguration()
+	existing.Spec.Path = "./unrelated"
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(template, instance, existing).Build()
+	r := &KonfigurationInstanceReconciler{Client: c, Scheme: scheme}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(instance)}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	var konfig appsv1.Konfiguration
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(existing), &konfig); err != nil {
+		t.Fatal(err)
+	}
+	if konfig.Spec.Path != "./unrelated" || len(konfig.GetOwnerReferences()) != 0 {
+		t.Errorf("Konfiguration not controlled by the instance was taken over")
+	}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(instance), instance); err != nil {
+		t.Fatal(err)
+	}
+	if ready := apimeta.FindStatusCondition(instance.Status.Conditions, meta.ReadyCondition); ready == nil || ready.Reason != appsv1.KonfigurationConflictReason {
+		t.Errorf("Ready condition = %v, want reason %s", ready, appsv1.KonfigurationConflictReason)
+	}
+}
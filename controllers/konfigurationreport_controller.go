@@ -0,0 +1,167 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// KonfigurationReportReconciler reconciles a KonfigurationReport object
+type KonfigurationReportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KonfigurationReportReconciler) SetupWithManager(log logr.Logger, mgr ctrl.Manager) error {
+	log.Info("Setting up KonfigurationReports subscription")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1.KonfigurationReport{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&source.Kind{Type: &appsv1.Konfiguration{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForKonfigurationChange),
+		).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurationreports,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.kubecfg.io,resources=konfigurationreports/status,verbs=get;update;patch
+
+// Reconcile aggregates the status of the Konfigurations selected by a
+// KonfigurationReport into its status.
+func (r *KonfigurationReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	var report appsv1.KonfigurationReport
+	if err := r.Get(ctx, req.NamespacedName, &report); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	opts := []client.ListOption{}
+	if report.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(report.Spec.Selector)
+		if err != nil {
+			reqLogger.Error(err, "Invalid Konfiguration selector")
+			return ctrl.Result{}, nil
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, opts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list Konfigurations: %w", err)
+	}
+
+	// Only update the report when the summary changed, to avoid a write on
+	// every Konfiguration status update.
+	status := summarizeKonfigurations(list.Items)
+	status.LastUpdateTime = report.Status.LastUpdateTime
+	if apiequality.Semantic.DeepEqual(report.Status, status) {
+		return ctrl.Result{}, nil
+	}
+	now := metav1.Now()
+	status.LastUpdateTime = &now
+
+	patch := client.MergeFrom(report.DeepCopy())
+	report.Status = status
+	return ctrl.Result{}, r.Status().Patch(ctx, &report, patch)
+}
+
+// summarizeKonfigurations computes the status of a KonfigurationReport from the
+// given Konfigurations.
+func summarizeKonfigurations(konfigs []appsv1.Konfiguration) appsv1.KonfigurationReportStatus {
+	status := appsv1.KonfigurationReportStatus{
+		Total:          len(konfigs),
+		FailureReasons: make(map[string]int),
+		Konfigurations: make([]appsv1.KonfigurationSummary, 0, len(konfigs)),
+	}
+	for _, k := range konfigs {
+		summary := appsv1.KonfigurationSummary{
+			Namespace:             k.GetNamespace(),
+			Name:                  k.GetName(),
+			Ready:                 metav1.ConditionUnknown,
+			Suspended:             k.IsSuspended(),
+			LastAppliedRevision:   k.Status.LastAppliedRevision,
+			LastAttemptedRevision: k.Status.LastAttemptedRevision,
+		}
+		if cond := apimeta.FindStatusCondition(k.Status.Conditions, meta.ReadyCondition); cond != nil {
+			summary.Ready = cond.Status
+			summary.Reason = cond.Reason
+		}
+		switch summary.Ready {
+		case metav1.ConditionTrue:
+			status.Ready++
+		case metav1.ConditionFalse:
+			status.NotReady++
+			status.FailureReasons[summary.Reason]++
+		}
+		if summary.Suspended {
+			status.Suspended++
+		}
+		status.Konfigurations = append(status.Konfigurations, summary)
+	}
+	// Keep the report bounded, listing the Konfigurations that are not ready
+	// first so they are the last to be left out.
+	sort.Slice(status.Konfigurations, func(i, j int) bool {
+		a, b := status.Konfigurations[i], status.Konfigurations[j]
+		if aReady, bReady := a.Ready == metav1.ConditionTrue, b.Ready == metav1.ConditionTrue; aReady != bReady {
+			return bReady
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	if len(status.Konfigurations) > appsv1.MaxReportEntries {
+		status.Omitted = len(status.Konfigurations) - appsv1.MaxReportEntries
+		status.Konfigurations = status.Konfigurations[:appsv1.MaxReportEntries]
+	}
+	return status
+}
+
+// requestsForKonfigurationChange enqueues every KonfigurationReport when a
+// Konfiguration changes.
+func (r *KonfigurationReportReconciler) requestsForKonfigurationChange(obj client.Object) []reconcile.Request {
+	var list appsv1.KonfigurationReportList
+	if err := r.List(context.Background(), &list); err != nil {
+		return nil
+	}
+	reqs := make([]reconcile.Request, len(list.Items))
+	for i := range list.Items {
+		reqs[i].NamespacedName.Name = list.Items[i].GetName()
+	}
+	return reqs
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestSummarizeKonfigurations(t *testing.T) {
+	konfigs := make([]appsv1.Konfiguration, appsv1.MaxReportEntries+10)
+	for i := range konfigs {
+		konfigs[i].SetNamespace("team")
+		konfigs[i].SetName(fmt.Sprintf("app-%03d", i))
+		konfigs[i].Status.Conditions = []metav1.Condition{{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: meta.ReconciliationSucceededReason}}
+	}
+	last := &konfigs[len(konfigs)-1]
+	last.Status.Conditions[0].Status = metav1.ConditionFalse
+	last.Status.Conditions[0].Reason = appsv1.EvaluationFailedReason
+
+	status := summarizeKonfigurations(konfigs)
+	if status.Total != len(konfigs) || status.Ready != len(konfigs)-1 || status.NotReady != 1 {
+		t.Errorf("counts = %d/%d/%d, want %d/%d/1", status.Total, status.Ready, status.NotReady, len(konfigs), len(konfigs)-1)
+	}
+	if len(status.Konfigurations) != appsv1.MaxReportEntries || status.Omitted != 10 {
+		t.Errorf("entries = %d, omitted = %d, want %d and 10", len(status.Konfigurations), status.Omitted, appsv1.MaxReportEntries)
+	}
+	if got := status.Konfigurations[0].Name; got != last.GetName() {
+		t.Errorf("first entry = %s, want the Konfiguration that is not ready, %s", got, last.GetName())
+	}
+}
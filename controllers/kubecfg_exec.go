@@ -20,7 +20,10 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os/exec"
 	"strings"
 
@@ -28,11 +31,37 @@ import (
 	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
 )
 
+// kubecfgError is the failure of a kubecfg command, carrying what it wrote to
+// stderr, so the cause of the failure can be told from the error.
+type kubecfgError struct {
+	err    error
+	stderr string
+}
+
+func (e *kubecfgError) Error() string {
+	if e.stderr == "" {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s, stderr: %s", e.err, e.stderr)
+}
+
+func (e *kubecfgError) Unwrap() error { return e.err }
+
+// kubecfgStderr returns what the failed kubecfg command of err wrote to
+// stderr, or the message of err if it is not the failure of a kubecfg command.
+func kubecfgStderr(err error) string {
+	var ke *kubecfgError
+	if errors.As(err, &ke) {
+		return ke.stderr
+	}
+	return err.Error()
+}
+
 func runKubecfgDiff(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string) (updateRequired bool, err error) {
 	cmdCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "/kubecfg", konfig.ToDiffArgs(path)...)
+	cmd := exec.CommandContext(cmdCtx, "/kubecfg", kubecfgArgs(ctx, konfig.ToDiffArgs(path))...)
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
@@ -57,14 +86,17 @@ func runKubecfgDiff(ctx context.Context, log logr.Logger, konfig *appsv1.Konfigu
 		return true, nil
 	}
 
-	return false, fmt.Errorf("Diff exited with non-zero/non-ten status %d, stdout: %s : stderr: %s", exitErr.ProcessState.ExitCode(), outBuf.String(), errBuf.String())
+	return false, &kubecfgError{
+		err:    fmt.Errorf("Diff exited with non-zero/non-ten status %d, stdout: %s", exitErr.ProcessState.ExitCode(), outBuf.String()),
+		stderr: sanitizeStderr(&errBuf),
+	}
 }
 
-func runKubecfgUpdate(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string, dryRun bool) error {
+func runKubecfgUpdate(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string, dryRun, skipGC bool) error {
 	cmdCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "/kubecfg", konfig.ToUpdateArgs(path, dryRun)...)
+	cmd := exec.CommandContext(cmdCtx, "/kubecfg", kubecfgArgs(ctx, konfig.ToUpdateArgs(path, dryRun, skipGC))...)
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
@@ -84,14 +116,74 @@ func runKubecfgUpdate(ctx context.Context, log logr.Logger, konfig *appsv1.Konfi
 			return err
 		}
 		log.Info(fmt.Sprintf("Process exited with a non-zero status of %d", exitErr.ProcessState.ExitCode()))
-		log.Info("Error executing command", "Stdout", stdoutBuf.String(), "Stderr", sanitizeStderr(&stderrBuf))
-		return exitErr
+		stderr := sanitizeStderr(&stderrBuf)
+		log.Info("Error executing command", "Stdout", stdoutBuf.String(), "Stderr", stderr)
+		// kubecfg garbage collects after applying all objects, so a failure
+		// after it started is a failure to prune.
+		if !dryRun && konfig.GCEnabled() && strings.Contains(stderr, "Garbage collecting") {
+			return withReason(appsv1.PruneFailedReason, &kubecfgError{err: exitErr, stderr: stderr})
+		}
+		return &kubecfgError{err: exitErr, stderr: stderr}
 	}
 
 	log.Info("Process completed successfully", "Stdout", stdoutBuf.String(), "Stderr", sanitizeStderr(&stderrBuf))
 	return nil
 }
 
+// runKubecfgShow renders the manifests at path, passing the output of kubecfg
+// to decode as it is produced rather than buffering it in memory. It returns
+// the output of any std.trace calls made during the evaluation, even if it
+// failed.
+func runKubecfgShow(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, libDirs []string, path string, decode func(io.Reader) error) (trace string, err error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(cmdCtx, "/kubecfg", kubecfgArgs(ctx, konfig.ToShowArgs(libDirs, path))...)
+
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("Rendering manifests", "Command", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("Show failed to start: %w", err)
+	}
+
+	decodeErr := decode(stdout)
+	if decodeErr != nil {
+		// Stop kubecfg instead of waiting for output that is not read, but
+		// keep what it reported so far, since a failed render may produce
+		// output that does not decode.
+		cancel()
+		io.Copy(ioutil.Discard, stdout)
+		cmd.Wait()
+		return evaluationTrace(stderrBuf.String()), fmt.Errorf("%w, stderr: %s", decodeErr, sanitizeStderr(&stderrBuf))
+	}
+	// A failed render may produce truncated output that decodes cleanly, so
+	// the exit status takes precedence.
+	err = cmd.Wait()
+	trace = evaluationTrace(stderrBuf.String())
+	if err != nil {
+		return trace, fmt.Errorf("Show exited with error: %w, stderr: %s", err, sanitizeStderr(&stderrBuf))
+	}
+	return trace, nil
+}
+
+// evaluationTrace returns the lines written by std.trace in the given output
+// of kubecfg.
+func evaluationTrace(stderr string) string {
+	var lines []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.HasPrefix(line, "TRACE: ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func sanitizeStderr(buf *bytes.Buffer) string {
 	scanner := bufio.NewScanner(buf)
 	lines := make([]string, 0)
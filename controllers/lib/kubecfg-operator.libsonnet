@@ -0,0 +1,29 @@
+// Functions provided to manifests rendered by the kubecfg-operator.
+
+{
+  // The source revision being rendered, and the name and namespace of the
+  // Konfiguration rendering it, e.g. to stamp version labels on objects.
+  revision:: std.extVar('kubecfg.io/revision'),
+  konfigurationName:: std.extVar('kubecfg.io/konfigurationName'),
+  namespace:: std.extVar('kubecfg.io/namespace'),
+
+  // The RFC 3339 time of the reconciliation if `reconcileTimeVar` is enabled
+  // on the Konfiguration, an empty string otherwise.
+  reconcileTime:: std.extVar('kubecfg.io/reconcileTime'),
+
+  // prevManifest(kind, name, namespace=null): returns the object of the
+  // given `kind` and `name`, and `namespace` if set, applied by the
+  // previous revision of the Konfiguration, as it was rendered, or
+  // null if there is none. Requires `previousManifests` to be enabled on
+  // the Konfiguration, otherwise null is always returned.
+  prevManifest(kind, name, namespace=null):: (
+    local matches = [
+      obj
+      for obj in std.extVar('kubecfg_operator_previous')
+      if obj.kind == kind && obj.metadata.name == name &&
+         (namespace == null ||
+          std.objectHas(obj.metadata, 'namespace') && obj.metadata.namespace == namespace)
+    ];
+    if std.length(matches) == 0 then null else matches[0]
+  ),
+}
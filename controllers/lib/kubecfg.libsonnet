@@ -0,0 +1,65 @@
+// Copyright 2017 The kubecfg authors
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// NB: libjsonnet native functions only pass primitive types, so some
+// functions json-encode and then json-decode values on the other side.
+
+{
+  // parseJson(data): parses the `data` string as a json representation
+  // and returns the resulting jsonnet value.
+  parseJson:: std.native("parseJson"),
+
+  // parseYaml(data): parse the `data` string as a YAML stream and
+  // returns an *array* of the resulting jsonnet values.  (A YAML stream
+  // contains zero or more YAML documents, separated by "---".)
+  parseYaml:: std.native("parseYaml"),
+
+  // manifestJson(value, indent): convert the jsonnet object `value`
+  // to a string encoded as "pretty" (multi-line) JSON, with each
+  // nesting level indented by `indent` spaces.
+  manifestJson(value, indent=4):: (
+    local f = std.native("manifestJsonFromJson");
+    f(std.toString(value), indent)
+  ),
+
+  // manifestYaml(value): convert the jsonnet object `value` to a
+  // string encoded as a single YAML document.
+  manifestYaml(value):: (
+    local f = std.native("manifestYamlFromJson");
+    f(std.toString(value))
+  ),
+
+  // resolveImage(image): convert the docker image string `image` to a
+  // more specific string.  This is usually used to convert a
+  // tag-based image to a digest-based image.  The exact behaviour is
+  // controlled by the --resolve-images command line option.
+  resolveImage:: std.native("resolveImage"),
+
+  // escapeStringRegex(s): Quote the regular expression metacharacters
+  // found in `s`.  The result is a regular expression that matches the
+  // original literal text.
+  escapeStringRegex:: std.native("escapeStringRegex"),
+
+  // regexMatch(regex, string): Returns true if `regex` is found in
+  // `string`. Regex is as implemented in golang regexp package
+  // (python-ish).
+  regexMatch:: std.native("regexMatch"),
+
+  // regexSubst(regex, src, repl): Return the string `src` with all
+  // regex matches replaced by `repl`.  Within `repl`, `$` signs are
+  // interpreted as in golang regexp.Expand, so for instance `$1`
+  // represents the text of the first submatch.
+  regexSubst:: std.native("regexSubst"),
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/linter"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// lint checks the Jsonnet entrypoint at path with jsonnet-lint, given the same
+// libraries and variables as the render, and jsonnetfmt, and records the
+// findings on the LintedCondition of the Konfiguration. When the formatting of
+// the imports is checked, jsonnetfmt also checks the files of the source
+// imported by the entrypoint and the unformatted ones are listed in the
+// status. New findings are also reported as a warning event. An error is only
+// returned for findings if linting is enforced. The condition is removed if
+// linting is not configured.
+func (r *KonfigurationReconciler) lint(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string) error {
+	konfig.Status.UnformattedFiles = nil
+	if !konfig.LintEnabled() {
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.LintedCondition)
+		return nil
+	}
+	// Plain manifests are not evaluated, and remote entrypoints cannot be
+	// linted along with their imports.
+	if isPlainManifest(path) || httpPathRegex.MatchString(path) {
+		log.Info("Skipping lint of entrypoint that is not a local Jsonnet file", "Path", path)
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.LintedCondition)
+		return nil
+	}
+
+	libDirs, removeLibs, err := r.jsonnetLibDirs(ctx, konfig)
+	if err != nil {
+		return withReason(appsv1.EvaluationFailedReason, err)
+	}
+	defer removeLibs()
+	// The linter resolves imports and variables with the same flags as the
+	// render.
+	vm, err := newJsonnetVM(konfig.ToShowArgs(libDirs, path))
+	if err != nil {
+		return withReason(appsv1.EvaluationFailedReason, err)
+	}
+
+	var findings []string
+	out, err := lintJsonnet(log, vm, path)
+	if err != nil {
+		return withReason(appsv1.EvaluationFailedReason, err)
+	}
+	if out != "" {
+		findings = append(findings, out)
+	}
+
+	if konfig.LintFormatImports() {
+		root := r.sourceRoot(path)
+		for _, file := range jsonnetImports(path, libDirs, root) {
+			out, err := runLinter(ctx, log, konfig, []string{"/jsonnetfmt", "--test", file})
+			if err != nil {
+				return withReason(appsv1.EvaluationFailedReason, err)
+			}
+			if out != "" {
+				rel, _ := filepath.Rel(root, file)
+				konfig.Status.UnformattedFiles = append(konfig.Status.UnformattedFiles, rel)
+			}
+		}
+		if len(konfig.Status.UnformattedFiles) != 0 {
+			findings = append(findings, fmt.Sprintf("files are not formatted: %s", strings.Join(konfig.Status.UnformattedFiles, ", ")))
+		}
+	} else {
+		out, err := runLinter(ctx, log, konfig, []string{"/jsonnetfmt", "--test", path})
+		if err != nil {
+			return withReason(appsv1.EvaluationFailedReason, err)
+		}
+		if out != "" {
+			findings = append(findings, out)
+		}
+	}
+
+	if len(findings) == 0 {
+		meta.SetResourceCondition(konfig, appsv1.LintedCondition, metav1.ConditionTrue, appsv1.LintPassedReason,
+			"no lint findings")
+		return nil
+	}
+
+	msg := strings.Join(findings, "\n")
+	if cond := apimeta.FindStatusCondition(konfig.Status.Conditions, appsv1.LintedCondition); cond == nil || cond.Message != msg {
+		r.recorder.Event(konfig, corev1.EventTypeWarning, appsv1.LintFindingsReason, msg)
+	}
+	meta.SetResourceCondition(konfig, appsv1.LintedCondition, metav1.ConditionFalse, appsv1.LintFindingsReason, msg)
+	if konfig.LintEnforced() {
+		return withReason(appsv1.LintFailedReason, fmt.Errorf("lint findings: %s", msg))
+	}
+	log.Info("Lint findings are not enforced, continuing", "Findings", msg)
+	return nil
+}
+
+// lintJsonnet checks the Jsonnet file at path with the linter jsonnet-lint is
+// built on, resolving its imports with the given VM, and returns its findings.
+func lintJsonnet(log logr.Logger, vm *jsonnet.VM, path string) (string, error) {
+	code, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	log.Info("Linting manifests", "Path", path)
+	var outBuf bytes.Buffer
+	if !linter.LintSnippet(vm, &outBuf, []linter.Snippet{{FileName: path, Code: string(code)}}) {
+		return "", nil
+	}
+	out := sanitizeStderr(&outBuf)
+	if out == "" {
+		out = "jsonnet-lint reported problems"
+	}
+	return out, nil
+}
+
+// runLinter runs the given linter command and returns its findings. Linters
+// report findings by exiting non-zero, so only a failure to run the command
+// is returned as an error.
+func runLinter(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, args []string) (string, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(cmdCtx, args[0], args[1:]...)
+	var outBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &outBuf
+
+	log.Info("Linting manifests", "Command", cmd.String())
+	err := cmd.Run()
+	if err == nil {
+		return "", nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || cmdCtx.Err() != nil {
+		return "", fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	out := sanitizeStderr(&outBuf)
+	if out == "" {
+		out = fmt.Sprintf("%s exited with status %d", args[0], exitErr.ProcessState.ExitCode())
+	}
+	return out, nil
+}
+
+// jsonnetImportRegex matches the imports of Jsonnet code. importstr and
+// importbin are left out, since they do not import Jsonnet.
+var jsonnetImportRegex = regexp.MustCompile(`\bimport\s*(?:'([^']*)'|"([^"]*)")`)
+
+// jsonnetImports returns the entrypoint at path and the Jsonnet files it
+// imports, directly or not, that are part of the source at root. Imports are
+// resolved like Jsonnet does, relative to the importing file first and then to
+// the library directories. Files outside of root, such as libraries, and JSON
+// files are not returned nor followed. Imports are found by scanning the
+// files, so imports in comments and strings are followed too.
+func jsonnetImports(path string, libDirs []string, root string) []string {
+	entrypoint, err := filepath.Abs(path)
+	if err != nil {
+		return []string{path}
+	}
+	files := []string{entrypoint}
+	seen := map[string]bool{entrypoint: true}
+	for i := 0; i < len(files); i++ {
+		data, err := ioutil.ReadFile(files[i])
+		if err != nil {
+			continue
+		}
+		for _, match := range jsonnetImportRegex.FindAllStringSubmatch(string(data), -1) {
+			imported := match[1] + match[2]
+			if filepath.Ext(imported) == ".json" {
+				continue
+			}
+			file := resolveImport(filepath.Dir(files[i]), imported, libDirs)
+			if file == "" || seen[file] {
+				continue
+			}
+			seen[file] = true
+			if rel, err := filepath.Rel(root, file); err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// resolveImport returns the path of the file imported from a file in dir, or
+// an empty string if it does not exist.
+func resolveImport(dir, imported string, libDirs []string) string {
+	candidates := []string{imported}
+	if !filepath.IsAbs(imported) {
+		candidates = []string{filepath.Join(dir, imported)}
+		for _, libDir := range libDirs {
+			candidates = append(candidates, filepath.Join(libDir, imported))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs
+			}
+		}
+	}
+	return ""
+}
+
+// sourceRoot returns the root of the extracted artifact holding the
+// entrypoint at path, or the directory of the entrypoint if it is not part of
+// an extracted artifact.
+func (r *KonfigurationReconciler) sourceRoot(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Dir(path)
+	}
+	artifacts, err := filepath.Abs(r.artifacts.root)
+	if err != nil {
+		return filepath.Dir(abs)
+	}
+	rel, err := filepath.Rel(artifacts, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Dir(abs)
+	}
+	return filepath.Join(artifacts, strings.SplitN(rel, string(filepath.Separator), 2)[0])
+}
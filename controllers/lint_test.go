@@ -0,0 +1,82 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestLintJsonnet(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		args         []string
+		wantFindings bool
+	}{
+		{
+			name:   "clean",
+			source: `local lib = import "lib.libsonnet"; lib.configMap("app")`,
+		},
+		{
+			name:         "unused variable",
+			source:       `local unused = 1; []`,
+			wantFindings: true,
+		},
+		{
+			name:   "library path from the kubecfg arguments",
+			source: `local extra = import "extra.libsonnet"; extra`,
+			args:   []string{"--jpath", "extra"},
+		},
+		{
+			name:         "library path missing from the kubecfg arguments",
+			source:       `local extra = import "extra.libsonnet"; extra`,
+			wantFindings: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"main.jsonnet":          tt.source,
+				"lib/lib.libsonnet":     `{ configMap(name):: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: name } } }`,
+				"extra/extra.libsonnet": `[]`,
+			})
+			konfig := testKonfiguration()
+			for i, arg := range tt.args {
+				if i > 0 && tt.args[i-1] == "--jpath" {
+					arg = filepath.Join(dir, arg)
+				}
+				konfig.Spec.KubecfgArgs = append(konfig.Spec.KubecfgArgs, arg)
+			}
+			path := filepath.Join(dir, "main.jsonnet")
+			vm, err := newJsonnetVM(konfig.ToShowArgs([]string{filepath.Join(dir, "lib")}, path))
+			if err != nil {
+				t.Fatalf("newJsonnetVM() error = %v", err)
+			}
+			out, err := lintJsonnet(logr.Discard(), vm, path)
+			if err != nil {
+				t.Fatalf("lintJsonnet() error = %v", err)
+			}
+			if got := out != ""; got != tt.wantFindings {
+				t.Errorf("findings = %q, wantFindings %v", out, tt.wantFindings)
+			}
+		})
+	}
+}
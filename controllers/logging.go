@@ -0,0 +1,53 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// logLevelDebug is the value of the log level annotation enabling debug logs.
+const logLevelDebug = "debug"
+
+// newReconcileID returns a random ID correlating the logs of a reconciliation.
+func newReconcileID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// loggerFor returns the logger for the reconciliation of the given
+// Konfiguration. If its log level annotation asks for debug logs, the debug
+// logger of the controller is used in place of log, with the same correlation
+// ID.
+func (r *KonfigurationReconciler) loggerFor(log logr.Logger, konfig *appsv1.Konfiguration, reconcileID string) logr.Logger {
+	if r.debugLog == nil || konfig.GetAnnotations()[appsv1.LogLevelAnnotation] != logLevelDebug {
+		return log
+	}
+	return r.debugLog.WithName(controllerName).WithValues(
+		"konfiguration", client.ObjectKeyFromObject(konfig),
+		"reconcileID", reconcileID,
+	)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// manifestsPartSize is the maximum size of the compressed manifests stored in
+// a single Secret, leaving room for its metadata below the 1MiB limit.
+const manifestsPartSize = 900 * 1024
+
+// manifestsPartName returns the name of the Secret holding the part with the
+// given index of the stored manifests of the Konfiguration.
+func manifestsPartName(konfig *appsv1.Konfiguration, part int) string {
+	return fmt.Sprintf("%s-manifests-%d", konfig.GetName(), part)
+}
+
+// writeManifestStore stores the gzip-compressed manifests in a chain of Secrets
+// owned by the Konfiguration, since they may hold Secrets themselves, removes
+// any parts left over from larger manifests and records a reference to the
+// first part in the status. The first part, recording the checksum of the
+// manifests, is written last, so nothing is written while the checksum is
+// unchanged. Like the inventory, the Secrets are accessed directly rather than
+// through the cache.
+func (r *KonfigurationReconciler) writeManifestStore(ctx context.Context, konfig *appsv1.Konfiguration, manifests *renderedManifests) error {
+	secrets := r.clientset.CoreV1().Secrets(konfig.GetNamespace())
+	head, err := secrets.Get(ctx, manifestsPartName(konfig, 0), metav1.GetOptions{})
+	if err == nil && metav1.IsControlledBy(head, konfig) && head.GetAnnotations()[appsv1.ManifestsChecksumAnnotation] == manifests.checksum {
+		konfig.Status.ManifestsRef = &corev1.LocalObjectReference{Name: head.GetName()}
+		return nil
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	f, err := os.Open(manifests.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, f); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	data := buf.Bytes()
+	parts := (len(data) + manifestsPartSize - 1) / manifestsPartSize
+	if parts == 0 {
+		parts = 1
+	}
+	for part := parts - 1; part >= 0; part-- {
+		end := (part + 1) * manifestsPartSize
+		if end > len(data) {
+			end = len(data)
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        manifestsPartName(konfig, part),
+				Namespace:   konfig.GetNamespace(),
+				Labels:      r.ownership.labels(client.ObjectKeyFromObject(konfig)),
+				Annotations: map[string]string{},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{appsv1.ManifestsKey: data[part*manifestsPartSize : end]},
+		}
+		if part < parts-1 {
+			secret.Annotations[appsv1.ManifestsNextAnnotation] = manifestsPartName(konfig, part+1)
+		}
+		if part == 0 {
+			// Leftover parts are not part of the chain, but are removed
+			// before it is marked as complete.
+			if err := r.deleteManifestsParts(ctx, konfig, parts); err != nil {
+				return err
+			}
+			secret.Annotations[appsv1.ManifestsChecksumAnnotation] = manifests.checksum
+		}
+		if err := r.writeManifestsPart(ctx, konfig, secret); err != nil {
+			return err
+		}
+	}
+
+	konfig.Status.ManifestsRef = &corev1.LocalObjectReference{Name: manifestsPartName(konfig, 0)}
+	return nil
+}
+
+// readManifestStore returns the stored manifests of the Konfiguration, or nil
+// if none are stored or the chain of Secrets is incomplete, e.g. while it is
+// written.
+func (r *KonfigurationReconciler) readManifestStore(ctx context.Context, konfig *appsv1.Konfiguration) ([]byte, error) {
+	secrets := r.clientset.CoreV1().Secrets(konfig.GetNamespace())
+	var data []byte
+	for name := manifestsPartName(konfig, 0); name != ""; {
+		secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !metav1.IsControlledBy(secret, konfig) {
+			return nil, nil
+		}
+		if data == nil && secret.GetAnnotations()[appsv1.ManifestsChecksumAnnotation] == "" {
+			return nil, nil
+		}
+		data = append(data, secret.Data[appsv1.ManifestsKey]...)
+		name = secret.GetAnnotations()[appsv1.ManifestsNextAnnotation]
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// writeManifestsPart creates or replaces a Secret of the stored manifests. An
+// existing Secret not controlled by the Konfiguration is never overwritten.
+func (r *KonfigurationReconciler) writeManifestsPart(ctx context.Context, konfig *appsv1.Konfiguration, secret *corev1.Secret) error {
+	if err := controllerutil.SetControllerReference(konfig, secret, r.Scheme); err != nil {
+		return err
+	}
+	secrets := r.clientset.CoreV1().Secrets(secret.GetNamespace())
+	existing, err := secrets.Get(ctx, secret.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	case err == nil && !metav1.IsControlledBy(existing, konfig):
+		err = fmt.Errorf("Secret is not controlled by the Konfiguration")
+	case err == nil:
+		if reflect.DeepEqual(existing.GetAnnotations(), secret.GetAnnotations()) && reflect.DeepEqual(existing.Data, secret.Data) {
+			return nil
+		}
+		existing.SetLabels(secret.GetLabels())
+		existing.SetAnnotations(secret.GetAnnotations())
+		existing.Data = secret.Data
+		_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write manifests '%s/%s': %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	return nil
+}
+
+// deleteManifestsParts removes the Secrets of the stored manifests from the
+// part with the given index on, stopping at the first missing part or the
+// first not controlled by the Konfiguration.
+func (r *KonfigurationReconciler) deleteManifestsParts(ctx context.Context, konfig *appsv1.Konfiguration, from int) error {
+	secrets := r.clientset.CoreV1().Secrets(konfig.GetNamespace())
+	for part := from; ; part++ {
+		secret, err := secrets.Get(ctx, manifestsPartName(konfig, part), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(secret, konfig) {
+			return nil
+		}
+		err = secrets.Delete(ctx, secret.GetName(), metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &secret.UID}})
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+}
+
+// deleteManifestStore removes the stored manifests of the Konfiguration and the
+// reference to them.
+func (r *KonfigurationReconciler) deleteManifestStore(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	if err := r.deleteManifestsParts(ctx, konfig, 0); err != nil {
+		return err
+	}
+	konfig.Status.ManifestsRef = nil
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// newManifestStoreReconciler returns a reconciler whose clientset holds the
+// given objects, and a Konfiguration it may own objects for.
+func newManifestStoreReconciler(t *testing.T, objs ...runtime.Object) (*KonfigurationReconciler, *kubefake.Clientset, *appsv1.Konfiguration) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	clientset := kubefake.NewSimpleClientset(objs...)
+	konfig := testKonfiguration()
+	konfig.SetUID("uid")
+	return &KonfigurationReconciler{Scheme: scheme, clientset: clientset}, clientset, konfig
+}
+
+func testManifests(t *testing.T, checksum string) *renderedManifests {
+	f, err := ioutil.TempFile("", "manifests-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("apiVersion: v1\nkind: Secret\n"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	return &renderedManifests{path: f.Name(), checksum: checksum}
+}
+
+func TestWriteManifestStore(t *testing.T) {
+	r, clientset, konfig := newManifestStoreReconciler(t)
+	if err := r.writeManifestStore(context.TODO(), konfig, testManifests(t, "a")); err != nil {
+		t.Fatalf("writeManifestStore() error = %v", err)
+	}
+	secret, err := clientset.CoreV1().Secrets("team").Get(context.TODO(), "app-manifests-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data[appsv1.ManifestsKey]) == 0 || secret.GetAnnotations()[appsv1.ManifestsChecksumAnnotation] != "a" {
+		t.Errorf("unexpected Secret %v", secret)
+	}
+	if ref := konfig.Status.ManifestsRef; ref == nil || ref.Name != "app-manifests-0" {
+		t.Errorf("ManifestsRef = %v", ref)
+	}
+
+	clientset.ClearActions()
+	if err := r.writeManifestStore(context.TODO(), konfig, testManifests(t, "a")); err != nil {
+		t.Fatalf("writeManifestStore() error = %v", err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("unexpected %s of unchanged manifests", action.GetVerb())
+		}
+	}
+}
+
+func TestWriteManifestStoreNotControlled(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "app-manifests-0"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	r, clientset, konfig := newManifestStoreReconciler(t, existing)
+	if err := r.writeManifestStore(context.TODO(), konfig, testManifests(t, "a")); err == nil {
+		t.Fatal("writeManifestStore() error = nil, want error")
+	}
+	secret, err := clientset.CoreV1().Secrets("team").Get(context.TODO(), "app-manifests-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["password"]) != "secret" {
+		t.Errorf("Secret not controlled by the Konfiguration was overwritten")
+	}
+	if err := r.deleteManifestStore(context.TODO(), konfig); err != nil {
+		t.Fatalf("deleteManifestStore() error = %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("team").Get(context.TODO(), "app-manifests-0", metav1.GetOptions{}); err != nil {
+		t.Errorf("Secret not controlled by the Konfiguration was deleted: %v", err)
+	}
+}
+
+func TestReadManifestStore(t *testing.T) {
+	r, _, konfig := newManifestStoreReconciler(t)
+	if data, err := r.readManifestStore(context.TODO(), konfig); err != nil || data != nil {
+		t.Fatalf("readManifestStore() = %q, %v, want nothing stored", data, err)
+	}
+	if err := r.writeManifestStore(context.TODO(), konfig, testManifests(t, "a")); err != nil {
+		t.Fatalf("writeManifestStore() error = %v", err)
+	}
+	data, err := r.readManifestStore(context.TODO(), konfig)
+	if err != nil {
+		t.Fatalf("readManifestStore() error = %v", err)
+	}
+	if want := "apiVersion: v1\nkind: Secret\n"; string(data) != want {
+		t.Errorf("readManifestStore() = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// The metrics below summarize the fleet of Konfigurations rather than each of
+// them, so their cardinality does not grow with the number of Konfigurations.
+// Reconciles per minute and queue depth are covered by the
+// controller_runtime_reconcile_total and workqueue_depth metrics of
+// controller-runtime.
+var (
+	// runsTotal counts the runs of the Konfigurations by result and, for
+	// failed runs, the reason they failed with.
+	runsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubecfg_konfiguration_runs_total",
+		Help: "Total number of Konfiguration runs by result and failure reason.",
+	}, []string{"result", "reason"})
+
+	// stageDuration observes the duration of the stages of the runs of the
+	// Konfigurations.
+	stageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kubecfg_konfiguration_stage_duration_seconds",
+		Help:    "Duration of the stages of Konfiguration runs in seconds.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"stage"})
+
+	// runDuration observes the duration of the runs of the Konfigurations.
+	runDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kubecfg_konfiguration_run_duration_seconds",
+		Help:    "Duration of Konfiguration runs in seconds by result.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"result"})
+
+	konfigurationsDesc = prometheus.NewDesc(
+		"kubecfg_konfigurations",
+		"Number of Konfigurations by readiness and whether they are suspended.",
+		[]string{"ready", "suspended"}, nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(runsTotal, stageDuration, runDuration)
+}
+
+// openMetricsPath is the path of the metrics endpoint serving the OpenMetrics
+// format, which unlike the default endpoint exposes exemplars.
+const openMetricsPath = "/openmetrics"
+
+// openMetricsHandler serves the metrics of the controller in the OpenMetrics
+// format.
+func openMetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling:     promhttp.HTTPErrorOnError,
+		EnableOpenMetrics: true,
+	})
+}
+
+// recordRunMetrics records the result and durations of the given run of the
+// Konfiguration. The observations are annotated with exemplars naming the
+// commit that was reconciled, so a spike can be traced to the commit causing
+// it without adding a label per revision.
+func recordRunMetrics(konfig *appsv1.Konfiguration, summary *appsv1.RunSummary, duration time.Duration) {
+	exemplar := runExemplar(konfig, summary.Revision)
+	addWithExemplar(runsTotal.WithLabelValues(summary.Result, summary.Reason), exemplar)
+	observeWithExemplar(runDuration.WithLabelValues(summary.Result), duration.Seconds(), exemplar)
+	for _, stage := range summary.Stages {
+		observeWithExemplar(stageDuration.WithLabelValues(stage.Name), stage.Duration.Seconds(), exemplar)
+	}
+}
+
+// runExemplar returns the exemplar labels of a run of the Konfiguration at
+// the given revision: the SHA of the commit, if the revision names one, and
+// the namespace and name of the Konfiguration if they fit within the size
+// limit of exemplars.
+func runExemplar(konfig *appsv1.Konfiguration, revision string) prometheus.Labels {
+	sha := commitSHA(revision)
+	if sha == "" {
+		return nil
+	}
+	labels := prometheus.Labels{"commit": sha}
+	name := konfig.GetNamespace() + "/" + konfig.GetName()
+	if utf8.RuneCountInString("commit"+sha+"konfiguration"+name) <= prometheus.ExemplarMaxRunes {
+		labels["konfiguration"] = name
+	}
+	return labels
+}
+
+// addWithExemplar increments the counter, with the given exemplar if any.
+func addWithExemplar(counter prometheus.Counter, exemplar prometheus.Labels) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		adder.AddWithExemplar(1, exemplar)
+		return
+	}
+	counter.Inc()
+}
+
+// observeWithExemplar records the observation, with the given exemplar if
+// any.
+func observeWithExemplar(observer prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
+}
+
+// fleetCollector counts the Konfigurations in the cache of the manager by
+// readiness when metrics are scraped.
+type fleetCollector struct {
+	reader client.Reader
+}
+
+// Describe implements prometheus.Collector.
+func (c *fleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- konfigurationsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *fleetCollector) Collect(ch chan<- prometheus.Metric) {
+	var list appsv1.KonfigurationList
+	if err := c.reader.List(context.Background(), &list); err != nil {
+		ch <- prometheus.NewInvalidMetric(konfigurationsDesc, err)
+		return
+	}
+	type key struct{ ready, suspended string }
+	counts := make(map[key]int)
+	for _, konfig := range list.Items {
+		ready := string(metav1.ConditionUnknown)
+		if cond := apimeta.FindStatusCondition(konfig.Status.Conditions, meta.ReadyCondition); cond != nil {
+			ready = string(cond.Status)
+		}
+		suspended := "false"
+		if konfig.IsSuspended() {
+			suspended = "true"
+		}
+		counts[key{ready, suspended}]++
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(konfigurationsDesc, prometheus.GaugeValue, float64(n), k.ready, k.suspended)
+	}
+}
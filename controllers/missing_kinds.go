@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// splitMissingKinds returns the kinds of the objects in the manifests at path
+// that are not registered with the API server. If there are any, the other
+// objects are written to a new file, whose path is returned as well.
+func (r *KonfigurationReconciler) splitMissingKinds(konfig *appsv1.Konfiguration, path string) (string, []schema.GroupVersionKind, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	objs, err := decodeManifests(f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var ready []*unstructured.Unstructured
+	missing := make(map[schema.GroupVersionKind]struct{})
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		if _, ok := missing[gvk]; ok {
+			continue
+		}
+		if _, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); apimeta.IsNoMatchError(err) {
+			missing[gvk] = struct{}{}
+			continue
+		} else if err != nil {
+			return "", nil, err
+		}
+		ready = append(ready, obj)
+	}
+	if len(missing) == 0 {
+		return "", nil, nil
+	}
+
+	manifests, err := r.writeManifests(konfig, ready)
+	if err != nil {
+		return "", nil, err
+	}
+	kinds := make([]schema.GroupVersionKind, 0, len(missing))
+	for gvk := range missing {
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+	return manifests.path, kinds, nil
+}
+
+// waitForKinds waits for the given kinds to be registered with the API server,
+// e.g. once the CustomResourceDefinitions applied along with them are
+// established, until the timeout of the Konfiguration expires.
+func (r *KonfigurationReconciler) waitForKinds(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, kinds []schema.GroupVersionKind) error {
+	pending := append([]schema.GroupVersionKind{}, kinds...)
+	err := wait.PollImmediate(verifyPollInterval, konfig.GetTimeout(), func() (bool, error) {
+		var still []schema.GroupVersionKind
+		for _, gvk := range pending {
+			_, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+			if apimeta.IsNoMatchError(err) {
+				still = append(still, gvk)
+				continue
+			} else if err != nil {
+				return false, err
+			}
+		}
+		pending = still
+		return len(pending) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		missing := make([]string, 0, len(pending))
+		for _, gvk := range pending {
+			missing = append(missing, gvk.String())
+		}
+		return fmt.Errorf("kinds not registered after %s: %s", konfig.GetTimeout(), strings.Join(missing, ", "))
+	} else if err != nil {
+		return err
+	}
+	log.Info("Deferred kinds registered", "Count", len(kinds))
+	return nil
+}
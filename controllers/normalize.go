@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// fieldDefault is a field the API server sets to value when it is omitted.
+type fieldDefault struct {
+	path  []string
+	value interface{}
+}
+
+// podSpecDefaults are the server defaults of pod specs.
+var podSpecDefaults = []fieldDefault{
+	{[]string{"restartPolicy"}, "Always"},
+	{[]string{"dnsPolicy"}, "ClusterFirst"},
+	{[]string{"schedulerName"}, "default-scheduler"},
+	{[]string{"terminationGracePeriodSeconds"}, int64(30)},
+}
+
+// containerDefaults are the server defaults of the containers of pod specs.
+var containerDefaults = []fieldDefault{
+	{[]string{"terminationMessagePath"}, "/dev/termination-log"},
+	{[]string{"terminationMessagePolicy"}, "File"},
+}
+
+// kindDefaults are the server defaults of the specs of kinds, other than
+// those of their pod specs.
+var kindDefaults = map[string][]fieldDefault{
+	"Deployment": {
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "progressDeadlineSeconds"}, int64(600)},
+	},
+	"StatefulSet": {
+		{[]string{"spec", "podManagementPolicy"}, "OrderedReady"},
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+	},
+	"DaemonSet": {
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+	},
+	"Service": {
+		{[]string{"spec", "sessionAffinity"}, "None"},
+		{[]string{"spec", "type"}, "ClusterIP"},
+	},
+}
+
+// normalize strips null values and fields set to well-known server defaults
+// from the rendered objects, so they match the objects in the cluster. Either
+// can be kept by setting `empty` or `defaults` to false in the config.
+func normalize(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error) {
+	empty, err := boolConfig(config, "empty", true)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := boolConfig(config, "defaults", true)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if defaults {
+			stripServerDefaults(obj)
+		}
+		if empty {
+			stripEmptyFields(obj.Object)
+		}
+	}
+	return objs, nil
+}
+
+// boolConfig returns the boolean value of key in the config of a transformer,
+// or def if it is not set.
+func boolConfig(config map[string]string, key string, def bool) (bool, error) {
+	value, ok := config[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for '%s': %q", key, value)
+	}
+	return b, nil
+}
+
+// stripServerDefaults removes the fields of the object that are set to their
+// server defaults.
+func stripServerDefaults(obj *unstructured.Unstructured) {
+	stripDefaults(obj.Object, kindDefaults[obj.GetKind()])
+	if obj.GetKind() == "Service" {
+		stripPortProtocols(obj.Object, "spec", "ports")
+	}
+
+	path, ok := podSpecPaths[obj.GetKind()]
+	if !ok {
+		return
+	}
+	podSpec, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, path...)
+	if !ok {
+		return
+	}
+	if podSpec, ok := podSpec.(map[string]interface{}); ok {
+		stripDefaults(podSpec, podSpecDefaults)
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _ := podSpec[field].([]interface{})
+			for _, container := range containers {
+				if container, ok := container.(map[string]interface{}); ok {
+					stripDefaults(container, containerDefaults)
+					stripPortProtocols(container, "ports")
+				}
+			}
+		}
+	}
+}
+
+// stripDefaults removes the given defaults from obj where they are set to
+// their default value.
+func stripDefaults(obj map[string]interface{}, defaults []fieldDefault) {
+	for _, def := range defaults {
+		value, ok, _ := unstructured.NestedFieldNoCopy(obj, def.path...)
+		if ok && fmt.Sprint(value) == fmt.Sprint(def.value) {
+			unstructured.RemoveNestedField(obj, def.path...)
+		}
+	}
+}
+
+// stripPortProtocols removes the TCP protocol from the ports at the given
+// path of obj.
+func stripPortProtocols(obj map[string]interface{}, path ...string) {
+	ports, _, _ := unstructured.NestedFieldNoCopy(obj, path...)
+	list, _ := ports.([]interface{})
+	for _, port := range list {
+		if port, ok := port.(map[string]interface{}); ok && port["protocol"] == "TCP" {
+			delete(port, "protocol")
+		}
+	}
+}
+
+// stripEmptyFields recursively removes the null values of the given map.
+// Empty maps, lists and strings are kept, since some fields such as emptyDir
+// volumes or empty pod selectors are meaningful without a value.
+func stripEmptyFields(obj map[string]interface{}) {
+	for key, value := range obj {
+		switch value := value.(type) {
+		case nil:
+			delete(obj, key)
+		case []interface{}:
+			for _, item := range value {
+				if item, ok := item.(map[string]interface{}); ok {
+					stripEmptyFields(item)
+				}
+			}
+		case map[string]interface{}:
+			stripEmptyFields(value)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripEmptyFields(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "null value",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"replicas": nil, "paused": false}},
+			want: map[string]interface{}{"spec": map[string]interface{}{"paused": false}},
+		},
+		{
+			name: "empty map",
+			obj:  map[string]interface{}{"volume": map[string]interface{}{"emptyDir": map[string]interface{}{}}},
+			want: map[string]interface{}{"volume": map[string]interface{}{"emptyDir": map[string]interface{}{}}},
+		},
+		{
+			name: "empty string",
+			obj:  map[string]interface{}{"metadata": map[string]interface{}{"namespace": ""}},
+			want: map[string]interface{}{"metadata": map[string]interface{}{"namespace": ""}},
+		},
+		{
+			name: "empty list",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{}}},
+			want: map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{}}},
+		},
+		{
+			name: "null value in a list of maps",
+			obj:  map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "app", "args": nil}}},
+			want: map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "app"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripEmptyFields(tt.obj)
+			if !reflect.DeepEqual(tt.obj, tt.want) {
+				t.Errorf("stripEmptyFields() = %v, want %v", tt.obj, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// inventoryHealth reads back the objects of the given inventory entries and
+// returns their health, in the same order. Objects that cannot be read are
+// reported as Unknown rather than failing the reconciliation.
+func inventoryHealth(ctx context.Context, reader client.Reader, entries []appsv1.InventoryEntry) []appsv1.ObjectHealth {
+	health := make([]appsv1.ObjectHealth, 0, len(entries))
+	for _, entry := range entries {
+		obj, err := getEntry(ctx, reader, entry)
+		switch {
+		case err != nil:
+			health = append(health, appsv1.ObjectHealth{Status: appsv1.HealthUnknown, Message: err.Error()})
+		case obj == nil:
+			health = append(health, appsv1.ObjectHealth{Status: appsv1.HealthNotFound, Message: "does not exist"})
+		default:
+			health = append(health, objectHealth(obj))
+		}
+	}
+	return health
+}
+
+// objectCondition is the part of a status condition the health of an object
+// is computed from.
+type objectCondition struct {
+	status  string
+	reason  string
+	message string
+}
+
+// objectHealth computes the health of an object from its status, along the
+// lines of the kstatus library: the status must be up to date with the spec,
+// the workloads the built-in kinds describe must be rolled out, and any
+// conditions following the Ready, Reconciling and Stalled conventions must
+// report the object as reconciled.
+func objectHealth(obj *unstructured.Unstructured) appsv1.ObjectHealth {
+	inProgress := func(format string, args ...interface{}) appsv1.ObjectHealth {
+		return appsv1.ObjectHealth{Status: appsv1.HealthInProgress, Message: fmt.Sprintf(format, args...)}
+	}
+	failed := func(format string, args ...interface{}) appsv1.ObjectHealth {
+		return appsv1.ObjectHealth{Status: appsv1.HealthFailed, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if obj.GetDeletionTimestamp() != nil {
+		return inProgress("is being deleted")
+	}
+	if observed, ok, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); ok && observed < obj.GetGeneration() {
+		return inProgress("status is not up to date")
+	}
+	conditions := objectConditions(obj)
+	if c, ok := conditions["Stalled"]; ok && c.status == "True" {
+		return failed("%s: %s", c.reason, c.message)
+	}
+	if c, ok := conditions["Reconciling"]; ok && c.status == "True" {
+		return inProgress("%s: %s", c.reason, c.message)
+	}
+
+	gv, _ := schema.ParseGroupVersion(obj.GetAPIVersion())
+	status := func(fields ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, append([]string{"status"}, fields...)...)
+		return v
+	}
+	replicas := func() int64 {
+		v, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !ok {
+			return 1
+		}
+		return v
+	}
+	switch (schema.GroupKind{Group: gv.Group, Kind: obj.GetKind()}) {
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		if c := conditions["Progressing"]; c.status == "False" && c.reason == "ProgressDeadlineExceeded" {
+			return failed("%s", c.message)
+		}
+		want := replicas()
+		if updated := status("updatedReplicas"); updated < want {
+			return inProgress("%d of %d replicas updated", updated, want)
+		}
+		if available := status("availableReplicas"); available < want {
+			return inProgress("%d of %d replicas available", available, want)
+		}
+	case schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		want := replicas()
+		if ready := status("readyReplicas"); ready < want {
+			return inProgress("%d of %d replicas ready", ready, want)
+		}
+		current, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		update, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		if current != update {
+			return inProgress("revision %s is rolling out", update)
+		}
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		want := status("desiredNumberScheduled")
+		if updated := status("updatedNumberScheduled"); updated < want {
+			return inProgress("%d of %d pods updated", updated, want)
+		}
+		if available := status("numberAvailable"); available < want {
+			return inProgress("%d of %d pods available", available, want)
+		}
+	case schema.GroupKind{Group: "batch", Kind: "Job"}:
+		if c := conditions["Failed"]; c.status == "True" {
+			return failed("%s: %s", c.reason, c.message)
+		}
+		if c := conditions["Complete"]; c.status != "True" {
+			return inProgress("has not completed")
+		}
+	case schema.GroupKind{Kind: "Pod"}:
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		switch phase {
+		case "Succeeded":
+			return appsv1.ObjectHealth{Status: appsv1.HealthCurrent}
+		case "Failed":
+			return failed("pod failed")
+		}
+	case schema.GroupKind{Kind: "PersistentVolumeClaim"}:
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Bound" {
+			return inProgress("is not bound")
+		}
+	}
+
+	if c, ok := conditions["Ready"]; ok && c.status != "True" {
+		return inProgress("not ready: %s", c.message)
+	}
+	return appsv1.ObjectHealth{Status: appsv1.HealthCurrent}
+}
+
+// objectConditions returns the status conditions of an object by type.
+func objectConditions(obj *unstructured.Unstructured) map[string]objectCondition {
+	conditions := make(map[string]objectCondition)
+	list, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range list {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typ, _, _ := unstructured.NestedString(c, "type")
+		status, _, _ := unstructured.NestedString(c, "status")
+		reason, _, _ := unstructured.NestedString(c, "reason")
+		message, _, _ := unstructured.NestedString(c, "message")
+		conditions[typ] = objectCondition{status: status, reason: reason, message: message}
+	}
+	return conditions
+}
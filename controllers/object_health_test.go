@@ -0,0 +1,117 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestObjectHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    string
+		status string
+	}{
+		{
+			name:   "ConfigMap",
+			obj:    `{"apiVersion": "v1", "kind": "ConfigMap"}`,
+			status: appsv1.HealthCurrent,
+		},
+		{
+			name:   "outdated status",
+			obj:    `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 2}, "status": {"observedGeneration": 1}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "Deployment rolling out",
+			obj:    `{"apiVersion": "apps/v1", "kind": "Deployment", "spec": {"replicas": 2}, "status": {"updatedReplicas": 1, "availableReplicas": 2}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "Deployment past its progress deadline",
+			obj:    `{"apiVersion": "apps/v1", "kind": "Deployment", "status": {"conditions": [{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"}]}}`,
+			status: appsv1.HealthFailed,
+		},
+		{
+			name:   "Deployment rolled out",
+			obj:    `{"apiVersion": "apps/v1", "kind": "Deployment", "spec": {"replicas": 2}, "status": {"updatedReplicas": 2, "availableReplicas": 2}}`,
+			status: appsv1.HealthCurrent,
+		},
+		{
+			name:   "StatefulSet updating",
+			obj:    `{"apiVersion": "apps/v1", "kind": "StatefulSet", "status": {"readyReplicas": 1, "currentRevision": "a", "updateRevision": "b"}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "DaemonSet without available pods",
+			obj:    `{"apiVersion": "apps/v1", "kind": "DaemonSet", "status": {"desiredNumberScheduled": 3, "updatedNumberScheduled": 3, "numberAvailable": 1}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "failed Job",
+			obj:    `{"apiVersion": "batch/v1", "kind": "Job", "status": {"conditions": [{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"}]}}`,
+			status: appsv1.HealthFailed,
+		},
+		{
+			name:   "complete Job",
+			obj:    `{"apiVersion": "batch/v1", "kind": "Job", "status": {"conditions": [{"type": "Complete", "status": "True"}]}}`,
+			status: appsv1.HealthCurrent,
+		},
+		{
+			name:   "pending PersistentVolumeClaim",
+			obj:    `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "status": {"phase": "Pending"}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "custom resource not ready",
+			obj:    `{"apiVersion": "example.com/v1", "kind": "Database", "status": {"conditions": [{"type": "Ready", "status": "False", "message": "provisioning"}]}}`,
+			status: appsv1.HealthInProgress,
+		},
+		{
+			name:   "stalled custom resource",
+			obj:    `{"apiVersion": "example.com/v1", "kind": "Database", "status": {"conditions": [{"type": "Stalled", "status": "True", "reason": "InvalidSpec"}]}}`,
+			status: appsv1.HealthFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON([]byte(tt.obj)); err != nil {
+				t.Fatal(err)
+			}
+			if got := objectHealth(obj); got.Status != tt.status {
+				t.Errorf("objectHealth() = %+v, want %s", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestInventoryHealth(t *testing.T) {
+	r := newTestReconciler(testObject("v1", "ConfigMap", "team", "app", ""))
+	health := inventoryHealth(context.TODO(), r.Client, []appsv1.InventoryEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "app"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "removed"},
+	})
+	if len(health) != 2 || health[0].Status != appsv1.HealthCurrent || health[1].Status != appsv1.HealthNotFound {
+		t.Errorf("inventoryHealth() = %+v", health)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// observeOnly returns true if the controller must not write to the cluster on
+// behalf of the Konfiguration, because it or the controller is in observe-only
+// mode.
+func (r *KonfigurationReconciler) observeOnly(konfig *appsv1.Konfiguration) bool {
+	return konfig.IsObserveOnly() || r.settings.get().observeOnly
+}
+
+// observe renders the manifests of the Konfiguration at the given revision,
+// compares them with the cluster and runs the health checks, without writing
+// to the cluster. Whether applying the manifests would change the cluster is
+// recorded on the DriftedCondition, and returned as a message for the Ready
+// condition. Pre-render hooks are not run, and the rendered manifests are not
+// kept for a later apply. The targets of the Konfiguration are each compared
+// with their cluster, without running the health checks.
+func (r *KonfigurationReconciler) observe(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path, revision string, summary *runSummary) (string, error) {
+	if konfig.HasTargets() {
+		return r.observeTargetClusters(ctx, reqLogger, konfig, path, revision, summary)
+	}
+
+	start := time.Now()
+	renderLog := reqLogger.WithValues("stage", "render")
+	if err := r.lint(ctx, renderLog, konfig, path); err != nil {
+		return "", err
+	}
+	manifests, err := r.prepareManifests(ctx, renderLog, konfig, path, revision)
+	if err != nil {
+		return "", withReason(appsv1.EvaluationFailedReason, err)
+	}
+	defer os.Remove(manifests.path)
+	konfig.Status.LastAttemptedChecksum = manifests.checksum
+	summary.stage("render", start)
+
+	start = time.Now()
+	changed, err := runKubecfgDiff(ctx, reqLogger.WithValues("stage", "diff"), konfig, manifests.path)
+	if err != nil {
+		return "", err
+	}
+	summary.stage("diff", start)
+	if changed {
+		meta.SetResourceCondition(konfig, appsv1.DriftedCondition, metav1.ConditionTrue, appsv1.ChangesRequiredReason,
+			fmt.Sprintf("Applying revision %s would change the cluster", revision))
+	} else {
+		meta.SetResourceCondition(konfig, appsv1.DriftedCondition, metav1.ConditionFalse, appsv1.InSyncReason,
+			fmt.Sprintf("The cluster matches revision %s", revision))
+	}
+
+	if len(konfig.GetHealthChecks()) != 0 {
+		start := time.Now()
+		if err := checkHealth(ctx, reqLogger.WithValues("stage", "health"), r.Client, konfig, manifests.inventory); err != nil {
+			return "", withReason(appsv1.HealthCheckFailedReason, err)
+		}
+		summary.stage("health", start)
+	}
+
+	if changed {
+		return fmt.Sprintf("Observed revision %s without applying it, applying it would change the cluster", revision), nil
+	}
+	return fmt.Sprintf("Observed revision %s without applying it, the cluster matches it", revision), nil
+}
+
+// observeTargetClusters compares the manifests rendered for each target of
+// the Konfiguration with the target clusters, recording whether applying them
+// would change any of them on the DriftedCondition.
+func (r *KonfigurationReconciler) observeTargetClusters(ctx context.Context, reqLogger logr.Logger, konfig *appsv1.Konfiguration, path, revision string, summary *runSummary) (string, error) {
+	start := time.Now()
+	changed, err := r.observeTargets(ctx, reqLogger, konfig, path, revision)
+	if err != nil {
+		return "", err
+	}
+	summary.stage("diff", start)
+	if len(changed) != 0 {
+		meta.SetResourceCondition(konfig, appsv1.DriftedCondition, metav1.ConditionTrue, appsv1.ChangesRequiredReason,
+			fmt.Sprintf("Applying revision %s would change the targets %s", revision, strings.Join(changed, ", ")))
+		return fmt.Sprintf("Observed revision %s without applying it, applying it would change the targets %s", revision, strings.Join(changed, ", ")), nil
+	}
+	meta.SetResourceCondition(konfig, appsv1.DriftedCondition, metav1.ConditionFalse, appsv1.InSyncReason,
+		fmt.Sprintf("The targets match revision %s", revision))
+	return fmt.Sprintf("Observed revision %s without applying it, the targets match it", revision), nil
+}
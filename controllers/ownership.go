@@ -0,0 +1,125 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// ownershipLabels are the labels tracking the objects the controller creates
+// on behalf of a Konfiguration, such as its inventory, hook Jobs and preview
+// namespaces. Objects are labeled under domain, and are also recognized when
+// labeled under one of the legacy domains, so labels can be migrated without
+// recreating the objects. Objects found with legacy labels are relabeled when
+// they are next updated.
+type ownershipLabels struct {
+	domain string
+	legacy []string
+}
+
+// newOwnershipLabels returns the ownership labels under the given domain, or
+// the default domain if it is empty, recognizing the given legacy domains.
+func newOwnershipLabels(domain string, legacy []string) (ownershipLabels, error) {
+	if domain == "" {
+		domain = appsv1.OwnershipLabelDomain
+	}
+	o := ownershipLabels{domain: domain}
+	for _, d := range append([]string{domain}, legacy...) {
+		if errs := validation.IsDNS1123Subdomain(d); len(errs) != 0 {
+			return o, fmt.Errorf("invalid ownership label domain '%s': %s", d, strings.Join(errs, ", "))
+		}
+		if d != domain {
+			o.legacy = append(o.legacy, d)
+		}
+	}
+	return o, nil
+}
+
+// nameLabel returns the label holding the name of the Konfiguration under
+// the given domain.
+func nameLabel(domain string) string {
+	return domain + "/" + appsv1.KonfigurationNameLabelName
+}
+
+// namespaceLabel returns the label holding the namespace of the Konfiguration
+// under the given domain.
+func namespaceLabel(domain string) string {
+	return domain + "/" + appsv1.KonfigurationNamespaceLabelName
+}
+
+// labels returns the labels of the objects created for the Konfiguration with
+// the given key.
+func (o ownershipLabels) labels(key client.ObjectKey) map[string]string {
+	return map[string]string{
+		nameLabel(o.domain):      key.Name,
+		namespaceLabel(o.domain): key.Namespace,
+	}
+}
+
+// set labels an object for the Konfiguration with the given key, removing
+// its legacy labels.
+func (o ownershipLabels) set(labels map[string]string, key client.ObjectKey) {
+	for _, d := range o.legacy {
+		delete(labels, nameLabel(d))
+		delete(labels, namespaceLabel(d))
+	}
+	for k, v := range o.labels(key) {
+		labels[k] = v
+	}
+}
+
+// ownedBy returns true if an object with the given labels is labeled for the
+// Konfiguration with the given key, under any of the domains.
+func (o ownershipLabels) ownedBy(labels map[string]string, key client.ObjectKey) bool {
+	for _, d := range append([]string{o.domain}, o.legacy...) {
+		if labels[nameLabel(d)] == key.Name && labels[namespaceLabel(d)] == key.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the key of the Konfiguration an object with the given labels
+// is labeled for, under any of the domains.
+func (o ownershipLabels) owner(labels map[string]string) (client.ObjectKey, bool) {
+	for _, d := range append([]string{o.domain}, o.legacy...) {
+		key := client.ObjectKey{Namespace: labels[namespaceLabel(d)], Name: labels[nameLabel(d)]}
+		if key.Namespace != "" && key.Name != "" {
+			return key, true
+		}
+	}
+	return client.ObjectKey{}, false
+}
+
+// selectors returns the selectors matching the objects labeled for the
+// Konfiguration with the given key, one for each domain.
+func (o ownershipLabels) selectors(key client.ObjectKey) []client.MatchingLabels {
+	selectors := []client.MatchingLabels{o.labels(key)}
+	for _, d := range o.legacy {
+		selectors = append(selectors, client.MatchingLabels{
+			nameLabel(d):      key.Name,
+			namespaceLabel(d): key.Namespace,
+		})
+	}
+	return selectors
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// pausedKey identifies the live objects of a kind in a namespace.
+type pausedKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+}
+
+// holdPausedObjects replaces the rendered objects whose live object is paused
+// with the live object, so kubecfg finds nothing to change in them while they
+// are still applied, tracked in the inventory and kept from garbage
+// collection. Live objects are listed once per kind and namespace instead of
+// being read one by one. It returns a description of each paused object.
+func (r *KonfigurationReconciler) holdPausedObjects(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) ([]string, error) {
+	pausedByKey := make(map[pausedKey]map[string]*unstructured.Unstructured)
+	var paused []string
+	for i, obj := range objs {
+		key := client.ObjectKey{Name: obj.GetName()}
+		if r.isNamespaced(obj) {
+			key.Namespace = namespaceOrDefault(obj, konfig)
+		}
+		pk := pausedKey{gvk: obj.GroupVersionKind(), namespace: key.Namespace}
+		byName, ok := pausedByKey[pk]
+		if !ok {
+			var err error
+			if byName, err = r.listPaused(ctx, pk); err != nil {
+				return nil, err
+			}
+			pausedByKey[pk] = byName
+		}
+		live, ok := byName[key.Name]
+		if !ok {
+			continue
+		}
+		log.Info("Leaving paused object as it is", "Kind", obj.GetKind(), "Namespace", key.Namespace, "Name", key.Name)
+		live.SetManagedFields(nil)
+		live.SetResourceVersion("")
+		live.SetUID("")
+		live.SetGeneration(0)
+		live.SetSelfLink("")
+		unstructured.RemoveNestedField(live.Object, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(live.Object, "status")
+		objs[i] = live
+		paused = append(paused, fmt.Sprintf("%s '%s'", obj.GetKind(), key))
+	}
+	return paused, nil
+}
+
+// listPaused returns the paused live objects of a kind in a namespace by name.
+// Kinds unknown to the cluster, e.g. those of CRDs applied along with them,
+// have none.
+func (r *KonfigurationReconciler) listPaused(ctx context.Context, key pausedKey) (map[string]*unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(key.gvk.GroupVersion().WithKind(key.gvk.Kind + "List"))
+	paused := make(map[string]*unstructured.Unstructured)
+	if err := r.List(ctx, list, client.InNamespace(key.namespace)); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return paused, nil
+		}
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].GetAnnotations()[appsv1.PausedAnnotation] == "true" {
+			paused[list.Items[i].GetName()] = &list.Items[i]
+		}
+	}
+	return paused, nil
+}
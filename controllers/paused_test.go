@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestHoldPausedObjects(t *testing.T) {
+	tests := []struct {
+		name       string
+		live       *unstructured.Unstructured
+		wantPaused bool
+	}{
+		{name: "missing object"},
+		{name: "live object", live: testObject("example.com/v1", "Widget", "team", "app", "")},
+		{name: "paused object", live: testObject("example.com/v1", "Widget", "team", "app", "", appsv1.PausedAnnotation, "true"), wantPaused: true},
+		{name: "paused object in another namespace", live: testObject("example.com/v1", "Widget", "other", "app", "", appsv1.PausedAnnotation, "true")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The fake client cannot list kinds of its scheme as unstructured
+			// objects, so the test uses a custom kind registered as such
+			gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+			scheme := runtime.NewScheme()
+			scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+			scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("WidgetList"), &unstructured.UnstructuredList{})
+			mapper := apimeta.NewDefaultRESTMapper(nil)
+			mapper.Add(gvk, apimeta.RESTScopeNamespace)
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tt.live != nil {
+				builder = builder.WithObjects(tt.live)
+			}
+			r := &KonfigurationReconciler{Client: mappedClient{Client: builder.Build(), mapper: mapper}, Scheme: scheme}
+			rendered := testObject("example.com/v1", "Widget", "team", "app", "")
+			unstructured.SetNestedField(rendered.Object, "rendered", "data", "key")
+			objs := []*unstructured.Unstructured{rendered}
+			paused, err := r.holdPausedObjects(context.TODO(), logr.Discard(), testKonfiguration(), objs)
+			if err != nil {
+				t.Fatalf("holdPausedObjects() error = %v", err)
+			}
+			if got := len(paused) == 1; got != tt.wantPaused {
+				t.Errorf("holdPausedObjects() = %v, want paused %v", paused, tt.wantPaused)
+			}
+			_, keepsRendered, _ := unstructured.NestedString(objs[0].Object, "data", "key")
+			if held := !keepsRendered; held != tt.wantPaused {
+				t.Errorf("object held = %v, want %v", held, tt.wantPaused)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// pipelineState records the progress of a failed reconciliation so that a
+// retry at the same source revision and spec can resume from the stage
+// that failed instead of rendering and validating the manifests again.
+type pipelineState struct {
+	revision     string
+	specChecksum string
+	// hibernating records whether the manifests were rendered during a
+	// hibernation window.
+	hibernating bool
+	manifests   *renderedManifests
+	validated   bool
+	applied     bool
+	// updated records whether applying the manifests updated the cluster.
+	updated bool
+}
+
+// preRendered holds the objects rendered for a Konfiguration ahead of their
+// apply, before they are modified for the cluster they are applied to.
+type preRendered struct {
+	revision     string
+	specChecksum string
+	objs         []*unstructured.Unstructured
+}
+
+// pipelineCache keeps the pipelineState of Konfigurations between reconciles,
+// along with the objects pre-rendered for them.
+type pipelineCache struct {
+	entries  map[string]*pipelineState
+	rendered map[string]*preRendered
+	mu       sync.Mutex
+}
+
+func newPipelineCache() *pipelineCache {
+	return &pipelineCache{
+		entries:  make(map[string]*pipelineState),
+		rendered: make(map[string]*preRendered),
+	}
+}
+
+// Get returns the state stored for the given key if it was recorded at the
+// given revision and spec checksum.
+func (c *pipelineCache) Get(key, revision, specChecksum string) (*pipelineState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.entries[key]
+	if !ok || state.revision != revision || state.specChecksum != specChecksum {
+		return nil, false
+	}
+	if _, err := os.Stat(state.manifests.path); err != nil {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return state, true
+}
+
+// Put stores the state for the given key, removing the manifests of any state
+// previously stored under the same key.
+func (c *pipelineCache) Put(key string, state *pipelineState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[key]; ok && old.manifests.path != state.manifests.path {
+		os.Remove(old.manifests.path)
+	}
+	c.entries[key] = state
+}
+
+// Evict removes the state, rendered manifests and pre-rendered objects stored
+// for the given key.
+func (c *pipelineCache) Evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state, ok := c.entries[key]; ok {
+		os.Remove(state.manifests.path)
+		delete(c.entries, key)
+	}
+	delete(c.rendered, key)
+}
+
+// HasPreRendered returns true if objects were pre-rendered for the given key at
+// the given revision and spec checksum.
+func (c *pipelineCache) HasPreRendered(key, revision, specChecksum string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.rendered[key]
+	return ok && entry.revision == revision && entry.specChecksum == specChecksum
+}
+
+// PutPreRendered stores the objects pre-rendered for the given key, replacing
+// any stored before.
+func (c *pipelineCache) PutPreRendered(key string, entry *preRendered) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rendered[key] = entry
+}
+
+// TakePreRendered removes and returns the objects pre-rendered for the given
+// key if they were rendered at the given revision and spec checksum.
+func (c *pipelineCache) TakePreRendered(key, revision, specChecksum string) ([]*unstructured.Unstructured, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.rendered[key]
+	if !ok {
+		return nil, false
+	}
+	delete(c.rendered, key)
+	if entry.revision != revision || entry.specChecksum != specChecksum {
+		return nil, false
+	}
+	return entry.objs, true
+}
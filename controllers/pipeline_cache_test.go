@@ -0,0 +1,54 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTakePreRendered(t *testing.T) {
+	tests := []struct {
+		name         string
+		revision     string
+		specChecksum string
+		want         bool
+	}{
+		{name: "same revision and spec", revision: "main/abc", specChecksum: "spec", want: true},
+		{name: "new revision", revision: "main/def", specChecksum: "spec"},
+		{name: "new spec", revision: "main/abc", specChecksum: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newPipelineCache()
+			objs := []*unstructured.Unstructured{testObject("v1", "ConfigMap", "team", "app", "")}
+			c.PutPreRendered("team/app", &preRendered{revision: "main/abc", specChecksum: "spec", objs: objs})
+			if got := c.HasPreRendered("team/app", tt.revision, tt.specChecksum); got != tt.want {
+				t.Errorf("HasPreRendered() = %v, want %v", got, tt.want)
+			}
+			got, ok := c.TakePreRendered("team/app", tt.revision, tt.specChecksum)
+			if ok != tt.want || (ok && len(got) != len(objs)) {
+				t.Errorf("TakePreRendered() = %v, %v, want %v", got, ok, tt.want)
+			}
+			// The objects are modified once applied, so they are only taken once
+			if _, ok := c.TakePreRendered("team/app", "main/abc", "spec"); ok {
+				t.Errorf("TakePreRendered() returned the objects twice")
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// podTemplateKinds are the kinds whose pod templates are annotated with the
+// hash of the configuration they reference.
+var podTemplateKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// annotatePodTemplates sets an annotation on the pod templates of rendered
+// workloads holding a hash of the ConfigMaps and Secrets they reference that
+// are rendered by the same Konfiguration. Changing their contents changes the
+// pod template and triggers a rollout.
+func annotatePodTemplates(log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) error {
+	configs := make(map[string]*unstructured.Unstructured)
+	for _, obj := range objs {
+		if obj.GetAPIVersion() != "v1" || (obj.GetKind() != "ConfigMap" && obj.GetKind() != "Secret") {
+			continue
+		}
+		configs[configKey(obj.GetKind(), namespaceOrDefault(obj, konfig), obj.GetName())] = obj
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+
+	for _, obj := range objs {
+		if !podTemplateKinds[obj.GetKind()] {
+			continue
+		}
+		raw, found, err := unstructured.NestedMap(obj.Object, "spec", "template")
+		if err != nil || !found {
+			continue
+		}
+		var template corev1.PodTemplateSpec
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &template); err != nil {
+			return fmt.Errorf("failed to decode pod template of %s '%s': %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		namespace := namespaceOrDefault(obj, konfig)
+		var refs []string
+		for _, ref := range referencedConfigs(&template.Spec) {
+			key := configKey(ref.kind, namespace, ref.name)
+			if _, ok := configs[key]; ok {
+				refs = append(refs, key)
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		sort.Strings(refs)
+
+		hash := sha256.New()
+		for _, key := range refs {
+			config := configs[key]
+			data, err := json.Marshal([]interface{}{key, config.Object["data"], config.Object["binaryData"], config.Object["stringData"]})
+			if err != nil {
+				return err
+			}
+			hash.Write(data)
+		}
+
+		if err := unstructured.SetNestedField(obj.Object, fmt.Sprintf("%x", hash.Sum(nil)),
+			"spec", "template", "metadata", "annotations", appsv1.PodTemplateHashAnnotation); err != nil {
+			return err
+		}
+		log.Info("Annotated pod template with configuration hash", "Kind", obj.GetKind(), "Namespace", namespace, "Name", obj.GetName())
+	}
+	return nil
+}
+
+type configRef struct {
+	kind, name string
+}
+
+// referencedConfigs returns the ConfigMaps and Secrets referenced by the
+// volumes and containers of a pod.
+func referencedConfigs(spec *corev1.PodSpec) []configRef {
+	var refs []configRef
+	for _, vol := range spec.Volumes {
+		if vol.ConfigMap != nil {
+			refs = append(refs, configRef{"ConfigMap", vol.ConfigMap.Name})
+		}
+		if vol.Secret != nil {
+			refs = append(refs, configRef{"Secret", vol.Secret.SecretName})
+		}
+		if vol.Projected != nil {
+			for _, src := range vol.Projected.Sources {
+				if src.ConfigMap != nil {
+					refs = append(refs, configRef{"ConfigMap", src.ConfigMap.Name})
+				}
+				if src.Secret != nil {
+					refs = append(refs, configRef{"Secret", src.Secret.Name})
+				}
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				refs = append(refs, configRef{"ConfigMap", from.ConfigMapRef.Name})
+			}
+			if from.SecretRef != nil {
+				refs = append(refs, configRef{"Secret", from.SecretRef.Name})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				refs = append(refs, configRef{"ConfigMap", env.ValueFrom.ConfigMapKeyRef.Name})
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				refs = append(refs, configRef{"Secret", env.ValueFrom.SecretKeyRef.Name})
+			}
+		}
+	}
+	return refs
+}
+
+func configKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+func namespaceOrDefault(obj *unstructured.Unstructured, konfig *appsv1.Konfiguration) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns
+	}
+	return konfig.GetTargetNamespace()
+}
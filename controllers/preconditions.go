@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// checkPreconditions runs the precondition checks of the Konfiguration in
+// order from the network of the controller, within the timeout of the
+// Konfiguration. It returns an error describing the first one that is not met.
+// Since the checks reach any address from the controller, they must be enabled
+// by the operator.
+func (r *KonfigurationReconciler) checkPreconditions(ctx context.Context, konfig *appsv1.Konfiguration) error {
+	if len(konfig.Spec.Preconditions) == 0 {
+		return nil
+	}
+	if !r.settings.get().networkPreconditions {
+		return withReason(appsv1.PreconditionNotMetReason, fmt.Errorf("preconditions are not enabled on this controller"))
+	}
+	ctx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
+	defer cancel()
+	for _, precondition := range konfig.Spec.Preconditions {
+		if err := checkPrecondition(ctx, precondition); err != nil {
+			return withReason(appsv1.PreconditionNotMetReason, fmt.Errorf("%s precondition '%s' not met: %w", precondition.Type, precondition.Address, err))
+		}
+	}
+	return nil
+}
+
+// checkPrecondition runs a single precondition check.
+func checkPrecondition(ctx context.Context, precondition appsv1.Precondition) error {
+	ctx, cancel := context.WithTimeout(ctx, precondition.GetTimeout())
+	defer cancel()
+	switch precondition.Type {
+	case "tcp":
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", precondition.Address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case "dns":
+		addrs, err := net.DefaultResolver.LookupHost(ctx, precondition.Address)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses found")
+		}
+		return nil
+	case "http":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, precondition.Address, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %s", res.Status)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown precondition type '%s'", precondition.Type)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ok.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		preconditions []appsv1.Precondition
+		timeout       time.Duration
+		wantErr       bool
+	}{
+		{name: "no preconditions"},
+		{name: "not enabled", preconditions: []appsv1.Precondition{{Type: "http", Address: ok.URL}}, wantErr: true},
+		{name: "met", enabled: true, preconditions: []appsv1.Precondition{{Type: "http", Address: ok.URL}}},
+		{
+			name:    "not met within the timeout of the Konfiguration",
+			enabled: true,
+			preconditions: []appsv1.Precondition{
+				{Type: "http", Address: slow.URL, Timeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &KonfigurationReconciler{settings: &settingsStore{current: settings{networkPreconditions: tt.enabled}}}
+			konfig := testKonfiguration()
+			konfig.Spec.Preconditions = tt.preconditions
+			konfig.Spec.Timeout = &metav1.Duration{Duration: time.Minute}
+			if tt.timeout != 0 {
+				konfig.Spec.Timeout.Duration = tt.timeout
+			}
+			err := r.checkPreconditions(context.TODO(), konfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkPreconditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && reasonFor(err) != appsv1.PreconditionNotMetReason {
+				t.Errorf("reason = %s, want %s", reasonFor(err), appsv1.PreconditionNotMetReason)
+			}
+		})
+	}
+}
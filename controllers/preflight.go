@@ -0,0 +1,266 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// applyVerbs are the verbs kubecfg needs on every object it applies.
+var applyVerbs = []string{"get", "create", "patch"}
+
+// pruneVerbs are the additional verbs kubecfg needs on objects it may garbage
+// collect.
+var pruneVerbs = []string{"list", "delete"}
+
+// checkPermissions performs a SelfSubjectAccessReview for every verb needed to
+// apply the rendered objects, and fails with a report of all missing
+// permissions if any are denied. kubecfg applies the objects with the
+// credentials the clients of the reconciler use, those of the controller or of
+// the kubeconfig of a target, so the reviews are made for the identity kubecfg
+// runs as.
+func (r *KonfigurationReconciler) checkPermissions(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) error {
+	verbs := applyVerbs
+	if konfig.GCEnabled() {
+		verbs = append(append([]string{}, applyVerbs...), pruneVerbs...)
+	}
+
+	checked := make(map[authorizationv1.ResourceAttributes]bool)
+	var denied []string
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			// The kind may be defined by a CustomResourceDefinition that has
+			// not been applied yet.
+			continue
+		}
+		var namespace string
+		if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+			namespace = namespaceOrDefault(obj, konfig)
+		}
+		for _, verb := range verbs {
+			attrs := authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     mapping.Resource.Group,
+				Version:   mapping.Resource.Version,
+				Resource:  mapping.Resource.Resource,
+			}
+			if checked[attrs] {
+				continue
+			}
+			checked[attrs] = true
+
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+			}
+			if err := r.Create(ctx, review); err != nil {
+				return fmt.Errorf("failed to review access: %w", err)
+			}
+			if !review.Status.Allowed {
+				denied = append(denied, describeAccess(attrs))
+			}
+		}
+	}
+
+	if len(denied) == 0 {
+		return nil
+	}
+	sort.Strings(denied)
+	log.Info("Missing permissions to apply manifests", "Denied", denied)
+	return withReason(appsv1.ValidationFailedReason,
+		fmt.Errorf("missing permissions to apply manifests:\n - %s", strings.Join(denied, "\n - ")))
+}
+
+func describeAccess(attrs authorizationv1.ResourceAttributes) string {
+	resource := attrs.Resource
+	if attrs.Group != "" {
+		resource = fmt.Sprintf("%s.%s", attrs.Resource, attrs.Group)
+	}
+	if attrs.Namespace == "" {
+		return fmt.Sprintf("%s %s (cluster-scoped)", attrs.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace '%s'", attrs.Verb, resource, attrs.Namespace)
+}
+
+// schedulingPaths are the paths of the pod specs of the workload kinds whose
+// placement is checked. DaemonSets are left out, since they only run pods on
+// the nodes they can be scheduled on.
+var schedulingPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// checkScheduling fails with a report of the rendered workloads whose pods
+// cannot be scheduled on any node of the cluster the clients of the reconciler
+// talk to, because of their node selector, required node affinity, or the
+// taints of the nodes they do not tolerate. Cordoned nodes are not considered,
+// and workloads scaled to zero are not checked.
+func (r *KonfigurationReconciler) checkScheduling(ctx context.Context, log logr.Logger, objs []*unstructured.Unstructured) error {
+	if r.namespaceScoped {
+		return withReason(appsv1.InvalidSpecReason, fmt.Errorf("the scheduling check is not available when the controller runs namespace-scoped"))
+	}
+	var nodes *corev1.NodeList
+	var unschedulable []string
+	for _, obj := range objs {
+		path, ok := schedulingPaths[obj.GetKind()]
+		if !ok {
+			continue
+		}
+		if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found && replicas == 0 {
+			continue
+		}
+		raw, found, err := unstructured.NestedMap(obj.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+		var spec corev1.PodSpec
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+			return fmt.Errorf("failed to decode pod spec of %s '%s': %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		if nodes == nil {
+			if nodes, err = r.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+				return fmt.Errorf("failed to list nodes: %w", err)
+			}
+		}
+		if !schedulableOnAny(&spec, nodes.Items) {
+			unschedulable = append(unschedulable, fmt.Sprintf("%s '%s'", obj.GetKind(), client.ObjectKeyFromObject(obj)))
+		}
+	}
+
+	if len(unschedulable) == 0 {
+		return nil
+	}
+	log.Info("Rendered workloads cannot be scheduled", "Workloads", unschedulable)
+	return withReason(appsv1.UnschedulableReason,
+		fmt.Errorf("pods cannot be scheduled on any of the %d nodes:\n - %s", len(nodes.Items), strings.Join(unschedulable, "\n - ")))
+}
+
+// schedulableOnAny returns true if a pod with the given spec can be scheduled
+// on any of the given nodes, disregarding their resources.
+func schedulableOnAny(spec *corev1.PodSpec, nodes []corev1.Node) bool {
+	for i := range nodes {
+		if schedulableOn(spec, &nodes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// schedulableOn returns true if a pod with the given spec can be scheduled on
+// the given node, disregarding its resources.
+func schedulableOn(spec *corev1.PodSpec, node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	if spec.NodeName != "" && spec.NodeName != node.GetName() {
+		return false
+	}
+	if !labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.GetLabels())) {
+		return false
+	}
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			var matched bool
+			for _, term := range required.NodeSelectorTerms {
+				if nodeSelectorTermMatches(term, node) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		var tolerated bool
+		for j := range spec.Tolerations {
+			if spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeSelectorOperators maps the operators of node selector requirements to
+// those of label selectors.
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// nodeSelectorTermMatches returns true if the node matches every requirement
+// of the term. Terms without requirements match no node.
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	return requirementsMatch(term.MatchExpressions, labels.Set(node.GetLabels())) &&
+		requirementsMatch(term.MatchFields, labels.Set{"metadata.name": node.GetName()})
+}
+
+// requirementsMatch returns true if the given set matches every requirement.
+// Requirements with an unknown operator or invalid values match no set.
+func requirementsMatch(reqs []corev1.NodeSelectorRequirement, set labels.Set) bool {
+	for _, req := range reqs {
+		op, ok := nodeSelectorOperators[req.Operator]
+		if !ok {
+			return false
+		}
+		requirement, err := labels.NewRequirement(req.Key, op, req.Values)
+		if err != nil || !requirement.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSchedulableOn(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"pool": "gpu", "cores": "16"}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		}},
+	}
+	tolerateGPU := []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+
+	tests := []struct {
+		name          string
+		spec          corev1.PodSpec
+		unschedulable bool
+		want          bool
+	}{
+		{name: "tolerated taints", spec: corev1.PodSpec{Tolerations: tolerateGPU}, want: true},
+		{name: "untolerated taint", spec: corev1.PodSpec{}},
+		{name: "cordoned node", spec: corev1.PodSpec{Tolerations: tolerateGPU}, unschedulable: true},
+		{name: "node name", spec: corev1.PodSpec{NodeName: "gpu-1", Tolerations: tolerateGPU}, want: true},
+		{name: "other node name", spec: corev1.PodSpec{NodeName: "cpu-1", Tolerations: tolerateGPU}},
+		{name: "matching node selector", spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "gpu"}, Tolerations: tolerateGPU}, want: true},
+		{name: "mismatching node selector", spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "cpu"}, Tolerations: tolerateGPU}},
+		{
+			name: "matching affinity term",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"cpu"}}}},
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "cores", Operator: corev1.NodeSelectorOpGt, Values: []string{"8"}}}},
+			)},
+			want: true,
+		},
+		{
+			name: "matching affinity field",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(
+				corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu-1"}}}},
+			)},
+			want: true,
+		},
+		{
+			name: "mismatching affinity terms",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpDoesNotExist}}},
+			)},
+		},
+		{
+			name: "empty affinity term",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(corev1.NodeSelectorTerm{})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := node.DeepCopy()
+			n.Spec.Unschedulable = tt.unschedulable
+			if got := schedulableOn(&tt.spec, n); got != tt.want {
+				t.Errorf("schedulableOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// missingRefMessages are fragments of the errors reported by source-controller
+// when the Git reference it tracks no longer exists on the remote.
+var missingRefMessages = []string{
+	"couldn't find remote ref",
+	"reference not found",
+}
+
+// reconcilePreviewNamespace ensures the preview namespace for the branch of the
+// given revision exists and returns its name. Preview namespaces created for
+// any other branch are removed.
+func (r *KonfigurationReconciler) reconcilePreviewNamespace(ctx context.Context, konfig *appsv1.Konfiguration, revision string) (string, error) {
+	if r.namespaceScoped {
+		return "", fmt.Errorf("preview mode is not available when the controller runs namespace-scoped")
+	}
+	if sourceRef := konfig.GetSourceRef(); sourceRef == nil || sourceRef.Kind != sourcev1.GitRepositoryKind {
+		return "", fmt.Errorf("preview mode requires a %s source", sourcev1.GitRepositoryKind)
+	}
+
+	branch, err := appsv1.GetPreviewBranch(revision)
+	if err != nil {
+		return "", err
+	}
+
+	// Read the namespace from the namespace cache, since the cache of the
+	// manager cannot serve it when restricted to several namespaces
+	c, err := client.NewDelegatingClient(client.NewDelegatingClientInput{CacheReader: r.namespaces, Client: r.Client})
+	if err != nil {
+		return "", err
+	}
+	ns := &corev1.Namespace{}
+	ns.SetName(konfig.GetPreviewNamespace(branch))
+	_, err = controllerutil.CreateOrUpdate(ctx, c, ns, func() error {
+		labels := ns.GetLabels()
+		if ns.GetResourceVersion() != "" && !r.ownership.ownedBy(labels, client.ObjectKeyFromObject(konfig)) {
+			return fmt.Errorf("namespace '%s' is not managed by this Konfiguration", ns.GetName())
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		r.ownership.set(labels, client.ObjectKeyFromObject(konfig))
+		labels[appsv1.PreviewLabel] = "true"
+		ns.SetLabels(labels)
+		annotations := ns.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[appsv1.PreviewBranchAnnotation] = branch
+		ns.SetAnnotations(annotations)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to reconcile preview namespace '%s': %w", ns.GetName(), err)
+	}
+
+	if err := r.cleanupPreviewNamespaces(ctx, client.ObjectKeyFromObject(konfig), ns.GetName()); err != nil {
+		return "", err
+	}
+	return ns.GetName(), nil
+}
+
+// cleanupPreviewNamespaces removes any preview namespaces created on behalf of
+// the given Konfiguration, except the one named by keep (if any). It is a no-op
+// when the controller runs namespace-scoped, since no preview namespaces can
+// have been created and namespaces cannot be listed. Namespaces are listed from
+// the namespace cache of the reconciler.
+func (r *KonfigurationReconciler) cleanupPreviewNamespaces(ctx context.Context, key client.ObjectKey, keep string) error {
+	if r.namespaceScoped {
+		return nil
+	}
+	for _, selector := range r.ownership.selectors(key) {
+		selector[appsv1.PreviewLabel] = "true"
+		var list corev1.NamespaceList
+		if err := r.namespaces.List(ctx, &list, selector); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			if list.Items[i].GetName() == keep {
+				continue
+			}
+			if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sourceBranchGone returns true if the source reports that the Git reference it
+// tracks could not be found on the remote.
+func sourceBranchGone(source sourcev1.Source) bool {
+	obj, ok := source.(meta.ObjectWithStatusConditions)
+	if !ok {
+		return false
+	}
+	cond := apimeta.FindStatusCondition(*obj.GetStatusConditions(), meta.ReadyCondition)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != sourcev1.GitOperationFailedReason {
+		return false
+	}
+	for _, msg := range missingRefMessages {
+		if strings.Contains(cond.Message, msg) {
+			return true
+		}
+	}
+	return false
+}
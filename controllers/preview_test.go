@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestCleanupPreviewNamespaces(t *testing.T) {
+	ownership, err := newOwnershipLabels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := client.ObjectKey{Namespace: "team", Name: "app"}
+	previewNamespace := func(name string, owner client.ObjectKey, preview bool) *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		ns.SetName(name)
+		labels := ownership.labels(owner)
+		if preview {
+			labels[appsv1.PreviewLabel] = "true"
+		}
+		ns.SetLabels(labels)
+		return ns
+	}
+	tests := []struct {
+		name    string
+		ns      *corev1.Namespace
+		keep    string
+		deleted bool
+	}{
+		{name: "preview namespace", ns: previewNamespace("app-feature", key, true), deleted: true},
+		{name: "kept preview namespace", ns: previewNamespace("app-feature", key, true), keep: "app-feature"},
+		{name: "preview namespace of another Konfiguration", ns: previewNamespace("other-feature", client.ObjectKey{Namespace: "team", Name: "other"}, true)},
+		{name: "namespace that is no preview", ns: previewNamespace("app-data", key, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.ns)
+			r.ownership = ownership
+			r.namespaces = r.Client
+			if err := r.cleanupPreviewNamespaces(context.TODO(), key, tt.keep); err != nil {
+				t.Fatalf("cleanupPreviewNamespaces() error = %v", err)
+			}
+			err := r.Get(context.TODO(), client.ObjectKeyFromObject(tt.ns), &corev1.Namespace{})
+			if got := apierrors.IsNotFound(err); got != tt.deleted {
+				t.Errorf("deleted = %v, want %v (error %v)", got, tt.deleted, err)
+			}
+		})
+	}
+}
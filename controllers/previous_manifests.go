@@ -0,0 +1,95 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// withPreviousManifests returns a copy of the Konfiguration that passes the
+// manifests applied by its previous revision to kubecfg in the
+// PreviousManifestsExtVar external variable, along with a function removing
+// any file written for it. The variable is always set, to an empty list if the
+// feature is disabled, so `prevManifest` returns null rather than failing the
+// evaluation.
+func (r *KonfigurationReconciler) withPreviousManifests(ctx context.Context, konfig *appsv1.Konfiguration) (*appsv1.Konfiguration, func(), error) {
+	pk := konfig.DeepCopy()
+	if !konfig.PreviousManifestsEnabled() {
+		pk.Spec.KubecfgArgs = append(pk.Spec.KubecfgArgs, "--ext-code", appsv1.PreviousManifestsExtVar+"=[]")
+		return pk, func() {}, nil
+	}
+
+	objs, err := r.previousObjects(ctx, konfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read previous manifests: %w", err)
+	}
+	data, err := json.Marshal(objs)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-previous-*.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return nil, nil, err
+	}
+
+	pk.Spec.KubecfgArgs = append(pk.Spec.KubecfgArgs, "--ext-code-file", fmt.Sprintf("%s=%s", appsv1.PreviousManifestsExtVar, f.Name()))
+	return pk, func() { os.Remove(f.Name()) }, nil
+}
+
+// previousObjects returns the objects of the manifests applied by the last
+// successful reconciliation of the Konfiguration, as stored in its manifest
+// store, or an empty list if none are stored. Namespaced objects rendered
+// without a namespace are given the one they were applied to.
+func (r *KonfigurationReconciler) previousObjects(ctx context.Context, konfig *appsv1.Konfiguration) ([]map[string]interface{}, error) {
+	objs := []map[string]interface{}{}
+	data, err := r.readManifestStore(ctx, konfig)
+	if err != nil || data == nil {
+		return objs, err
+	}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err == io.EOF {
+			return objs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if r.isNamespaced(obj) {
+			obj.SetNamespace(namespaceOrDefault(obj, konfig))
+		}
+		objs = append(objs, obj.Object)
+	}
+}
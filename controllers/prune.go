@@ -0,0 +1,309 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+const (
+	// gcStrategyAnnotation is the annotation kubecfg checks before garbage
+	// collecting an object.
+	gcStrategyAnnotation = "kubecfg.ksonnet.io/garbage-collect-strategy"
+	// gcStrategyIgnore tells kubecfg to never garbage collect the object.
+	gcStrategyIgnore = "ignore"
+	// gcTagAnnotation is the annotation kubecfg records the garbage
+	// collection tag of an object in.
+	gcTagAnnotation = "kubecfg.ksonnet.io/garbage-collect-tag"
+)
+
+// prunePollInterval is the interval at which pruned objects are checked for
+// removal.
+const prunePollInterval = 2 * time.Second
+
+var crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+// protectClusterScoped marks rendered cluster-scoped objects to be ignored by
+// kubecfg garbage collection unless the Konfiguration opted in to pruning them.
+// CustomResourceDefinitions require a separate opt-in, since deleting one
+// removes every custom resource of its kind. The objects are also marked with
+// the ProtectedAnnotation, so the protection is told from one set by the
+// manifests and lifted once the Konfiguration allows pruning them. Both
+// annotations are then removed from the live objects still rendered when they
+// are applied, since the manifests no longer set them.
+func (r *KonfigurationReconciler) protectClusterScoped(log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) {
+	for _, obj := range objs {
+		if r.isNamespaced(obj) || clusterScopedPrunable(konfig, obj) {
+			continue
+		}
+		annotations := obj.GetAnnotations()
+		if annotations[gcStrategyAnnotation] == gcStrategyIgnore {
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[gcStrategyAnnotation] = gcStrategyIgnore
+		annotations[appsv1.ProtectedAnnotation] = "true"
+		obj.SetAnnotations(annotations)
+		log.Info("Protecting cluster-scoped object from garbage collection", "Kind", obj.GetKind(), "Name", obj.GetName())
+	}
+}
+
+// clusterScopedPrunable returns true if the Konfiguration allows pruning the
+// given cluster-scoped object.
+func clusterScopedPrunable(konfig *appsv1.Konfiguration, obj *unstructured.Unstructured) bool {
+	return konfig.PruneClusterScopedEnabled() && (obj.GroupVersionKind().GroupKind() != crdGroupKind || konfig.PruneCRDsEnabled())
+}
+
+// gcProtected returns true if the live object is protected from garbage
+// collection. The protection protectClusterScoped added no longer holds once
+// the Konfiguration allows pruning the object.
+func gcProtected(konfig *appsv1.Konfiguration, obj *unstructured.Unstructured) bool {
+	annotations := obj.GetAnnotations()
+	if annotations[gcStrategyAnnotation] != gcStrategyIgnore {
+		return false
+	}
+	_, protected := annotations[appsv1.ProtectedAnnotation]
+	return !protected || !clusterScopedPrunable(konfig, obj)
+}
+
+// isNamespaced returns true if the object is of a namespaced kind. Kinds that
+// cannot be resolved, e.g. because their CustomResourceDefinition is rendered
+// alongside them, are considered namespaced only if the object sets a
+// namespace.
+func (r *KonfigurationReconciler) isNamespaced(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return obj.GetNamespace() != ""
+	}
+	return mapping.Scope.Name() == apimeta.RESTScopeNameNamespace
+}
+
+// pruneAll removes all the objects applied for the given Konfiguration. The
+// cordoned objects and the objects of its inventory are deleted in order
+// first, regardless of the prune grace period, then an empty set of manifests
+// is applied with garbage collection enabled to catch any others. Objects
+// protected from garbage collection are left in place.
+func (r *KonfigurationReconciler) pruneAll(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration) error {
+	if !konfig.GCEnabled() {
+		return fmt.Errorf("removing managed objects requires prune to be enabled")
+	}
+	if err := r.deleteCordoned(ctx, log, konfig); err != nil {
+		return err
+	}
+	previous, err := r.readInventory(ctx, konfig)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := r.deleteInOrder(ctx, log, konfig, previous.Entries); err != nil {
+			return err
+		}
+	}
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-*.yaml")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	return runKubecfgUpdate(ctx, log, konfig, f.Name(), false, false)
+}
+
+// pruneStale removes the objects of the given stale entries of the previous
+// inventory of the Konfiguration, once the current manifests are applied. With
+// a prune grace period they are cordoned instead.
+func (r *KonfigurationReconciler) pruneStale(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, stale []appsv1.InventoryEntry) error {
+	if konfig.GetPruneGracePeriod() > 0 {
+		return r.cordonStale(ctx, log, konfig, stale)
+	}
+	return r.deleteInOrder(ctx, log, konfig, stale)
+}
+
+// deletionTier returns the stage in which an object is deleted when pruning.
+// Namespaced objects go first, then cluster-scoped objects, then the
+// Namespaces they lived in and finally the CustomResourceDefinitions of any
+// custom resources removed before. It returns -1 if the object must not be
+// deleted.
+func (r *KonfigurationReconciler) deletionTier(konfig *appsv1.Konfiguration, obj *unstructured.Unstructured) int {
+	if r.isNamespaced(obj) {
+		return 0
+	}
+	if !konfig.PruneClusterScopedEnabled() {
+		return -1
+	}
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Namespace"}:
+		return 2
+	case crdGroupKind:
+		if !konfig.PruneCRDsEnabled() {
+			return -1
+		}
+		return 3
+	}
+	return 1
+}
+
+// deleteInOrder deletes the objects of the given inventory entries in reverse
+// order of application, one tier at a time as returned by deletionTier,
+// waiting for the objects of each tier to be gone, including their
+// finalizers, before moving on. Objects are deleted with the propagation
+// policy configured for their kind. This keeps objects such as custom resources
+// from being stuck behind the removal of their definition or of the webhooks
+// and controllers handling them. Objects not tagged for garbage collection of
+// this Konfiguration, protected from it or paused, are left in place.
+func (r *KonfigurationReconciler) deleteInOrder(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, entries []appsv1.InventoryEntry) error {
+	tiers := make([][]*unstructured.Unstructured, 4)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(entry.APIVersion)
+		obj.SetKind(entry.Kind)
+		obj.SetNamespace(entry.Namespace)
+		obj.SetName(entry.Name)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		annotations := obj.GetAnnotations()
+		if annotations[gcTagAnnotation] != konfig.GetGCTag() || gcProtected(konfig, obj) {
+			continue
+		}
+		if annotations[appsv1.PausedAnnotation] == "true" {
+			log.Info("Leaving paused object in place", "Kind", entry.Kind, "Namespace", entry.Namespace, "Name", entry.Name)
+			continue
+		}
+		if tier := r.deletionTier(konfig, obj); tier >= 0 {
+			tiers[tier] = append(tiers[tier], obj)
+		}
+	}
+	return r.deleteTiers(ctx, log, konfig, tiers)
+}
+
+// deleteTombstones deletes the objects listed in the deletes of the
+// Konfiguration, unless they are part of the given inventory, not tagged for
+// garbage collection of this Konfiguration or protected from it. Objects are
+// deleted in tiers like pruned ones.
+func (r *KonfigurationReconciler) deleteTombstones(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, inventory []appsv1.InventoryEntry) error {
+	rendered := make(map[appsv1.InventoryEntry]struct{}, len(inventory))
+	for _, entry := range inventory {
+		rendered[entry] = struct{}{}
+	}
+
+	tiers := make([][]*unstructured.Unstructured, 4)
+	for _, deletion := range konfig.Spec.Deletes {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(deletion.APIVersion)
+		obj.SetKind(deletion.Kind)
+		obj.SetName(deletion.Name)
+		gvk := obj.GroupVersionKind()
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if apimeta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+			namespace := deletion.Namespace
+			if namespace == "" {
+				namespace = konfig.GetTargetNamespace()
+			}
+			obj.SetNamespace(namespace)
+		}
+		entry := appsv1.InventoryEntry{APIVersion: deletion.APIVersion, Kind: deletion.Kind, Namespace: obj.GetNamespace(), Name: deletion.Name}
+		if _, ok := rendered[entry]; ok {
+			log.Info("Not deleting object still rendered", "Kind", entry.Kind, "Namespace", entry.Namespace, "Name", entry.Name)
+			continue
+		}
+
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		annotations := obj.GetAnnotations()
+		if annotations[gcTagAnnotation] != konfig.GetGCTag() {
+			log.Info("Not deleting object not applied by this Konfiguration", "Kind", entry.Kind, "Namespace", entry.Namespace, "Name", entry.Name)
+			continue
+		}
+		if gcProtected(konfig, obj) {
+			continue
+		}
+		if tier := r.deletionTier(konfig, obj); tier >= 0 {
+			tiers[tier] = append(tiers[tier], obj)
+		} else {
+			log.Info("Not deleting cluster-scoped object", "Kind", entry.Kind, "Name", entry.Name)
+		}
+	}
+	return r.deleteTiers(ctx, log, konfig, tiers)
+}
+
+// deleteTiers deletes the given tiers of objects in order, waiting for the
+// objects of each tier to be gone before moving on to the next.
+func (r *KonfigurationReconciler) deleteTiers(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, tiers [][]*unstructured.Unstructured) error {
+	for _, objs := range tiers {
+		if len(objs) == 0 {
+			continue
+		}
+		for _, obj := range objs {
+			policy := konfig.GetPropagationPolicy(obj.GetKind())
+			log.Info("Pruning object", "Kind", obj.GetKind(), "Namespace", obj.GetNamespace(), "Name", obj.GetName(), "PropagationPolicy", policy)
+			if err := r.Delete(ctx, obj, client.PropagationPolicy(policy)); client.IgnoreNotFound(err) != nil {
+				return withReason(appsv1.PruneFailedReason, fmt.Errorf("failed to delete %s '%s': %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err))
+			}
+		}
+		var remaining []string
+		err := wait.PollImmediate(prunePollInterval, konfig.GetTimeout(), func() (bool, error) {
+			remaining = remaining[:0]
+			for _, obj := range objs {
+				err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopy())
+				if apierrors.IsNotFound(err) {
+					continue
+				} else if err != nil {
+					return false, err
+				}
+				remaining = append(remaining, fmt.Sprintf("%s '%s'", obj.GetKind(), client.ObjectKeyFromObject(obj)))
+			}
+			return len(remaining) == 0, nil
+		})
+		if err == wait.ErrWaitTimeout {
+			return withReason(appsv1.PruneFailedReason, fmt.Errorf("timed out waiting for the deletion of %s", strings.Join(remaining, ", ")))
+		} else if err != nil {
+			return withReason(appsv1.PruneFailedReason, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// scaledWorkloadKinds are the kinds of workloads scaled to zero while they are
+// cordoned.
+var scaledWorkloadKinds = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "ReplicaSet"}:  true,
+}
+
+// getEntry returns the object of the given inventory entry, or nil if it does
+// not exist or its kind is not registered.
+func (r *KonfigurationReconciler) getEntry(ctx context.Context, entry appsv1.InventoryEntry) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(entry.APIVersion)
+	obj.SetKind(entry.Kind)
+	obj.SetNamespace(entry.Namespace)
+	obj.SetName(entry.Name)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// cordonStale cordons the objects of the given stale inventory entries instead
+// of deleting them, and records them in the status of the Konfiguration until
+// the prune grace period expires. Cordoned objects are protected from kubecfg
+// garbage collection, and workloads are scaled to zero. Objects that would not
+// be pruned are left untouched. Objects already cordoned, e.g. if the status
+// of the Konfiguration failed to be recorded, are recorded as cordoned at the
+// time recorded on them.
+func (r *KonfigurationReconciler) cordonStale(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, entries []appsv1.InventoryEntry) error {
+	cordoned := make(map[appsv1.InventoryEntry]bool, len(konfig.Status.CordonedObjects))
+	for _, object := range konfig.Status.CordonedObjects {
+		cordoned[object.InventoryEntry] = true
+	}
+	now := metav1.Now()
+	for _, entry := range entries {
+		if cordoned[entry] {
+			continue
+		}
+		obj, err := r.getEntry(ctx, entry)
+		if err != nil {
+			return err
+		} else if obj == nil {
+			continue
+		}
+		annotations := obj.GetAnnotations()
+		if annotations[gcTagAnnotation] != konfig.GetGCTag() || r.deletionTier(konfig, obj) < 0 {
+			continue
+		}
+		if at, ok := cordonedAt(obj); ok {
+			konfig.Status.CordonedObjects = append(konfig.Status.CordonedObjects, appsv1.CordonedObject{InventoryEntry: entry, CordonedAt: at})
+			continue
+		}
+		if gcProtected(konfig, obj) {
+			continue
+		}
+		if err := cordonObject(obj, now); err != nil {
+			return err
+		}
+		log.Info("Cordoning object no longer rendered", "Kind", entry.Kind, "Namespace", entry.Namespace, "Name", entry.Name,
+			"GracePeriod", konfig.GetPruneGracePeriod().String())
+		if err := r.Update(ctx, obj); err != nil {
+			return withReason(appsv1.PruneFailedReason, fmt.Errorf("failed to cordon %s '%s': %w", entry.Kind, client.ObjectKeyFromObject(obj), err))
+		}
+		konfig.Status.CordonedObjects = append(konfig.Status.CordonedObjects, appsv1.CordonedObject{InventoryEntry: entry, CordonedAt: now})
+	}
+	return nil
+}
+
+// reconcileCordoned settles the cordoned objects of the Konfiguration after
+// the given inventory was applied. Objects rendered again, or that would no
+// longer be pruned, are restored, and the others are deleted once the prune
+// grace period expired, as recorded on the objects. Objects removed or
+// uncordoned by hand are forgotten.
+func (r *KonfigurationReconciler) reconcileCordoned(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, inventory []appsv1.InventoryEntry) error {
+	if len(konfig.Status.CordonedObjects) == 0 {
+		return nil
+	}
+	rendered := make(map[appsv1.InventoryEntry]struct{}, len(inventory))
+	for _, entry := range inventory {
+		rendered[entry] = struct{}{}
+	}
+
+	var remaining, expired []appsv1.CordonedObject
+	tiers := make([][]*unstructured.Unstructured, 4)
+	for _, object := range konfig.Status.CordonedObjects {
+		obj, err := r.getEntry(ctx, object.InventoryEntry)
+		if err != nil {
+			return err
+		} else if obj == nil || obj.GetLabels()[appsv1.CordonedLabel] != "true" {
+			continue
+		}
+		if at, ok := cordonedAt(obj); ok {
+			object.CordonedAt = at
+		}
+		_, ok := rendered[object.InventoryEntry]
+		tier := r.deletionTier(konfig, obj)
+		switch {
+		case ok || !konfig.GCEnabled() || tier < 0:
+			log.Info("Restoring cordoned object", "Kind", object.Kind, "Namespace", object.Namespace, "Name", object.Name)
+			if err := r.uncordon(ctx, obj); err != nil {
+				return err
+			}
+		case time.Since(object.CordonedAt.Time) >= konfig.GetPruneGracePeriod():
+			tiers[tier] = append(tiers[tier], obj)
+			expired = append(expired, object)
+		default:
+			remaining = append(remaining, object)
+		}
+	}
+	// Keep the expired objects listed until they are gone, so their deletion
+	// is retried
+	konfig.Status.CordonedObjects = append(remaining, expired...)
+	if err := r.deleteTiers(ctx, log, konfig, tiers); err != nil {
+		return err
+	}
+	konfig.Status.CordonedObjects = remaining
+	return nil
+}
+
+// deleteCordoned deletes all the cordoned objects of the Konfiguration without
+// waiting for the prune grace period to expire.
+func (r *KonfigurationReconciler) deleteCordoned(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration) error {
+	tiers := make([][]*unstructured.Unstructured, 4)
+	for _, object := range konfig.Status.CordonedObjects {
+		obj, err := r.getEntry(ctx, object.InventoryEntry)
+		if err != nil {
+			return err
+		} else if obj == nil || obj.GetLabels()[appsv1.CordonedLabel] != "true" {
+			continue
+		}
+		if tier := r.deletionTier(konfig, obj); tier >= 0 {
+			tiers[tier] = append(tiers[tier], obj)
+		}
+	}
+	if err := r.deleteTiers(ctx, log, konfig, tiers); err != nil {
+		return err
+	}
+	konfig.Status.CordonedObjects = nil
+	return nil
+}
+
+// nextCordonExpiry returns when the prune grace period of the first cordoned
+// object of the Konfiguration expires, or the zero time if none is cordoned.
+func nextCordonExpiry(konfig *appsv1.Konfiguration) time.Time {
+	var next time.Time
+	for _, object := range konfig.Status.CordonedObjects {
+		expiry := object.CordonedAt.Add(konfig.GetPruneGracePeriod())
+		if next.IsZero() || expiry.Before(next) {
+			next = expiry
+		}
+	}
+	return next
+}
+
+// cordonedAt returns when the object was cordoned, as recorded on it, and
+// whether it is cordoned.
+func cordonedAt(obj *unstructured.Unstructured) (metav1.Time, bool) {
+	if obj.GetLabels()[appsv1.CordonedLabel] != "true" {
+		return metav1.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339, obj.GetAnnotations()[appsv1.CordonedAtAnnotation])
+	if err != nil {
+		return metav1.Time{}, false
+	}
+	return metav1.NewTime(at), true
+}
+
+// cordonObject labels the object as cordoned at the given time, protects it
+// from kubecfg garbage collection and scales it to zero if it is a workload,
+// recording its replicas and garbage collection strategy to restore them.
+func cordonObject(obj *unstructured.Unstructured, now metav1.Time) error {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[appsv1.CordonedLabel] = "true"
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	// A cordoned object is no longer protected by the controller, so the
+	// protection is not restored if it is rendered again.
+	if _, ok := annotations[appsv1.ProtectedAnnotation]; ok {
+		delete(annotations, appsv1.ProtectedAnnotation)
+		delete(annotations, gcStrategyAnnotation)
+	}
+	annotations[appsv1.CordonedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	annotations[appsv1.CordonedGCStrategyAnnotation] = annotations[gcStrategyAnnotation]
+	annotations[gcStrategyAnnotation] = gcStrategyIgnore
+	if scaledWorkloadKinds[obj.GroupVersionKind().GroupKind()] {
+		replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if err != nil {
+			return err
+		}
+		if !found {
+			replicas = 1
+		}
+		annotations[appsv1.CordonedReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+		if err := unstructured.SetNestedField(obj.Object, int64(0), "spec", "replicas"); err != nil {
+			return err
+		}
+	}
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// uncordon removes the cordon of the object, restoring the replicas of
+// workloads that were not scaled since they were cordoned, and the garbage
+// collection strategy of the object unless it was changed since.
+func (r *KonfigurationReconciler) uncordon(ctx context.Context, obj *unstructured.Unstructured) error {
+	labels := obj.GetLabels()
+	delete(labels, appsv1.CordonedLabel)
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if value, ok := annotations[appsv1.CordonedReplicasAnnotation]; ok {
+		replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if err != nil {
+			return err
+		}
+		if restore, err := strconv.ParseInt(value, 10, 32); err == nil && (!found || replicas == 0) {
+			if err := unstructured.SetNestedField(obj.Object, restore, "spec", "replicas"); err != nil {
+				return err
+			}
+		}
+	}
+	if strategy, ok := annotations[appsv1.CordonedGCStrategyAnnotation]; ok && annotations[gcStrategyAnnotation] == gcStrategyIgnore {
+		if strategy == "" {
+			delete(annotations, gcStrategyAnnotation)
+		} else {
+			annotations[gcStrategyAnnotation] = strategy
+		}
+	}
+	delete(annotations, appsv1.CordonedAtAnnotation)
+	delete(annotations, appsv1.CordonedReplicasAnnotation)
+	delete(annotations, appsv1.CordonedGCStrategyAnnotation)
+	obj.SetAnnotations(annotations)
+
+	if err := r.Update(ctx, obj); err != nil {
+		return withReason(appsv1.PruneFailedReason, fmt.Errorf("failed to restore %s '%s': %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err))
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+const cordonTestTag = "team_app"
+
+// testGraceKonfiguration returns a Konfiguration pruning objects after a grace
+// period of an hour.
+func testGraceKonfiguration() *appsv1.Konfiguration {
+	konfig := testKonfiguration()
+	konfig.Spec.Prune = true
+	konfig.Spec.Timeout = &metav1.Duration{Duration: prunePollInterval}
+	konfig.Spec.PruneOptions = &appsv1.PruneOptions{GracePeriod: &metav1.Duration{Duration: time.Hour}}
+	return konfig
+}
+
+// testDeployment returns a Deployment with the given replicas tagged for
+// garbage collection by the test Konfiguration.
+func testDeployment(replicas int64, annotations ...string) *unstructured.Unstructured {
+	obj := testObject("apps/v1", "Deployment", "team", "web", cordonTestTag, annotations...)
+	unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas")
+	return obj
+}
+
+// cordoned returns the object cordoned at the given time.
+func cordoned(obj *unstructured.Unstructured, at time.Time) *unstructured.Unstructured {
+	if err := cordonObject(obj, metav1.NewTime(at)); err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// entryOf returns the inventory entry of the object.
+func entryOf(obj *unstructured.Unstructured) appsv1.InventoryEntry {
+	return appsv1.InventoryEntry{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// getObject returns the object as stored in the fake cluster.
+func getObject(t *testing.T, r *KonfigurationReconciler, obj *unstructured.Unstructured) *unstructured.Unstructured {
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), got); err != nil {
+		t.Fatalf("failed to get %s '%s': %v", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	return got
+}
+
+func TestCordonStale(t *testing.T) {
+	cordonedBefore := time.Now().Add(-30 * time.Minute).UTC().Truncate(time.Second)
+	tests := []struct {
+		name         string
+		obj          *unstructured.Unstructured
+		wantCordoned bool
+		wantAt       time.Time
+	}{
+		{
+			name:         "tagged object",
+			obj:          testDeployment(3),
+			wantCordoned: true,
+		},
+		{
+			name: "object of another Konfiguration",
+			obj:  testObject("v1", "ConfigMap", "team", "old", "team_other"),
+		},
+		{
+			name: "protected object",
+			obj:  testObject("v1", "ConfigMap", "team", "old", cordonTestTag, gcStrategyAnnotation, gcStrategyIgnore),
+		},
+		{
+			name:         "object cordoned before",
+			obj:          cordoned(testDeployment(3), cordonedBefore),
+			wantCordoned: true,
+			wantAt:       cordonedBefore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.obj)
+			konfig := testGraceKonfiguration()
+			if err := r.cordonStale(context.TODO(), logr.Discard(), konfig, []appsv1.InventoryEntry{entryOf(tt.obj)}); err != nil {
+				t.Fatalf("cordonStale() error = %v", err)
+			}
+			if got := len(konfig.Status.CordonedObjects) == 1; got != tt.wantCordoned {
+				t.Fatalf("recorded cordoned = %v, want %v", got, tt.wantCordoned)
+			}
+			obj := getObject(t, r, tt.obj)
+			if got := obj.GetLabels()[appsv1.CordonedLabel] == "true"; got != tt.wantCordoned {
+				t.Errorf("cordoned = %v, want %v", got, tt.wantCordoned)
+			}
+			if !tt.wantCordoned {
+				return
+			}
+			if !tt.wantAt.IsZero() && !konfig.Status.CordonedObjects[0].CordonedAt.Time.Equal(tt.wantAt) {
+				t.Errorf("cordoned at %s, want %s", konfig.Status.CordonedObjects[0].CordonedAt, tt.wantAt)
+			}
+			if obj.GetAnnotations()[gcStrategyAnnotation] != gcStrategyIgnore {
+				t.Errorf("cordoned object not protected from garbage collection")
+			}
+			if replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); replicas != 0 {
+				t.Errorf("replicas = %d, want 0", replicas)
+			}
+			if got := obj.GetAnnotations()[appsv1.CordonedReplicasAnnotation]; got != "3" {
+				t.Errorf("recorded replicas = %s, want 3", got)
+			}
+		})
+	}
+}
+
+func TestReconcileCordoned(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		obj          *unstructured.Unstructured
+		recordedAt   time.Time
+		rendered     bool
+		wantDeleted  bool
+		wantCordoned bool
+	}{
+		{
+			name:         "within the grace period",
+			obj:          cordoned(testDeployment(3), now),
+			recordedAt:   now,
+			wantCordoned: true,
+		},
+		{
+			name:        "grace period expired",
+			obj:         cordoned(testDeployment(3), now.Add(-2*time.Hour)),
+			recordedAt:  now.Add(-2 * time.Hour),
+			wantDeleted: true,
+		},
+		{
+			name:        "grace period expired as recorded on the object",
+			obj:         cordoned(testDeployment(3), now.Add(-2*time.Hour)),
+			recordedAt:  now,
+			wantDeleted: true,
+		},
+		{
+			name:       "rendered again",
+			obj:        cordoned(testDeployment(3), now.Add(-2*time.Hour)),
+			recordedAt: now.Add(-2 * time.Hour),
+			rendered:   true,
+		},
+		{
+			name:       "uncordoned by hand",
+			obj:        testDeployment(3),
+			recordedAt: now.Add(-2 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.obj)
+			konfig := testGraceKonfiguration()
+			konfig.Status.CordonedObjects = []appsv1.CordonedObject{{InventoryEntry: entryOf(tt.obj), CordonedAt: metav1.NewTime(tt.recordedAt)}}
+			var inventory []appsv1.InventoryEntry
+			if tt.rendered {
+				inventory = append(inventory, entryOf(tt.obj))
+			}
+			if err := r.reconcileCordoned(context.TODO(), logr.Discard(), konfig, inventory); err != nil {
+				t.Fatalf("reconcileCordoned() error = %v", err)
+			}
+			if got := !exists(t, r, tt.obj); got != tt.wantDeleted {
+				t.Fatalf("deleted = %v, want %v", got, tt.wantDeleted)
+			}
+			if got := len(konfig.Status.CordonedObjects) == 1; got != tt.wantCordoned {
+				t.Errorf("still recorded cordoned = %v, want %v", got, tt.wantCordoned)
+			}
+			if tt.wantDeleted {
+				return
+			}
+			obj := getObject(t, r, tt.obj)
+			if got := obj.GetLabels()[appsv1.CordonedLabel] == "true"; got != tt.wantCordoned {
+				t.Errorf("cordoned = %v, want %v", got, tt.wantCordoned)
+			}
+		})
+	}
+}
+
+func TestUncordon(t *testing.T) {
+	tests := []struct {
+		name         string
+		obj          *unstructured.Unstructured
+		mutate       func(obj *unstructured.Unstructured)
+		wantReplicas int64
+		wantStrategy string
+	}{
+		{
+			name:         "cordoned workload",
+			obj:          cordoned(testDeployment(3), time.Now()),
+			wantReplicas: 3,
+		},
+		{
+			name: "workload scaled while cordoned",
+			obj:  cordoned(testDeployment(3), time.Now()),
+			mutate: func(obj *unstructured.Unstructured) {
+				unstructured.SetNestedField(obj.Object, int64(5), "spec", "replicas")
+			},
+			wantReplicas: 5,
+		},
+		{
+			name:         "workload with a garbage collection strategy",
+			obj:          cordoned(testDeployment(3, gcStrategyAnnotation, "custom"), time.Now()),
+			wantReplicas: 3,
+			wantStrategy: "custom",
+		},
+		{
+			name: "workload cordoned without recording its strategy",
+			obj:  cordoned(testDeployment(3), time.Now()),
+			mutate: func(obj *unstructured.Unstructured) {
+				annotations := obj.GetAnnotations()
+				delete(annotations, appsv1.CordonedGCStrategyAnnotation)
+				obj.SetAnnotations(annotations)
+			},
+			wantReplicas: 3,
+			wantStrategy: gcStrategyIgnore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mutate != nil {
+				tt.mutate(tt.obj)
+			}
+			r := newTestReconciler(tt.obj)
+			if err := r.uncordon(context.TODO(), getObject(t, r, tt.obj)); err != nil {
+				t.Fatalf("uncordon() error = %v", err)
+			}
+			obj := getObject(t, r, tt.obj)
+			if _, ok := obj.GetLabels()[appsv1.CordonedLabel]; ok {
+				t.Errorf("object still labeled cordoned")
+			}
+			for _, key := range []string{appsv1.CordonedAtAnnotation, appsv1.CordonedReplicasAnnotation, appsv1.CordonedGCStrategyAnnotation} {
+				if _, ok := obj.GetAnnotations()[key]; ok {
+					t.Errorf("annotation %s not removed", key)
+				}
+			}
+			if got := obj.GetAnnotations()[gcStrategyAnnotation]; got != tt.wantStrategy {
+				t.Errorf("garbage collection strategy = %q, want %q", got, tt.wantStrategy)
+			}
+			if replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); replicas != tt.wantReplicas {
+				t.Errorf("replicas = %d, want %d", replicas, tt.wantReplicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// mappedClient is a fake client resolving kinds with a static mapper, since the
+// fake client has none.
+type mappedClient struct {
+	client.Client
+	mapper apimeta.RESTMapper
+}
+
+func (c mappedClient) RESTMapper() apimeta.RESTMapper { return c.mapper }
+
+// newTestReconciler returns a reconciler backed by a fake client holding the
+// given objects, knowing ConfigMaps as namespaced and ClusterRoles and
+// Namespaces as cluster-scoped kinds.
+func newTestReconciler(objs ...client.Object) *KonfigurationReconciler {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, apimeta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, apimeta.RESTScopeRoot)
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build()
+	return &KonfigurationReconciler{Client: mappedClient{Client: c, mapper: mapper}, Scheme: clientgoscheme.Scheme}
+}
+
+// testObject returns an object of the given kind tagged for garbage collection
+// with the given tag, if any, and carrying the given annotations.
+func testObject(apiVersion, kind, namespace, name, gcTag string, annotations ...string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	set := make(map[string]string)
+	if gcTag != "" {
+		set[gcTagAnnotation] = gcTag
+	}
+	for i := 0; i+1 < len(annotations); i += 2 {
+		set[annotations[i]] = annotations[i+1]
+	}
+	obj.SetAnnotations(set)
+	return obj
+}
+
+func testKonfiguration() *appsv1.Konfiguration {
+	konfig := &appsv1.Konfiguration{}
+	konfig.SetNamespace("team")
+	konfig.SetName("app")
+	return konfig
+}
+
+// exists returns whether the object still exists in the fake cluster.
+func exists(t *testing.T, r *KonfigurationReconciler, obj *unstructured.Unstructured) bool {
+	err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj.DeepCopy())
+	if apierrors.IsNotFound(err) {
+		return false
+	} else if err != nil {
+		t.Fatalf("failed to get %s '%s': %v", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	return true
+}
+
+func TestDeleteTombstones(t *testing.T) {
+	const tag = "team_app"
+	tests := []struct {
+		name          string
+		obj           *unstructured.Unstructured
+		inventory     []appsv1.InventoryEntry
+		clusterScoped bool
+		deleted       bool
+	}{
+		{
+			name:    "tagged object",
+			obj:     testObject("v1", "ConfigMap", "team", "old", tag),
+			deleted: true,
+		},
+		{
+			name: "object of another Konfiguration",
+			obj:  testObject("v1", "ConfigMap", "team", "old", "team_other"),
+		},
+		{
+			name: "untagged object",
+			obj:  testObject("v1", "ConfigMap", "team", "old", ""),
+		},
+		{
+			name: "protected object",
+			obj:  testObject("v1", "ConfigMap", "team", "old", tag, gcStrategyAnnotation, gcStrategyIgnore),
+		},
+		{
+			name:      "object still rendered",
+			obj:       testObject("v1", "ConfigMap", "team", "old", tag),
+			inventory: []appsv1.InventoryEntry{{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "old"}},
+		},
+		{
+			name: "cluster-scoped object without cluster-scoped pruning",
+			obj:  testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old", tag),
+		},
+		{
+			name:          "cluster-scoped object with cluster-scoped pruning",
+			obj:           testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old", tag),
+			clusterScoped: true,
+			deleted:       true,
+		},
+		{
+			name: "cluster-scoped object protected by the controller",
+			obj:  testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old", tag, gcStrategyAnnotation, gcStrategyIgnore, appsv1.ProtectedAnnotation, "true"),
+		},
+		{
+			name:          "cluster-scoped object protected by the controller with cluster-scoped pruning",
+			obj:           testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old", tag, gcStrategyAnnotation, gcStrategyIgnore, appsv1.ProtectedAnnotation, "true"),
+			clusterScoped: true,
+			deleted:       true,
+		},
+		{
+			name:          "cluster-scoped object protected by the manifests with cluster-scoped pruning",
+			obj:           testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old", tag, gcStrategyAnnotation, gcStrategyIgnore),
+			clusterScoped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.obj)
+			konfig := testKonfiguration()
+			konfig.Spec.Timeout = &metav1.Duration{Duration: prunePollInterval}
+			konfig.Spec.PruneOptions = &appsv1.PruneOptions{ClusterScoped: tt.clusterScoped}
+			konfig.Spec.Deletes = []appsv1.ObjectDeletion{{APIVersion: tt.obj.GetAPIVersion(), Kind: tt.obj.GetKind(), Name: tt.obj.GetName()}}
+			if err := r.deleteTombstones(context.TODO(), logr.Discard(), konfig, tt.inventory); err != nil {
+				t.Fatalf("deleteTombstones() error = %v", err)
+			}
+			if got := !exists(t, r, tt.obj); got != tt.deleted {
+				t.Errorf("deleted = %v, want %v", got, tt.deleted)
+			}
+		})
+	}
+}
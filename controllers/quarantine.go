@@ -0,0 +1,148 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// unsafeArtifactError is returned for a downloaded artifact that cannot be
+// extracted safely. Since the artifact was downloaded in full, extracting it
+// fails the same way on every attempt.
+type unsafeArtifactError struct {
+	err error
+}
+
+func (e *unsafeArtifactError) Error() string { return "unsafe artifact: " + e.err.Error() }
+
+func (e *unsafeArtifactError) Unwrap() error { return e.err }
+
+// inspectArtifact reads the gzip-compressed tarball from r and returns an
+// unsafeArtifactError if it is corrupt, or if any of its entries would be
+// extracted outside of the extraction directory, is a link, or is of a type
+// that is not extracted.
+func inspectArtifact(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return &unsafeArtifactError{fmt.Errorf("not gzip-compressed: %w", err)}
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return &unsafeArtifactError{fmt.Errorf("corrupt tarball: %w", err)}
+		}
+		if escapesDir(hdr.Name) {
+			return &unsafeArtifactError{fmt.Errorf("entry '%s' escapes the artifact directory", hdr.Name)}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeDir:
+		case tar.TypeSymlink, tar.TypeLink:
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink && !path.IsAbs(target) {
+				target = path.Join(path.Dir(hdr.Name), target)
+			}
+			if escapesDir(target) {
+				return &unsafeArtifactError{fmt.Errorf("link '%s' to '%s' escapes the artifact directory", hdr.Name, hdr.Linkname)}
+			}
+			return &unsafeArtifactError{fmt.Errorf("link '%s' is not supported", hdr.Name)}
+		default:
+			return &unsafeArtifactError{fmt.Errorf("entry '%s' has unsupported type '%c'", hdr.Name, hdr.Typeflag)}
+		}
+		if _, err := io.Copy(ioutil.Discard, tr); err != nil {
+			return &unsafeArtifactError{fmt.Errorf("corrupt tarball: %w", err)}
+		}
+	}
+}
+
+// checksumHash returns the hash the given artifact checksum was computed with,
+// and the checksum without any algorithm prefix, telling the algorithm by the
+// length of the hex digest unless prefixed with it, e.g. 'sha256:<digest>'.
+// It returns false if the algorithm cannot be told.
+func checksumHash(checksum string) (hash.Hash, string, bool) {
+	algorithm, digest := "", checksum
+	if i := strings.Index(checksum, ":"); i >= 0 {
+		algorithm, digest = checksum[:i], checksum[i+1:]
+	}
+	switch {
+	case algorithm == "sha256" || algorithm == "" && len(digest) == sha256.Size*2:
+		return sha256.New(), digest, true
+	case algorithm == "sha1" || algorithm == "" && len(digest) == sha1.Size*2:
+		return sha1.New(), digest, true
+	}
+	return nil, "", false
+}
+
+// escapesDir returns true if the tarball entry with the given name is not
+// below the directory it is extracted to.
+func escapesDir(name string) bool {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) {
+		return true
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQuarantine returns an error if the given revision of the source of the
+// Konfiguration is quarantined.
+func checkQuarantine(konfig *appsv1.Konfiguration, revision string) error {
+	if konfig.Status.QuarantinedRevision == "" || konfig.Status.QuarantinedRevision != revision {
+		return nil
+	}
+	return withReason(appsv1.ArtifactQuarantinedReason,
+		fmt.Errorf("revision '%s' is quarantined, waiting for a new revision of the source", revision))
+}
+
+// quarantineRevision quarantines the given revision of the source of the
+// Konfiguration, whose artifact failed inspection with err, so it is not
+// downloaded again.
+func (r *KonfigurationReconciler) quarantineRevision(konfig *appsv1.Konfiguration, revision string, err error) error {
+	konfig.Status.QuarantinedRevision = revision
+	meta.SetResourceCondition(konfig, appsv1.QuarantinedCondition, metav1.ConditionTrue, appsv1.UnsafeArtifactReason,
+		fmt.Sprintf("Revision '%s' quarantined: %s", revision, err))
+	r.recorder.Eventf(konfig, corev1.EventTypeWarning, appsv1.UnsafeArtifactReason, "Revision '%s' quarantined: %s", revision, err)
+	return withReason(appsv1.ArtifactQuarantinedReason, err)
+}
+
+// releaseQuarantine lifts the quarantine of the Konfiguration once a new
+// revision of its source is extracted.
+func releaseQuarantine(konfig *appsv1.Konfiguration) {
+	konfig.Status.QuarantinedRevision = ""
+	apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.QuarantinedCondition)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testArtifact returns a gzip-compressed tarball of the given entries.
+func testArtifact(t *testing.T, entries ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, hdr := range entries {
+		var data []byte
+		if hdr.Typeflag == tar.TypeReg {
+			data = []byte("{}")
+			hdr.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectArtifact(t *testing.T) {
+	tests := []struct {
+		name     string
+		artifact func(t *testing.T) []byte
+		unsafe   bool
+	}{
+		{
+			name: "files and directories",
+			artifact: func(t *testing.T) []byte {
+				return testArtifact(t,
+					&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0755},
+					&tar.Header{Name: "app/main.jsonnet", Typeflag: tar.TypeReg, Mode: 0644})
+			},
+		},
+		{
+			name:     "not gzip-compressed",
+			artifact: func(t *testing.T) []byte { return []byte("main.jsonnet") },
+			unsafe:   true,
+		},
+		{
+			name: "corrupt tarball",
+			artifact: func(t *testing.T) []byte {
+				data := testArtifact(t, &tar.Header{Name: "main.jsonnet", Typeflag: tar.TypeReg, Mode: 0644})
+				var buf bytes.Buffer
+				zw := gzip.NewWriter(&buf)
+				zw.Write(data[:len(data)/2])
+				zw.Close()
+				return buf.Bytes()
+			},
+			unsafe: true,
+		},
+		{
+			name: "entry escaping the directory",
+			artifact: func(t *testing.T) []byte {
+				return testArtifact(t, &tar.Header{Name: "../main.jsonnet", Typeflag: tar.TypeReg, Mode: 0644})
+			},
+			unsafe: true,
+		},
+		{
+			name: "symlink",
+			artifact: func(t *testing.T) []byte {
+				return testArtifact(t, &tar.Header{Name: "app/lib", Linkname: "../lib", Typeflag: tar.TypeSymlink})
+			},
+			unsafe: true,
+		},
+		{
+			name: "symlink escaping the directory",
+			artifact: func(t *testing.T) []byte {
+				return testArtifact(t, &tar.Header{Name: "app/lib", Linkname: "../../lib", Typeflag: tar.TypeSymlink})
+			},
+			unsafe: true,
+		},
+		{
+			name: "device",
+			artifact: func(t *testing.T) []byte {
+				return testArtifact(t, &tar.Header{Name: "null", Typeflag: tar.TypeChar})
+			},
+			unsafe: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := inspectArtifact(bytes.NewReader(tt.artifact(t)))
+			var unsafe *unsafeArtifactError
+			if got := errors.As(err, &unsafe); got != tt.unsafe {
+				t.Errorf("inspectArtifact() error = %v, unsafe %v", err, tt.unsafe)
+			}
+		})
+	}
+}
+
+func TestEscapesDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "main.jsonnet"},
+		{name: "app/main.jsonnet"},
+		{name: "./app/main.jsonnet"},
+		{name: "app/..data/main.jsonnet"},
+		{name: "", want: true},
+		{name: "/etc/passwd", want: true},
+		{name: "../main.jsonnet", want: true},
+		{name: "app/../../main.jsonnet", want: true},
+		{name: `app\..\main.jsonnet`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapesDir(tt.name); got != tt.want {
+				t.Errorf("escapesDir(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumHash(t *testing.T) {
+	data := []byte("artifact")
+	sha1Sum := fmt.Sprintf("%x", sha1.Sum(data))
+	sha256Sum := fmt.Sprintf("%x", sha256.Sum256(data))
+	tests := []struct {
+		name     string
+		checksum string
+		want     string
+		ok       bool
+	}{
+		{name: "sha1 digest", checksum: sha1Sum, want: sha1Sum, ok: true},
+		{name: "sha256 digest", checksum: sha256Sum, want: sha256Sum, ok: true},
+		{name: "prefixed sha256 digest", checksum: "sha256:" + sha256Sum, want: sha256Sum, ok: true},
+		{name: "prefixed sha1 digest", checksum: "sha1:" + sha1Sum, want: sha1Sum, ok: true},
+		{name: "no checksum"},
+		{name: "unknown algorithm", checksum: "sha512:" + sha256Sum},
+		{name: "unknown length", checksum: sha256Sum[:32]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, digest, ok := checksumHash(tt.checksum)
+			if ok != tt.ok {
+				t.Fatalf("checksumHash() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			hash.Write(data)
+			if got := fmt.Sprintf("%x", hash.Sum(nil)); got != tt.want || digest != tt.want {
+				t.Errorf("checksumHash() hashed %s with digest %s, want %s", got, digest, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// quotaLimits are the limits of what the Konfigurations of a namespace may
+// manage in total. Zero values are unlimited.
+type quotaLimits struct {
+	maxObjects       int
+	maxClusterScoped int
+	maxTargets       int
+}
+
+func (q quotaLimits) unlimited() bool {
+	return q.maxObjects == 0 && q.maxClusterScoped == 0 && q.maxTargets == 0
+}
+
+// quotaLimitsFor returns the quotas of the given namespace, as overridden by
+// its annotations. Namespace annotations are not read when running
+// namespace-scoped.
+func (r *KonfigurationReconciler) quotaLimitsFor(ctx context.Context, namespace string) (quotaLimits, error) {
+	limits := r.settings.get().quotas
+	if r.namespaceScoped {
+		return limits, nil
+	}
+	ns, err := r.getNamespace(ctx, namespace)
+	if err != nil || ns == nil {
+		return limits, err
+	}
+	for annotation, limit := range map[string]*int{
+		appsv1.MaxObjectsAnnotation:              &limits.maxObjects,
+		appsv1.MaxClusterScopedObjectsAnnotation: &limits.maxClusterScoped,
+		appsv1.MaxTargetsAnnotation:              &limits.maxTargets,
+	} {
+		value, ok := ns.GetAnnotations()[annotation]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return limits, fmt.Errorf("invalid %s annotation on namespace '%s': %q", annotation, namespace, value)
+		}
+		*limit = n
+	}
+	return limits, nil
+}
+
+// checkQuota returns an error with the QuotaExceededReason if applying the
+// given inventory and targets of the Konfiguration would exceed a quota of its
+// namespace. The usage of the other Konfigurations of the namespace is read
+// from their status, as of their last successful reconciliation.
+func (r *KonfigurationReconciler) checkQuota(ctx context.Context, konfig *appsv1.Konfiguration, inventory []appsv1.InventoryEntry) error {
+	limits, err := r.quotaLimitsFor(ctx, konfig.GetNamespace())
+	if err != nil || limits.unlimited() {
+		return err
+	}
+
+	objects, clusterScoped, targets := len(inventory), countClusterScoped(inventory), len(konfig.Spec.Targets)
+	var list appsv1.KonfigurationList
+	if err := r.List(ctx, &list, client.InNamespace(konfig.GetNamespace())); err != nil {
+		return err
+	}
+	for _, other := range list.Items {
+		if other.GetName() == konfig.GetName() {
+			continue
+		}
+		objects += int(other.Status.InventoryCount)
+		clusterScoped += int(other.Status.ClusterScopedCount)
+		targets += len(other.Spec.Targets)
+	}
+
+	for _, quota := range []struct {
+		what         string
+		usage, limit int
+	}{
+		{"objects", objects, limits.maxObjects},
+		{"cluster-scoped objects", clusterScoped, limits.maxClusterScoped},
+		{"target clusters", targets, limits.maxTargets},
+	} {
+		if quota.limit > 0 && quota.usage > quota.limit {
+			return withReason(appsv1.QuotaExceededReason, fmt.Errorf("the Konfigurations of namespace '%s' would manage %d %s, exceeding the quota of %d",
+				konfig.GetNamespace(), quota.usage, quota.what, quota.limit))
+		}
+	}
+	return nil
+}
+
+// countClusterScoped returns the number of cluster-scoped objects in the given
+// inventory.
+func countClusterScoped(inventory []appsv1.InventoryEntry) int {
+	var n int
+	for _, entry := range inventory {
+		if entry.Namespace == "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,474 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-retryablehttp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// maxEvaluationTraceSize is the maximum size of the std.trace output recorded
+// in the status of a Konfiguration.
+const maxEvaluationTraceSize = 4096
+
+// renderedManifests are the manifests rendered for a Konfiguration, written to
+// a file ready to be applied.
+type renderedManifests struct {
+	path     string
+	checksum string
+	snapshot *appsv1.Snapshot
+	// tests are the test Jobs to run after the manifests are applied.
+	tests []*unstructured.Unstructured
+	// inventory lists the objects in the manifests.
+	inventory []appsv1.InventoryEntry
+	// digests are the checksums of the objects, in the order of inventory.
+	digests []string
+}
+
+// prepareManifests renders the manifests at path, modifies them as configured
+// by the Konfiguration and writes them to a file to be applied. The caller is
+// responsible for removing the file.
+func (r *KonfigurationReconciler) prepareManifests(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) (*renderedManifests, error) {
+	objs, err := r.renderObjects(ctx, log, konfig, path, revision)
+	if err != nil {
+		return nil, err
+	}
+	return r.prepareObjects(ctx, log, konfig, objs)
+}
+
+// renderObjects renders the manifests at path and runs the transformers of the
+// Konfiguration over them. The objects only depend on the sources and the spec,
+// not on the cluster they are applied to.
+func (r *KonfigurationReconciler) renderObjects(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) ([]*unstructured.Unstructured, error) {
+	objs, err := r.renderManifests(ctx, log, konfig, path, revision)
+	if err != nil {
+		return nil, err
+	}
+	return r.transformManifests(ctx, log, konfig, objs)
+}
+
+// prepareObjects modifies the rendered objects as configured by the
+// Konfiguration and writes them to a file to be applied. The caller is
+// responsible for removing the file.
+func (r *KonfigurationReconciler) prepareObjects(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) (*renderedManifests, error) {
+	var err error
+	objs, konfig.Status.SkippedObjects = skipObjects(log, konfig, objs)
+
+	var tests []*unstructured.Unstructured
+	if konfig.TestHooksEnabled() {
+		objs, tests = splitTestHooks(objs)
+	}
+	if konfig.Status.PausedObjects, err = r.adaptManifests(ctx, log, konfig, objs); err != nil {
+		return nil, err
+	}
+
+	// Fail early if the quotas of the namespace would be exceeded, or the
+	// manifests cannot be applied to the cluster
+	inventory := r.inventoryEntries(konfig, objs)
+	if err := r.checkQuota(ctx, konfig, inventory); err != nil {
+		return nil, err
+	}
+	if err := r.preflight(ctx, log, konfig, objs); err != nil {
+		return nil, err
+	}
+
+	manifests, err := r.writeManifests(konfig, objs)
+	if err != nil {
+		return nil, err
+	}
+	manifests.tests = tests
+	manifests.inventory = inventory
+	return manifests, nil
+}
+
+// adaptManifests modifies the rendered objects as configured by the
+// Konfiguration for the cluster they are applied to, the one the clients of
+// the reconciler talk to. It returns a description of each object left as it
+// is because its live object is paused.
+func (r *KonfigurationReconciler) adaptManifests(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) ([]string, error) {
+	if konfig.IgnoreHPAReplicasEnabled() {
+		if err := r.releaseHPAReplicas(ctx, log, konfig, objs); err != nil {
+			return nil, err
+		}
+	}
+	if konfig.IsHibernating() {
+		if err := hibernateWorkloads(log, objs); err != nil {
+			return nil, err
+		}
+	}
+	if konfig.PodTemplateHashEnabled() {
+		if err := annotatePodTemplates(log, konfig, objs); err != nil {
+			return nil, err
+		}
+	}
+	if konfig.GCEnabled() {
+		r.protectClusterScoped(log, konfig, objs)
+	}
+	if r.settings.get().auditAnnotations {
+		annotateAudit(konfig, objs)
+	}
+	return r.holdPausedObjects(ctx, log, konfig, objs)
+}
+
+// preflight fails if any permissions needed to apply the objects to the
+// cluster the clients of the reconciler talk to are missing, or rendered
+// workloads cannot be scheduled on it, as far as the Konfiguration enables
+// these checks.
+func (r *KonfigurationReconciler) preflight(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) error {
+	if konfig.PermissionsCheckEnabled() {
+		if err := r.checkPermissions(ctx, log, konfig, objs); err != nil {
+			return err
+		}
+	}
+	if konfig.SchedulingCheckEnabled() {
+		return r.checkScheduling(ctx, log, objs)
+	}
+	return nil
+}
+
+// skipObjects removes the objects of kinds the Konfiguration skips from the
+// rendered objects. It returns the remaining objects and a description of each
+// skipped one.
+func skipObjects(log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, []string) {
+	kept := objs[:0]
+	var skipped []string
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		if !konfig.SkipsKind(gvk.GroupKind()) {
+			kept = append(kept, obj)
+			continue
+		}
+		log.Info("Skipping object of skipped kind", "Kind", gvk.Kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		skipped = append(skipped, fmt.Sprintf("%s '%s'", gvk.Kind, client.ObjectKeyFromObject(obj)))
+	}
+	return kept, skipped
+}
+
+// renderManifests evaluates the manifests at path and decodes the resulting
+// objects. Plain YAML and JSON entrypoints are decoded directly as a stream
+// of documents rather than being evaluated by kubecfg. The std.trace output of
+// the evaluation, and any variables it failed to resolve, are recorded on the
+// Konfiguration.
+func (r *KonfigurationReconciler) renderManifests(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) ([]*unstructured.Unstructured, error) {
+	if isPlainManifest(path) {
+		log.Info("Decoding plain manifests", "Path", path)
+		konfig.Status.LastEvaluationTrace = ""
+		recordVariables(konfig, nil)
+		rc, err := r.openManifests(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return decodeManifests(rc)
+	}
+
+	secrets, err := r.resolveSecretProviderVars(ctx, konfig)
+	if err != nil {
+		return nil, recordVariables(konfig, err)
+	}
+	secrets, err = r.resolveSealedVars(konfig, secrets)
+	if err != nil {
+		return nil, recordVariables(konfig, err)
+	}
+	rk, cleanup, err := r.withPreviousManifests(ctx, withReconcileVars(konfig, revision, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	rk, removeVars, err := r.withSecretVars(rk, secrets)
+	if err != nil {
+		return nil, err
+	}
+	defer removeVars()
+	rk, removeConfigMapVars, err := r.withConfigMapVars(ctx, rk)
+	if err != nil {
+		return nil, recordVariables(konfig, err)
+	}
+	defer removeConfigMapVars()
+	libDirs, removeLibs, err := r.jsonnetLibDirs(ctx, rk)
+	if err != nil {
+		return nil, err
+	}
+	defer removeLibs()
+	if konfig.YAMLStreamEnabled() {
+		wrapper, removeWrapper, err := r.yamlStreamEntrypoint(rk, path)
+		if err != nil {
+			return nil, err
+		}
+		defer removeWrapper()
+		path = wrapper
+	}
+	var objs []*unstructured.Unstructured
+	trace, err := evaluateManifests(ctx, log, rk, libDirs, path, func(out io.Reader) (err error) {
+		objs, err = decodeManifests(out)
+		return err
+	})
+	r.recordEvaluationTrace(konfig, trace)
+	return objs, recordVariables(konfig, missingVariables(err))
+}
+
+// recordEvaluationTrace records the std.trace output of the last evaluation
+// of the Konfiguration in its status, keeping only its end if it is longer
+// than maxEvaluationTraceSize. New output is also reported as an event.
+func (r *KonfigurationReconciler) recordEvaluationTrace(konfig *appsv1.Konfiguration, trace string) {
+	if len(trace) > maxEvaluationTraceSize {
+		trace = "..." + trace[len(trace)-maxEvaluationTraceSize:]
+	}
+	if trace != "" && trace != konfig.Status.LastEvaluationTrace {
+		r.recorder.Event(konfig, corev1.EventTypeNormal, appsv1.EvaluationTraceReason, trace)
+	}
+	konfig.Status.LastEvaluationTrace = trace
+}
+
+// withReconcileVars returns a copy of the Konfiguration that passes the
+// revision being rendered and the metadata of the reconciliation to kubecfg in
+// external variables, so the manifests can record them, e.g. in version labels.
+// The variables are always set so the manifests can read them unconditionally.
+func withReconcileVars(konfig *appsv1.Konfiguration, revision string, now time.Time) *appsv1.Konfiguration {
+	var reconcileTime string
+	if konfig.Spec.ReconcileTimeVar {
+		reconcileTime = now.UTC().Format(time.RFC3339)
+	}
+	rk := konfig.DeepCopy()
+	rk.Spec.KubecfgArgs = append(rk.Spec.KubecfgArgs,
+		"--ext-str", fmt.Sprintf("%s=%s", appsv1.RevisionExtVar, revision),
+		"--ext-str", fmt.Sprintf("%s=%s", appsv1.KonfigurationNameExtVar, konfig.GetName()),
+		"--ext-str", fmt.Sprintf("%s=%s", appsv1.NamespaceExtVar, konfig.GetNamespace()),
+		"--ext-str", fmt.Sprintf("%s=%s", appsv1.ReconcileTimeExtVar, reconcileTime),
+	)
+	return rk
+}
+
+// resolveEntrypoint returns the entrypoint to evaluate for the given path. If
+// the path is a local directory, the first of the given file names found in it
+// is returned, or else its only Jsonnet file. Other paths are returned as is.
+func resolveEntrypoint(path string, entrypoints []string) (string, error) {
+	if httpPathRegex.MatchString(path) {
+		return path, nil
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return path, nil
+	}
+	for _, name := range entrypoints {
+		candidate, err := securejoin.SecureJoin(path, name)
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	candidates, err := filepath.Glob(filepath.Join(path, "*.jsonnet"))
+	if err != nil {
+		return "", err
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("path is a directory without any of the entrypoints [%s]", strings.Join(entrypoints, ", "))
+	case 1:
+		return candidates[0], nil
+	}
+	for i := range candidates {
+		candidates[i] = filepath.Base(candidates[i])
+	}
+	return "", fmt.Errorf("path is a directory without any of the entrypoints [%s] and with several candidates [%s], set the path to one of them or list it in entrypoints",
+		strings.Join(entrypoints, ", "), strings.Join(candidates, ", "))
+}
+
+// isPlainManifest returns true if the path refers to a YAML or JSON file that
+// does not need to be evaluated.
+func isPlainManifest(path string) bool {
+	if u, err := url.Parse(path); err == nil && httpPathRegex.MatchString(path) {
+		path = u.Path
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// openManifests opens the file at path, downloading it if it is a http(s) URL.
+func (r *KonfigurationReconciler) openManifests(ctx context.Context, path string) (io.ReadCloser, error) {
+	if !httpPathRegex.MatchString(path) {
+		return os.Open(path)
+	}
+	req, err := retryablehttp.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new request: %w", err)
+	}
+	resp, err := r.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifests, error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download manifests from %s, status: %s", path, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// decodeManifests decodes a stream of YAML or JSON documents into Kubernetes
+// objects. Each document may hold a single object, a List, an array, or
+// arbitrarily nested maps and arrays of these, which are flattened in a
+// deterministic order: arrays in order and maps by key.
+func decodeManifests(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for doc := 0; ; doc++ {
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifests: document %d: %w", doc, err)
+		}
+		var err error
+		objs, err = flattenObjects(objs, v, "$")
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifests: document %d: %w", doc, err)
+		}
+	}
+	return objs, nil
+}
+
+// flattenObjects appends the Kubernetes objects found in v to objs. The path
+// of v within its document is used to point at the offending field when a
+// value is not a Kubernetes object.
+func flattenObjects(objs []*unstructured.Unstructured, v interface{}, path string) ([]*unstructured.Unstructured, error) {
+	switch val := v.(type) {
+	case nil:
+		return objs, nil
+	case []interface{}:
+		var err error
+		for i, item := range val {
+			if objs, err = flattenObjects(objs, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return objs, nil
+	case map[string]interface{}:
+		_, hasAPIVersion := val["apiVersion"]
+		_, hasKind := val["kind"]
+		if !hasAPIVersion && !hasKind {
+			// A map of objects, e.g. a jsonnet object holding a field per
+			// component.
+			keys := make([]string, 0, len(val))
+			for key := range val {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			var err error
+			for _, key := range keys {
+				if objs, err = flattenObjects(objs, val[key], fieldPath(path, key)); err != nil {
+					return nil, err
+				}
+			}
+			return objs, nil
+		}
+		obj := &unstructured.Unstructured{Object: val}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+			return nil, fmt.Errorf("%s: object must have a string apiVersion and kind", path)
+		}
+		if items, ok := val["items"].([]interface{}); ok && strings.HasSuffix(obj.GetKind(), "List") {
+			return flattenObjects(objs, items, fieldPath(path, "items"))
+		}
+		return append(objs, obj), nil
+	default:
+		return nil, fmt.Errorf("%s: expected a Kubernetes object, found %s", path, describeValue(val))
+	}
+}
+
+// identifierRegex matches map keys that can be written as .key in a field path.
+var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// fieldPath returns the path of the given key of the map at path.
+func fieldPath(path, key string) string {
+	if identifierRegex.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%q]", path, key)
+}
+
+// describeValue returns a short description of a non-object value for errors.
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case float64, int64:
+		return fmt.Sprintf("number %v", v)
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// writeManifests writes the given objects to a new YAML file in the artifact
+// cache directory. Objects are written one at a time, so the manifests are
+// not held in memory in addition to the objects.
+func (r *KonfigurationReconciler) writeManifests(konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) (*renderedManifests, error) {
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	w := bufio.NewWriter(io.MultiWriter(f, hash))
+	digests := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+		w.WriteString("---\n")
+		w.Write(data)
+		digests = append(digests, fmt.Sprintf("%x", sha1.Sum(data)))
+	}
+	if err := w.Flush(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	checksum := fmt.Sprintf("%x", hash.Sum(nil))
+	return &renderedManifests{path: f.Name(), checksum: checksum, digests: digests, snapshot: appsv1.NewSnapshotForObjects(objs, checksum)}, nil
+}
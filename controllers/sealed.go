@@ -0,0 +1,146 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// sealingCertPath is the path of the metrics endpoint the certificate sealed
+// variables are encrypted against is served on, as done by sealed-secrets.
+const sealingCertPath = "/v1/cert.pem"
+
+// resolveSealedVars unseals the sealed variables of the Konfiguration, adding
+// their values to the given values of external variables kubecfg reads from
+// files. The keypair is read from
+// the sealing key directory on every call, so it can be rotated by updating
+// the Secret mounted there.
+func (r *KonfigurationReconciler) resolveSealedVars(konfig *appsv1.Konfiguration, values map[string][]byte) (map[string][]byte, error) {
+	vars := konfig.GetVariables()
+	if vars == nil || len(vars.Sealed) == 0 {
+		return values, nil
+	}
+	names := make([]string, 0, len(vars.Sealed))
+	for name := range vars.Sealed {
+		names = append(names, name)
+	}
+	if r.sealingKeyDir == "" {
+		return nil, unresolvedVariables(names, fmt.Errorf("sealed variables are not enabled on this controller"))
+	}
+	key, err := readSealingKey(filepath.Join(r.sealingKeyDir, corev1.TLSPrivateKeyKey))
+	if err != nil {
+		return nil, unresolvedVariables(names, err)
+	}
+
+	label := []byte(fmt.Sprintf("%s/%s", konfig.GetNamespace(), konfig.GetName()))
+	if values == nil {
+		values = make(map[string][]byte, len(vars.Sealed))
+	}
+	for name, sealed := range vars.Sealed {
+		value, err := unseal(key, sealed, label)
+		if err != nil {
+			return nil, unresolvedVariables([]string{name}, fmt.Errorf("failed to unseal '%s': %w", name, err))
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// readSealingKey reads the PEM encoded RSA private key at the given path, in
+// either PKCS #1 or PKCS #8 form.
+func readSealingKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the sealing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the sealing key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the sealing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the sealing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// unseal decrypts a base64 encoded value sealed by sealed-secrets: a session
+// key encrypted with RSA-OAEP, prefixed with its big-endian uint16 length,
+// followed by the value encrypted with AES-GCM using the session key and a
+// zero nonce.
+func unseal(key *rsa.PrivateKey, sealed string, label []byte) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 2 {
+		return nil, errors.New("sealed value is too short")
+	}
+	keyLen := int(binary.BigEndian.Uint16(ciphertext))
+	if len(ciphertext) < 2+keyLen {
+		return nil, errors.New("sealed value is too short")
+	}
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+keyLen], label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[2+keyLen:], nil)
+}
+
+// serveSealingCert serves the certificate sealed variables are encrypted
+// against.
+func (r *KonfigurationReconciler) serveSealingCert(w http.ResponseWriter, req *http.Request) {
+	cert, err := ioutil.ReadFile(filepath.Join(r.sealingKeyDir, corev1.TLSCertKey))
+	if err != nil {
+		http.Error(w, "sealing certificate not available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(cert)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// resolveSecretProviderVars reads the values of the external variables the
+// Konfiguration resolves from secret providers. Each provider is expected to
+// be mounted in a directory of its name under the secret provider directory,
+// with a file per object, as done by the Secrets Store CSI driver, and must be
+// allowed by the SecretProvidersAnnotation of the namespace of the
+// Konfiguration.
+func (r *KonfigurationReconciler) resolveSecretProviderVars(ctx context.Context, konfig *appsv1.Konfiguration) (map[string][]byte, error) {
+	vars := konfig.GetVariables()
+	if vars == nil || len(vars.ExtStrFromSecretProvider) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(vars.ExtStrFromSecretProvider))
+	for name := range vars.ExtStrFromSecretProvider {
+		names = append(names, name)
+	}
+	if r.secretProviderDir == "" {
+		return nil, unresolvedVariables(names, fmt.Errorf("variables from secret providers are not enabled on this controller"))
+	}
+	allowed, err := r.allowedSecretProviders(ctx, konfig.GetNamespace())
+	if err != nil {
+		return nil, unresolvedVariables(names, err)
+	}
+
+	values := make(map[string][]byte, len(vars.ExtStrFromSecretProvider))
+	for name, ref := range vars.ExtStrFromSecretProvider {
+		if !allowed[ref.Provider] {
+			return nil, unresolvedVariables([]string{name},
+				fmt.Errorf("secret provider '%s' is not allowed in namespace '%s' by its %s annotation", ref.Provider, konfig.GetNamespace(), appsv1.SecretProvidersAnnotation))
+		}
+		path, err := securejoin.SecureJoin(r.secretProviderDir, filepath.Join(ref.Provider, ref.Object))
+		if err != nil {
+			return nil, err
+		}
+		value, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, unresolvedVariables([]string{name},
+				fmt.Errorf("failed to resolve '%s' from object '%s' of secret provider '%s': %w", name, ref.Object, ref.Provider, err))
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// allowedSecretProviders returns the secret providers the Konfigurations of
+// the given namespace may read, as listed by its SecretProvidersAnnotation.
+func (r *KonfigurationReconciler) allowedSecretProviders(ctx context.Context, namespace string) (map[string]bool, error) {
+	ns, err := r.getNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, fmt.Errorf("the controller cannot read namespace '%s' to authorize its secret providers", namespace)
+	}
+	allowed := make(map[string]bool)
+	for _, provider := range strings.Split(ns.GetAnnotations()[appsv1.SecretProvidersAnnotation], ",") {
+		if provider = strings.TrimSpace(provider); provider != "" {
+			allowed[provider] = true
+		}
+	}
+	return allowed, nil
+}
+
+// withSecretVars returns a copy of the Konfiguration that passes the given
+// values of external variables to kubecfg from files, along with a function
+// removing them. Values are not passed through the environment of kubecfg, so
+// they cannot override its configuration, such as its PATH or proxy.
+func (r *KonfigurationReconciler) withSecretVars(konfig *appsv1.Konfiguration, values map[string][]byte) (*appsv1.Konfiguration, func(), error) {
+	if len(values) == 0 {
+		return konfig, func() {}, nil
+	}
+	dir, err := ioutil.TempDir(r.artifacts.root, konfig.GetName()+"-vars-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	sk := konfig.DeepCopy()
+	i := 0
+	for name, value := range values {
+		path := filepath.Join(dir, fmt.Sprint(i))
+		if err := ioutil.WriteFile(path, value, 0600); err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, err
+		}
+		sk.Spec.KubecfgArgs = append(sk.Spec.KubecfgArgs, "--ext-str-file", fmt.Sprintf("%s=%s", name, path))
+		i++
+	}
+	return sk, func() { os.RemoveAll(dir) }, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestResolveSecretProviderVars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret-providers-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "vault"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "vault", "password"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		allowed  string
+		provider string
+		wantErr  bool
+	}{
+		{name: "allowed provider", allowed: "other, vault", provider: "vault"},
+		{name: "provider not allowed", allowed: "other", provider: "vault", wantErr: true},
+		{name: "no allowed provider", provider: "vault", wantErr: true},
+		{name: "missing object", allowed: "vault", provider: "vault/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+			if tt.allowed != "" {
+				ns.SetAnnotations(map[string]string{appsv1.SecretProvidersAnnotation: tt.allowed})
+			}
+			r := newTestReconciler()
+			r.clientset = kubefake.NewSimpleClientset(ns)
+			r.secretProviderDir = dir
+			konfig := testKonfiguration()
+			konfig.Spec.Variables = &appsv1.Variables{
+				ExtStrFromSecretProvider: map[string]appsv1.SecretProviderRef{
+					"PATH": {Provider: tt.provider, Object: "password"},
+				},
+			}
+			values, err := r.resolveSecretProviderVars(context.TODO(), konfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSecretProviderVars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(values["PATH"]) != "secret" {
+				t.Errorf("resolveSecretProviderVars() = %q, want %q", values["PATH"], "secret")
+			}
+		})
+	}
+}
+
+func TestWithSecretVars(t *testing.T) {
+	root, err := ioutil.TempDir("", "artifacts-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	r := newTestReconciler()
+	r.artifacts = newArtifactCache(root)
+
+	sk, cleanup, err := r.withSecretVars(testKonfiguration(), map[string][]byte{"PATH": []byte("secret")})
+	if err != nil {
+		t.Fatalf("withSecretVars() error = %v", err)
+	}
+	args := sk.Spec.KubecfgArgs
+	if len(args) != 2 || args[0] != "--ext-str-file" || !strings.HasPrefix(args[1], "PATH=") {
+		t.Fatalf("withSecretVars() args = %v", args)
+	}
+	path := strings.TrimPrefix(args[1], "PATH=")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "secret" {
+		t.Errorf("value = %q, want %q", data, "secret")
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("value file not removed: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// serverSideApplyFieldManager is the field manager of the objects applied
+// with server-side apply.
+const serverSideApplyFieldManager = "kubecfg-operator"
+
+// conflictManagerRegex extracts the field manager from the causes of a
+// server-side apply conflict.
+var conflictManagerRegex = regexp.MustCompile(`conflict with "([^"]+)"`)
+
+// update applies the manifests at path, with server-side apply if the
+// Konfiguration enabled it, or with kubecfg otherwise. With skipGC kubecfg
+// leaves the objects no longer rendered in place for the caller to prune.
+func (r *KonfigurationReconciler) update(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string, skipGC bool) error {
+	if konfig.FeatureGateEnabled(appsv1.FeatureGateServerSideApply) {
+		return r.serverSideApply(ctx, log, konfig, path)
+	}
+	return runKubecfgUpdate(ctx, log, konfig, path, false, skipGC)
+}
+
+// serverSideApply applies the objects in the manifests at path with
+// server-side apply, CustomResourceDefinitions first, then Namespaces, other
+// cluster-scoped objects and namespaced objects. The objects are marked with
+// the garbage collection tag of the Konfiguration if it is enabled. Ownership
+// of conflicting fields is forced if every manager they conflict with is
+// kubecfg, which applied the objects before the gate was enabled, or listed in
+// the override managers of the Konfiguration.
+func (r *KonfigurationReconciler) serverSideApply(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	objs, err := decodeManifests(f)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(objs, func(i, j int) bool { return r.applyTier(objs[i]) < r.applyTier(objs[j]) })
+
+	override := map[string]bool{kubecfgFieldManager: true}
+	for _, manager := range konfig.GetOverrideManagers() {
+		override[manager] = true
+	}
+
+	log.Info("Applying manifests with server-side apply", "Count", len(objs))
+	for _, obj := range objs {
+		if r.isNamespaced(obj) {
+			obj.SetNamespace(namespaceOrDefault(obj, konfig))
+		}
+		if konfig.GCEnabled() {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[gcTagAnnotation] = konfig.GetGCTag()
+			obj.SetAnnotations(annotations)
+		}
+
+		err := r.Patch(ctx, obj, client.Apply, client.FieldOwner(serverSideApplyFieldManager))
+		if apierrors.IsConflict(err) {
+			managers := conflictManagers(err)
+			forced := len(managers) != 0
+			for _, manager := range managers {
+				forced = forced && override[manager]
+			}
+			if !forced {
+				return fmt.Errorf("failed to apply %s '%s': %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+			}
+			log.Info("Taking over conflicting fields", "Kind", obj.GetKind(), "Object", client.ObjectKeyFromObject(obj).String(), "Managers", managers)
+			err = r.Patch(ctx, obj, client.Apply, client.FieldOwner(serverSideApplyFieldManager), client.ForceOwnership)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s '%s': %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+	}
+	return nil
+}
+
+// applyTier returns the stage in which an object is applied with server-side
+// apply, the reverse of the order it is pruned in.
+func (r *KonfigurationReconciler) applyTier(obj *unstructured.Unstructured) int {
+	switch obj.GroupVersionKind().GroupKind() {
+	case crdGroupKind:
+		return 0
+	case schema.GroupKind{Kind: "Namespace"}:
+		return 1
+	}
+	if !r.isNamespaced(obj) {
+		return 2
+	}
+	return 3
+}
+
+// conflictManagers returns the field managers a server-side apply conflict is
+// with.
+func conflictManagers(err error) []string {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var managers []string
+	for _, cause := range status.Status().Details.Causes {
+		match := conflictManagerRegex.FindStringSubmatch(cause.Message)
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		managers = append(managers, match[1])
+	}
+	return managers
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+// settingsPollInterval is the interval at which the settings file is checked
+// for changes. Mounted ConfigMaps are updated by replacing a symlink, which is
+// more reliably noticed by reading the file than by watching it.
+const settingsPollInterval = 10 * time.Second
+
+// settings are the settings of the controller that can be changed while it is
+// running. The number of workers of the controller cannot be changed once it
+// started, so it is not among them.
+type settings struct {
+	observeOnly          bool
+	auditAnnotations     bool
+	networkPreconditions bool
+	budgets              budgetLimits
+	quotas               quotaLimits
+	dependencyRequeue    time.Duration
+}
+
+// settingsFromOptions returns the settings given by the flags of the
+// controller.
+func settingsFromOptions(opts *ReconcilerOptions) settings {
+	return settings{
+		observeOnly:          opts.ObserveOnly,
+		auditAnnotations:     opts.AuditAnnotations,
+		networkPreconditions: opts.NetworkPreconditions,
+		budgets: budgetLimits{
+			maxConcurrent: opts.NamespaceMaxConcurrentReconciles,
+			qps:           float32(opts.NamespaceAPIQPS),
+		},
+		quotas: quotaLimits{
+			maxObjects:       opts.NamespaceMaxObjects,
+			maxClusterScoped: opts.NamespaceMaxClusterScopedObjects,
+			maxTargets:       opts.NamespaceMaxTargets,
+		},
+		dependencyRequeue: opts.DependencyRequeueInterval,
+	}
+}
+
+// settingsStore holds the current settings of the controller. It is shared by
+// the copies of the reconciler.
+type settingsStore struct {
+	current settings
+	mu      sync.RWMutex
+}
+
+func (s *settingsStore) get() settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *settingsStore) set(current settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = current
+}
+
+// settingsFile is the format of the settings file of the controller. Fields
+// that are not set keep the value of the corresponding flag.
+type settingsFile struct {
+	ObserveOnly                      *bool            `json:"observeOnly,omitempty"`
+	AuditAnnotations                 *bool            `json:"auditAnnotations,omitempty"`
+	EnableNetworkPreconditions       *bool            `json:"enableNetworkPreconditions,omitempty"`
+	NamespaceMaxConcurrentReconciles *int             `json:"namespaceMaxConcurrentReconciles,omitempty"`
+	NamespaceAPIQPS                  *float64         `json:"namespaceAPIQPS,omitempty"`
+	NamespaceMaxObjects              *int             `json:"namespaceMaxObjects,omitempty"`
+	NamespaceMaxClusterScopedObjects *int             `json:"namespaceMaxClusterScopedObjects,omitempty"`
+	NamespaceMaxTargets              *int             `json:"namespaceMaxTargets,omitempty"`
+	RequeueDependency                *metav1.Duration `json:"requeueDependency,omitempty"`
+}
+
+// parseSettings returns the given flag settings overridden by the settings
+// file with the given contents.
+func parseSettings(data []byte, flags settings) (settings, error) {
+	var file settingsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return flags, err
+	}
+	s := flags
+	if file.ObserveOnly != nil {
+		s.observeOnly = *file.ObserveOnly
+	}
+	if file.AuditAnnotations != nil {
+		s.auditAnnotations = *file.AuditAnnotations
+	}
+	if file.EnableNetworkPreconditions != nil {
+		s.networkPreconditions = *file.EnableNetworkPreconditions
+	}
+	for _, limit := range []struct {
+		name  string
+		value *int
+		into  *int
+	}{
+		{"namespaceMaxConcurrentReconciles", file.NamespaceMaxConcurrentReconciles, &s.budgets.maxConcurrent},
+		{"namespaceMaxObjects", file.NamespaceMaxObjects, &s.quotas.maxObjects},
+		{"namespaceMaxClusterScopedObjects", file.NamespaceMaxClusterScopedObjects, &s.quotas.maxClusterScoped},
+		{"namespaceMaxTargets", file.NamespaceMaxTargets, &s.quotas.maxTargets},
+	} {
+		if limit.value == nil {
+			continue
+		}
+		if *limit.value < 0 {
+			return flags, fmt.Errorf("%s cannot be negative", limit.name)
+		}
+		*limit.into = *limit.value
+	}
+	if file.NamespaceAPIQPS != nil {
+		if *file.NamespaceAPIQPS < 0 {
+			return flags, fmt.Errorf("namespaceAPIQPS cannot be negative")
+		}
+		s.budgets.qps = float32(*file.NamespaceAPIQPS)
+	}
+	if file.RequeueDependency != nil {
+		s.dependencyRequeue = file.RequeueDependency.Duration
+	}
+	return s, nil
+}
+
+// settingsWatcher applies the settings file of the controller, and applies it
+// again whenever it changes. It runs on every replica of the controller,
+// whether or not it is the leader.
+type settingsWatcher struct {
+	log   logr.Logger
+	path  string
+	flags settings
+	store *settingsStore
+	last  []byte
+}
+
+// load applies the settings file if it changed since it was last read.
+func (w *settingsWatcher) load() error {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	if w.last != nil && bytes.Equal(data, w.last) {
+		return nil
+	}
+	reload := w.last != nil
+	w.last = data
+	s, err := parseSettings(data, w.flags)
+	if err != nil {
+		return fmt.Errorf("invalid settings file '%s': %w", w.path, err)
+	}
+	w.store.set(s)
+	if reload {
+		w.log.Info("Reloaded settings", "Path", w.path)
+	}
+	return nil
+}
+
+// Start implements manager.Runnable. Invalid changes to the settings file are
+// logged once, and the previous settings are kept.
+func (w *settingsWatcher) Start(ctx context.Context) error {
+	wait.Until(func() {
+		if err := w.load(); err != nil {
+			w.log.Error(err, "Failed to reload settings")
+		}
+	}, settingsPollInterval, ctx.Done())
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (w *settingsWatcher) NeedLeaderElection() bool { return false }
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	flags := settings{
+		auditAnnotations:  true,
+		budgets:           budgetLimits{maxConcurrent: 2},
+		dependencyRequeue: 30 * time.Second,
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    settings
+		wantErr bool
+	}{
+		{name: "empty file", want: flags},
+		{
+			name: "overrides",
+			data: "observeOnly: true\nauditAnnotations: false\nenableNetworkPreconditions: true\nnamespaceMaxConcurrentReconciles: 5\n" +
+				"namespaceAPIQPS: 2.5\nnamespaceMaxObjects: 100\nrequeueDependency: 1m\n",
+			want: settings{
+				observeOnly:          true,
+				networkPreconditions: true,
+				budgets:              budgetLimits{maxConcurrent: 5, qps: 2.5},
+				quotas:               quotaLimits{maxObjects: 100},
+				dependencyRequeue:    time.Minute,
+			},
+		},
+		{name: "unknown field", data: "expeditedWorkers: 4\n", want: flags, wantErr: true},
+		{name: "negative limit", data: "namespaceMaxTargets: -1\n", want: flags, wantErr: true},
+		{name: "negative QPS", data: "namespaceAPIQPS: -1\n", want: flags, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSettings([]byte(tt.data), flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
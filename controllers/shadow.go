@@ -0,0 +1,198 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// The results of the comparisons of the shadow controller with the active
+// controller.
+const (
+	shadowMatched  = "matched"
+	shadowDiverged = "diverged"
+	shadowFailed   = "failed"
+)
+
+// shadowComparisonsTotal counts the comparisons of the manifests rendered by
+// the shadow controller with those applied by the active controller, by
+// result.
+var shadowComparisonsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubecfg_shadow_comparisons_total",
+	Help: "Total number of comparisons of the shadow controller with the active controller by result.",
+}, []string{"result"})
+
+func init() {
+	metrics.Registry.MustRegister(shadowComparisonsTotal)
+}
+
+// shadowReconciler reconciles Konfigurations as the shadow of the active
+// controller, such as a new version of the controller canaried before an
+// upgrade. It renders the manifests of the selected Konfigurations at the
+// revision the active controller applied last, and compares them with the
+// inventory it recorded, without writing to the cluster or to the status of
+// the Konfigurations. Divergences are reported as events and metrics.
+// Konfigurations are compared whenever the active controller applies a new
+// revision or spec, and also at the given interval if it is not zero.
+type shadowReconciler struct {
+	*KonfigurationReconciler
+	selector labels.Selector
+	interval time.Duration
+}
+
+// Reconcile compares the manifests the shadow controller renders for the
+// Konfiguration with those the active controller applied.
+func (r shadowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx).WithValues("shadow", true)
+
+	konfig := &appsv1.Konfiguration{}
+	if err := r.Get(ctx, req.NamespacedName, konfig); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !r.selector.Matches(labels.Set(konfig.GetLabels())) || !konfig.GetDeletionTimestamp().IsZero() || konfig.IsSuspended() {
+		return ctrl.Result{}, nil
+	}
+
+	// Konfigurations whose sources the controller derives or tears down, or
+	// applying to other clusters, cannot be rendered without writing to the
+	// cluster.
+	if konfig.HasTargets() || konfig.UsesDerivedSource() || konfig.PreviewEnabled() {
+		reqLogger.Info("Konfiguration cannot be shadowed, skipping")
+		return ctrl.Result{}, nil
+	}
+
+	// Only compare once the active controller applied the current spec
+	if konfig.Status.ObservedGeneration != konfig.GetGeneration() ||
+		!apimeta.IsStatusConditionTrue(konfig.Status.Conditions, meta.ReadyCondition) {
+		reqLogger.Info("Active controller has not applied the current spec yet, skipping")
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+	inventory, err := r.readInventory(ctx, konfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if inventory == nil {
+		reqLogger.Info("Active controller has not recorded an inventory, skipping")
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+
+	path, revision, err := r.prepareSource(ctx, reqLogger, req, konfig)
+	if err != nil {
+		reqLogger.Error(err, "Failed to prepare source")
+		return ctrl.Result{RequeueAfter: konfig.GetRetryInterval()}, nil
+	}
+	if revision != inventory.Revision {
+		reqLogger.Info("Active controller has not applied the source revision yet, skipping", "Revision", revision)
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+
+	manifests, err := r.prepareManifests(ctx, reqLogger, konfig, path, revision)
+	if err != nil {
+		r.reportShadow(reqLogger, konfig, revision, shadowFailed, fmt.Sprintf("Failed to render revision %s applied by the active controller: %s", revision, err))
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+	defer os.Remove(manifests.path)
+	if manifests.checksum == inventory.Checksum {
+		r.reportShadow(reqLogger, konfig, revision, shadowMatched, "")
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+
+	changed, err := runKubecfgDiff(ctx, reqLogger, konfig, manifests.path)
+	if err != nil {
+		r.reportShadow(reqLogger, konfig, revision, shadowFailed, fmt.Sprintf("Failed to compare revision %s with the cluster: %s", revision, err))
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+	msg := fmt.Sprintf("Rendered other manifests for revision %s than the active controller applied", revision)
+	if diff := inventoryDiff(inventory.Entries, manifests.inventory); diff != "" {
+		msg += ", " + diff
+	}
+	if changed {
+		msg += ", applying them would change the cluster"
+	} else {
+		msg += ", applying them would not change the cluster"
+	}
+	r.reportShadow(reqLogger, konfig, revision, shadowDiverged, msg)
+	return ctrl.Result{RequeueAfter: r.interval}, nil
+}
+
+// reportShadow records the result of a comparison with the active controller,
+// recording divergences and failures as events on the Konfiguration.
+func (r shadowReconciler) reportShadow(reqLogger logr.Logger, konfig *appsv1.Konfiguration, revision, result, msg string) {
+	addWithExemplar(shadowComparisonsTotal.WithLabelValues(result), runExemplar(konfig, revision))
+	if result == shadowMatched {
+		reqLogger.Info("Rendered the manifests the active controller applied", "Revision", revision)
+		return
+	}
+	reqLogger.Info("Diverged from the active controller", "Revision", revision, "Result", result, "Message", msg)
+	r.recorder.Event(konfig, corev1.EventTypeWarning, appsv1.ShadowDivergedReason, msg)
+}
+
+// inventoryDiff describes the objects added to and removed from the applied
+// inventory by the rendered one, or returns an empty string if they list the
+// same objects.
+func inventoryDiff(applied, rendered []appsv1.InventoryEntry) string {
+	appliedSet := make(map[appsv1.InventoryEntry]bool, len(applied))
+	for _, entry := range applied {
+		appliedSet[entry] = true
+	}
+	renderedSet := make(map[appsv1.InventoryEntry]bool, len(rendered))
+	var added, removed []string
+	for _, entry := range rendered {
+		renderedSet[entry] = true
+		if !appliedSet[entry] {
+			added = append(added, entryName(entry))
+		}
+	}
+	for _, entry := range applied {
+		if !renderedSet[entry] {
+			removed = append(removed, entryName(entry))
+		}
+	}
+	var parts []string
+	if len(added) != 0 {
+		parts = append(parts, fmt.Sprintf("adding [%s]", strings.Join(added, ", ")))
+	}
+	if len(removed) != 0 {
+		parts = append(parts, fmt.Sprintf("removing [%s]", strings.Join(removed, ", ")))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// entryName returns the kind, namespace and name of the inventory entry.
+func entryName(entry appsv1.InventoryEntry) string {
+	if entry.Namespace == "" {
+		return fmt.Sprintf("%s '%s'", entry.Kind, entry.Name)
+	}
+	return fmt.Sprintf("%s '%s/%s'", entry.Kind, entry.Namespace, entry.Name)
+}
@@ -14,10 +14,15 @@ limitations under the License.
 package controllers
 
 import (
+	"reflect"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
 )
 
 type SourceRevisionChangePredicate struct {
@@ -50,3 +55,59 @@ func (SourceRevisionChangePredicate) Update(e event.UpdateEvent) bool {
 
 	return false
 }
+
+// ReadyRevisionChangePredicate passes updates of Konfigurations that are Ready
+// at a newly applied revision.
+type ReadyRevisionChangePredicate struct {
+	predicate.Funcs
+}
+
+func (ReadyRevisionChangePredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	oldKonfig, ok := e.ObjectOld.(*appsv1.Konfiguration)
+	if !ok {
+		return false
+	}
+
+	newKonfig, ok := e.ObjectNew.(*appsv1.Konfiguration)
+	if !ok {
+		return false
+	}
+
+	if !apimeta.IsStatusConditionTrue(newKonfig.Status.Conditions, meta.ReadyCondition) {
+		return false
+	}
+
+	return oldKonfig.Status.LastAppliedRevision != newKonfig.Status.LastAppliedRevision
+}
+
+// ReadyStatusChangePredicate passes updates of Konfigurations that became
+// ready or stopped being ready, or whose labels changed.
+type ReadyStatusChangePredicate struct {
+	predicate.Funcs
+}
+
+func (ReadyStatusChangePredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	oldKonfig, ok := e.ObjectOld.(*appsv1.Konfiguration)
+	if !ok {
+		return false
+	}
+
+	newKonfig, ok := e.ObjectNew.(*appsv1.Konfiguration)
+	if !ok {
+		return false
+	}
+
+	if isReady(oldKonfig) != isReady(newKonfig) {
+		return true
+	}
+
+	return !reflect.DeepEqual(oldKonfig.GetLabels(), newKonfig.GetLabels())
+}
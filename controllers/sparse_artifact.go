@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"path"
+	"strings"
+)
+
+// sparseArtifactKey returns the key identifying the given subset of an
+// artifact in the artifact cache.
+func sparseArtifactKey(artifact string, paths []string) string {
+	if len(paths) == 0 {
+		return artifact
+	}
+	return artifact + "?paths=" + strings.Join(paths, ",")
+}
+
+// filterArtifact returns a gzip-compressed tarball holding only the entries of
+// the artifact read from r that are at or below any of the given paths. The
+// artifact is filtered as it is read, and the result is not compressed again,
+// so skipped entries cost neither disk space nor compression time.
+func filterArtifact(r io.Reader, paths []string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(copySparse(pw, r, paths))
+	}()
+	return pr
+}
+
+func copySparse(w io.Writer, r io.Reader, paths []string) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	zw, _ := gzip.NewWriterLevel(w, gzip.NoCompression)
+	tr, tw := tar.NewReader(zr), tar.NewWriter(zw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if !inSparsePaths(hdr.Name, paths) {
+			continue
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// inSparsePaths returns true if the tarball entry with the given name is at or
+// below any of the given paths.
+func inSparsePaths(name string, paths []string) bool {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	for _, p := range paths {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
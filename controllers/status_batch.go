@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// statusBatchInterval is the minimum interval between the status patches of a
+// Konfiguration that only report progress.
+const statusBatchInterval = 10 * time.Second
+
+// statusBatcher batches the status patches of Konfigurations that only report
+// progress, such as a new message of a condition, so frequent progress updates
+// result in at most one write per interval for each Konfiguration. A deferred
+// patch is written once the interval has passed, unless a later patch of the
+// Konfiguration supersedes it.
+type statusBatcher struct {
+	interval time.Duration
+	mu       sync.Mutex
+	written  map[types.NamespacedName]time.Time
+	pending  map[types.NamespacedName]*appsv1.KonfigurationStatus
+}
+
+func newStatusBatcher(interval time.Duration) *statusBatcher {
+	return &statusBatcher{
+		interval: interval,
+		written:  make(map[types.NamespacedName]time.Time),
+		pending:  make(map[types.NamespacedName]*appsv1.KonfigurationStatus),
+	}
+}
+
+// deferPatch keeps the status as the pending patch of the Konfiguration of the
+// given key and returns true if its status was written less than the interval
+// ago. flush is called once the interval has passed, unless a patch was
+// pending already.
+func (b *statusBatcher) deferPatch(key types.NamespacedName, status appsv1.KonfigurationStatus, flush func()) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	last, ok := b.written[key]
+	if !ok || time.Since(last) >= b.interval {
+		return false
+	}
+	_, scheduled := b.pending[key]
+	b.pending[key] = &status
+	if !scheduled {
+		time.AfterFunc(b.interval-time.Since(last), flush)
+	}
+	return true
+}
+
+// take removes and returns the pending patch of the Konfiguration of the given
+// key, if any.
+func (b *statusBatcher) take(key types.NamespacedName) *appsv1.KonfigurationStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := b.pending[key]
+	delete(b.pending, key)
+	return status
+}
+
+// patched records that the status of the Konfiguration of the given key was
+// written, dropping the pending patch it supersedes.
+func (b *statusBatcher) patched(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.written[key] = time.Now()
+	delete(b.pending, key)
+}
+
+// forget drops what is known of the Konfiguration of the given key, once it is
+// deleted.
+func (b *statusBatcher) forget(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.written, key)
+	delete(b.pending, key)
+}
+
+// progressOnly returns true if the statuses only differ in the messages of
+// their conditions.
+func progressOnly(old, new appsv1.KonfigurationStatus) bool {
+	if len(old.Conditions) != len(new.Conditions) {
+		return false
+	}
+	o, n := old.DeepCopy(), new.DeepCopy()
+	for i := range o.Conditions {
+		o.Conditions[i].Message = ""
+		n.Conditions[i].Message = ""
+	}
+	return apiequality.Semantic.DeepEqual(o, n)
+}
+
+// flushStatus writes the pending status patch of the Konfiguration of the
+// given key, if it was not superseded, once no reconciliation of it is running.
+func (r *KonfigurationReconciler) flushStatus(key types.NamespacedName) {
+	unlock := r.locks.Lock(key.String())
+	defer unlock()
+	status := r.batches.take(key)
+	if status == nil {
+		return
+	}
+	ctx := context.Background()
+	var konfig appsv1.Konfiguration
+	if err := r.Get(ctx, key, &konfig); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Log.Error(err, "Unable to update status", "konfiguration", key)
+		}
+		return
+	}
+	if err := r.writeStatus(ctx, &konfig, *status); err != nil {
+		log.Log.Error(err, "Unable to update status", "konfiguration", key)
+	}
+}
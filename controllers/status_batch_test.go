@@ -0,0 +1,99 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestProgressOnly(t *testing.T) {
+	status := func(reason, message, revision string) appsv1.KonfigurationStatus {
+		return appsv1.KonfigurationStatus{
+			LastAttemptedRevision: revision,
+			Conditions:            []metav1.Condition{{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: reason, Message: message}},
+		}
+	}
+	tests := []struct {
+		name     string
+		old, new appsv1.KonfigurationStatus
+		want     bool
+	}{
+		{name: "message", old: status(appsv1.DependencyNotReadyReason, "waiting on a", "1"), new: status(appsv1.DependencyNotReadyReason, "waiting on b", "1"), want: true},
+		{name: "reason", old: status(appsv1.DependencyNotReadyReason, "waiting", "1"), new: status(appsv1.EvaluationFailedReason, "waiting", "1")},
+		{name: "revision", old: status(appsv1.DependencyNotReadyReason, "waiting", "1"), new: status(appsv1.DependencyNotReadyReason, "waiting", "2")},
+		{name: "new condition", old: appsv1.KonfigurationStatus{LastAttemptedRevision: "1"}, new: status(appsv1.DependencyNotReadyReason, "waiting", "1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := progressOnly(tt.old, tt.new); got != tt.want {
+				t.Errorf("progressOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchStatusBatchesProgress(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, appsv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	konfig := testKonfiguration()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(konfig).Build()
+	r := &KonfigurationReconciler{Client: c, Scheme: scheme, locks: newKeyLocks(), batches: newStatusBatcher(100 * time.Millisecond)}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(konfig)}
+
+	waiting := func(message string) appsv1.KonfigurationStatus {
+		return appsv1.KonfigurationStatus{Conditions: []metav1.Condition{{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: appsv1.DependencyNotReadyReason, Message: message}}}
+	}
+	message := func() string {
+		var got appsv1.Konfiguration
+		if err := c.Get(context.TODO(), req.NamespacedName, &got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status.Conditions[0].Message
+	}
+
+	for _, msg := range []string{"waiting on a", "waiting on b", "waiting on c"} {
+		if err := r.patchStatus(context.TODO(), req, waiting(msg)); err != nil {
+			t.Fatalf("patchStatus() error = %v", err)
+		}
+	}
+	if got := message(); got != "waiting on a" {
+		t.Errorf("message = %q, want the progress written before the batch", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for message() != "waiting on c" {
+		if time.Now().After(deadline) {
+			t.Fatalf("message = %q, want the batched progress to be written", message())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
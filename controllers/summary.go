@@ -0,0 +1,138 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+const (
+	// runSucceeded and runFailed are the results of a run summary.
+	runSucceeded = "Succeeded"
+	runFailed    = "Failed"
+	// maxRunSummaryErrorSize is the maximum size of the error recorded in a
+	// run summary.
+	maxRunSummaryErrorSize = 4096
+	// maxHistory is the number of reconciliations kept in the history of a
+	// Konfiguration.
+	maxHistory = 5
+)
+
+// runSummary records the progress of a reconciliation.
+type runSummary struct {
+	appsv1.RunSummary
+}
+
+func newRunSummary(revision string, start time.Time) *runSummary {
+	return &runSummary{appsv1.RunSummary{StartTime: metav1.NewTime(start), Revision: revision}}
+}
+
+// stage records the duration of the named stage, which started at start.
+func (s *runSummary) stage(name string, start time.Time) {
+	s.Stages = append(s.Stages, appsv1.StageDuration{
+		Name:     name,
+		Duration: metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)},
+	})
+}
+
+// finish records the result of the reconciliation, which failed with err if
+// not nil, as the last run summary of the Konfiguration, in its history and in
+// the metrics of the controller. A run with the same outcome as the last run
+// summary is only recorded in the metrics, so the status of a Konfiguration
+// whose reconciliations find nothing new to do stays unchanged.
+func (s *runSummary) finish(konfig *appsv1.Konfiguration, err error) {
+	s.Result = runSucceeded
+	if err != nil {
+		s.Result, s.Reason, s.Error = runFailed, reasonFor(err), err.Error()
+		if len(s.Error) > maxRunSummaryErrorSize {
+			s.Error = s.Error[:maxRunSummaryErrorSize] + "..."
+		}
+	}
+	s.Skipped = int32(len(konfig.Status.SkippedObjects))
+	duration := time.Since(s.StartTime.Time)
+	recordRunMetrics(konfig, &s.RunSummary, duration)
+	if last := konfig.Status.LastRunSummary; last != nil && sameOutcome(last, &s.RunSummary) {
+		return
+	}
+	konfig.Status.LastRunSummary = &s.RunSummary
+
+	record := appsv1.RunRecord{
+		StartTime: s.StartTime,
+		Revision:  s.Revision,
+		Result:    s.Result,
+		Reason:    s.Reason,
+		Duration:  metav1.Duration{Duration: duration.Round(time.Millisecond)},
+	}
+	konfig.Status.History = append([]appsv1.RunRecord{record}, konfig.Status.History...)
+	if len(konfig.Status.History) > maxHistory {
+		konfig.Status.History = konfig.Status.History[:maxHistory]
+	}
+}
+
+// sameOutcome returns true if the run summaries differ at most in their start
+// times and stage durations.
+func sameOutcome(a, b *appsv1.RunSummary) bool {
+	x, y := *a, *b
+	x.StartTime, y.StartTime = metav1.Time{}, metav1.Time{}
+	x.Stages, y.Stages = nil, nil
+	return apiequality.Semantic.DeepEqual(x, y)
+}
+
+// countChanges counts the objects of the manifests created, updated, deleted
+// and left unchanged, by comparing them with the previous inventory of the
+// Konfiguration. It must be called before the inventory is replaced. Objects
+// are counted as unchanged if the cluster was not updated, and as updated if
+// their digests cannot be compared.
+func (r *KonfigurationReconciler) countChanges(ctx context.Context, konfig *appsv1.Konfiguration, state *pipelineState, s *runSummary) error {
+	manifests := state.manifests
+	if !state.updated {
+		s.Unchanged = int32(len(manifests.inventory))
+		return nil
+	}
+	previous, err := r.readInventory(ctx, konfig)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		s.Created = int32(len(manifests.inventory))
+		return nil
+	}
+	comparable := len(previous.Digests) == len(previous.Entries) && len(manifests.digests) == len(manifests.inventory)
+	applied := make(map[appsv1.InventoryEntry]int, len(previous.Entries))
+	for i, entry := range previous.Entries {
+		applied[entry] = i
+	}
+	for i, entry := range manifests.inventory {
+		j, ok := applied[entry]
+		switch {
+		case !ok:
+			s.Created++
+		case comparable && previous.Digests[j] == manifests.digests[i]:
+			s.Unchanged++
+		default:
+			s.Updated++
+		}
+	}
+	s.Deleted = int32(len(staleEntries(previous.Entries, manifests.inventory)))
+	return nil
+}
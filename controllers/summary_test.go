@@ -0,0 +1,59 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFinishRunSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		revision    string
+		unchanged   int32
+		err         error
+		wantHistory int
+	}{
+		{name: "same outcome", revision: "main/abc", unchanged: 2, wantHistory: 1},
+		{name: "new revision", revision: "main/def", unchanged: 2, wantHistory: 2},
+		{name: "changed objects", revision: "main/abc", unchanged: 1, wantHistory: 2},
+		{name: "failure", revision: "main/abc", unchanged: 2, err: errors.New("failed"), wantHistory: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			konfig := testKonfiguration()
+			start := time.Now().Add(-time.Minute)
+			first := newRunSummary("main/abc", start)
+			first.Unchanged = 2
+			first.stage("render", start)
+			first.finish(konfig, nil)
+
+			next := newRunSummary(tt.revision, time.Now())
+			next.Unchanged = tt.unchanged
+			next.stage("render", time.Now())
+			next.finish(konfig, tt.err)
+			if got := len(konfig.Status.History); got != tt.wantHistory {
+				t.Errorf("len(History) = %d, want %d", got, tt.wantHistory)
+			}
+			if kept := konfig.Status.LastRunSummary.StartTime.Time.Equal(first.StartTime.Time); kept != (tt.wantHistory == 1) {
+				t.Errorf("LastRunSummary kept = %v, want %v", kept, tt.wantHistory == 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// targetKubeConfigKey is the key of the Secrets recording the objects applied
+// to a target holding the reference to the kubeconfig of the target.
+const targetKubeConfigKey = "kubeConfig"
+
+// targetInventoryName returns the name of the Secret recording the objects
+// applied to the target of the Konfiguration with the given name. Target names
+// that cannot be part of an object name are hashed.
+func targetInventoryName(konfig *appsv1.Konfiguration, target string) string {
+	name := fmt.Sprintf("%s-target-%s", konfig.GetName(), target)
+	if len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+	name = fmt.Sprintf("%s-target-%x", konfig.GetName(), sha256.Sum256([]byte(target)))
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength]
+	}
+	return name
+}
+
+// writeTargetInventory records the objects applied to the target of the run,
+// along with the server of the target cluster and the reference to its
+// kubeconfig, in a Secret owned by the Konfiguration, so the target can be
+// pruned once it is removed from the Konfiguration. The inventory is
+// gzip-compressed to fit large object sets.
+func (r *KonfigurationReconciler) writeTargetInventory(ctx context.Context, konfig *appsv1.Konfiguration, run *targetRun, revision string) error {
+	ref, err := json.Marshal(&run.target.KubeConfig)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&appsv1.Inventory{
+		Revision: revision,
+		Checksum: run.manifests.checksum,
+		Entries:  run.manifests.inventory,
+	})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      targetInventoryName(konfig, run.status.Name),
+			Namespace: konfig.GetNamespace(),
+			Labels:    r.inventoryLabels(konfig),
+			Annotations: map[string]string{
+				appsv1.TargetInventoryAnnotation: run.status.Name,
+				appsv1.TargetServerAnnotation:    run.server,
+			},
+		},
+		Data: map[string][]byte{
+			appsv1.InventoryCompressedKey: buf.Bytes(),
+			targetKubeConfigKey:           ref,
+		},
+	}
+	if err := controllerutil.SetControllerReference(konfig, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	secrets := r.clientset.CoreV1().Secrets(secret.GetNamespace())
+	existing, err := secrets.Get(ctx, secret.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	case err == nil:
+		if target, ok := existing.GetAnnotations()[appsv1.TargetInventoryAnnotation]; !ok || target != run.status.Name {
+			return fmt.Errorf("Secret '%s/%s' does not record the objects of this target", existing.GetNamespace(), existing.GetName())
+		}
+		existing.SetLabels(secret.GetLabels())
+		existing.SetAnnotations(secret.GetAnnotations())
+		existing.SetOwnerReferences(secret.GetOwnerReferences())
+		existing.Data = secret.Data
+		_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write target inventory '%s/%s': %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	return nil
+}
+
+// pruneRemovedTargets deletes the objects applied to the targets that were
+// removed from the Konfiguration, from the inventories recorded for them, and
+// then removes the inventories. The objects just applied to the cluster of a
+// current target, given by server, are left in place, since all the targets
+// share the garbage collection tag of the Konfiguration, e.g. when a target is
+// renamed. Inventories are removed without pruning when the Konfiguration does
+// not prune removed targets.
+func (r *KonfigurationReconciler) pruneRemovedTargets(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, applied map[string][]appsv1.InventoryEntry) error {
+	current := make(map[string]bool, len(konfig.Spec.Targets))
+	for _, target := range konfig.Spec.Targets {
+		current[target.Name] = true
+	}
+	key := client.ObjectKeyFromObject(konfig)
+	for _, selector := range r.ownership.selectors(key) {
+		var list corev1.SecretList
+		if err := r.List(ctx, &list, client.InNamespace(key.Namespace), selector); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			secret := &list.Items[i]
+			target, ok := secret.GetAnnotations()[appsv1.TargetInventoryAnnotation]
+			switch {
+			case !ok:
+				continue
+			case !konfig.PruneRemovedTargetsEnabled():
+			case current[target]:
+				continue
+			default:
+				if err := r.pruneTarget(ctx, log, konfig, target, secret, applied); err != nil {
+					return withReason(appsv1.PruneFailedReason, fmt.Errorf("target '%s': %w", target, err))
+				}
+			}
+			if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removedTargetEntries returns the reference to the kubeconfig of the removed
+// target recorded in the given Secret, and the entries of its inventory that
+// are not applied to the same cluster by a current target.
+func removedTargetEntries(secret *corev1.Secret, applied map[string][]appsv1.InventoryEntry) (*appsv1.KubeConfig, []appsv1.InventoryEntry, error) {
+	ref, ok := secret.Data[targetKubeConfigKey]
+	if !ok {
+		return nil, nil, fmt.Errorf("Secret '%s/%s' records no kubeconfig", secret.GetNamespace(), secret.GetName())
+	}
+	var kubeConfig appsv1.KubeConfig
+	if err := json.Unmarshal(ref, &kubeConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode kubeconfig reference '%s/%s': %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(secret.Data[appsv1.InventoryCompressedKey]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress inventory '%s/%s': %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	defer zr.Close()
+	var inventory appsv1.Inventory
+	if err := json.NewDecoder(zr).Decode(&inventory); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode inventory '%s/%s': %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	return &kubeConfig, staleEntries(inventory.Entries, applied[secret.GetAnnotations()[appsv1.TargetServerAnnotation]]), nil
+}
+
+// pruneTarget deletes the objects recorded in the inventory of a removed
+// target, using the kubeconfig it referenced. The objects are deleted like
+// stale objects of the cluster the controller runs in, in tiers and with their
+// propagation policy, leaving the objects not tagged for garbage collection of
+// the Konfiguration, protected from it, paused or applied by a current target
+// in place. If the kubeconfig no longer exists, the objects are left in place.
+func (r *KonfigurationReconciler) pruneTarget(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, target string, secret *corev1.Secret, applied map[string][]appsv1.InventoryEntry) error {
+	kubeConfig, entries, err := removedTargetEntries(secret, applied)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	kubeconfig, err := kubeConfig.Fetch(ctx, r.Client, konfig.GetNamespace())
+	if apierrors.IsNotFound(err) {
+		msg := fmt.Sprintf("Leaving the objects of removed target '%s' in place, its kubeconfig no longer exists", target)
+		log.Info(msg)
+		r.recorder.Event(konfig, corev1.EventTypeWarning, appsv1.PruneFailedReason, msg)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch kubeconfig: %w", err)
+	}
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-kubeconfig-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(kubeconfig)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	cluster, err := r.targetCluster(f.Name())
+	if err != nil {
+		return err
+	}
+
+	log.Info("Pruning objects of removed target", "Target", target, "Count", len(entries))
+	return cluster.deleteInOrder(ctx, log, konfig, entries)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// testTargetInventory returns a Secret recording the given entries applied to
+// a removed target of the given server.
+func testTargetInventory(t *testing.T, server string, entries ...appsv1.InventoryEntry) *corev1.Secret {
+	data, err := json.Marshal(&appsv1.Inventory{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := json.Marshal(&appsv1.KubeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "team",
+			Name:        "app-target-old",
+			Annotations: map[string]string{appsv1.TargetInventoryAnnotation: "old", appsv1.TargetServerAnnotation: server},
+		},
+		Data: map[string][]byte{
+			appsv1.InventoryCompressedKey: buf.Bytes(),
+			targetKubeConfigKey:           ref,
+		},
+	}
+}
+
+func TestRemovedTargetEntries(t *testing.T) {
+	a := appsv1.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "a"}
+	b := appsv1.InventoryEntry{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "b"}
+	tests := []struct {
+		name    string
+		server  string
+		applied map[string][]appsv1.InventoryEntry
+		want    []appsv1.InventoryEntry
+	}{
+		{
+			name:   "no current target",
+			server: "https://a.example.com",
+			want:   []appsv1.InventoryEntry{a, b},
+		},
+		{
+			name:    "renamed target",
+			server:  "https://a.example.com",
+			applied: map[string][]appsv1.InventoryEntry{"https://a.example.com": {a, b}},
+		},
+		{
+			name:    "current target of the same cluster",
+			server:  "https://a.example.com",
+			applied: map[string][]appsv1.InventoryEntry{"https://a.example.com": {a}},
+			want:    []appsv1.InventoryEntry{b},
+		},
+		{
+			name:    "current target of another cluster",
+			server:  "https://a.example.com",
+			applied: map[string][]appsv1.InventoryEntry{"https://b.example.com": {a, b}},
+			want:    []appsv1.InventoryEntry{a, b},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := removedTargetEntries(testTargetInventory(t, tt.server, a, b), tt.applied)
+			if err != nil {
+				t.Fatalf("removedTargetEntries() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removedTargetEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovedTargetEntriesWithoutKubeConfig(t *testing.T) {
+	secret := testTargetInventory(t, "https://a.example.com")
+	delete(secret.Data, targetKubeConfigKey)
+	if _, _, err := removedTargetEntries(secret, nil); err == nil {
+		t.Error("removedTargetEntries() error = nil, want error")
+	}
+}
+
+func TestDeleteInOrder(t *testing.T) {
+	const tag = "team_app"
+	tests := []struct {
+		name    string
+		obj     *unstructured.Unstructured
+		deleted bool
+	}{
+		{
+			name:    "tagged object",
+			obj:     testObject("v1", "ConfigMap", "team", "old", tag),
+			deleted: true,
+		},
+		{
+			name: "object of another Konfiguration",
+			obj:  testObject("v1", "ConfigMap", "team", "old", "team_other"),
+		},
+		{
+			name: "protected object",
+			obj:  testObject("v1", "ConfigMap", "team", "old", tag, gcStrategyAnnotation, gcStrategyIgnore),
+		},
+		{
+			name: "paused object",
+			obj:  testObject("v1", "ConfigMap", "team", "old", tag, appsv1.PausedAnnotation, "true"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.obj)
+			konfig := testKonfiguration()
+			konfig.Spec.Timeout = &metav1.Duration{Duration: prunePollInterval}
+			entries := []appsv1.InventoryEntry{{APIVersion: "v1", Kind: "ConfigMap", Namespace: "team", Name: "old"}}
+			if err := r.deleteInOrder(context.TODO(), logr.Discard(), konfig, entries); err != nil {
+				t.Fatalf("deleteInOrder() error = %v", err)
+			}
+			if got := !exists(t, r, tt.obj); got != tt.deleted {
+				t.Errorf("deleted = %v, want %v", got, tt.deleted)
+			}
+		})
+	}
+}
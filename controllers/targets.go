@@ -0,0 +1,396 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// targetRun is the progress of reconciling a target of a Konfiguration.
+type targetRun struct {
+	target         appsv1.Target
+	konfig         *appsv1.Konfiguration
+	kubeconfig     string
+	server         string
+	manifests      *renderedManifests
+	skipped        []string
+	paused         []string
+	updateRequired bool
+	status         *appsv1.TargetStatus
+}
+
+// fail records err on the status of the target and returns it with the given
+// reason, naming the target.
+func (t *targetRun) fail(reason string, err error) error {
+	t.status.Ready = metav1.ConditionFalse
+	t.status.Message = err.Error()
+	return withReason(reason, fmt.Errorf("target '%s': %w", t.status.Name, err))
+}
+
+// reconcileTargets renders the manifests for each target of the Konfiguration
+// and applies them to the target clusters. The manifests of every target are
+// rendered and validated before any of them is applied, so an invalid render
+// or a target rejecting its manifests leaves all the targets untouched. The
+// state of each target is recorded in the status of the Konfiguration.
+//
+// The manifests are modified and checked against each target cluster the same
+// way as against the cluster of the controller, and the quotas of the
+// namespace count the objects of every target. Once every target is applied,
+// the targets removed from the Konfiguration are pruned if it enabled it.
+func (r *KonfigurationReconciler) reconcileTargets(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) error {
+	runs, inventory, cleanup, err := r.renderTargets(ctx, log, konfig, path, revision)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	// Validate the manifests against every target that needs an update
+	for _, run := range runs {
+		err := r.withRotatedCredentials(ctx, log, konfig, run, func() (err error) {
+			run.updateRequired, err = runKubecfgDiff(ctx, log, run.konfig, run.manifests.path)
+			return err
+		})
+		if err != nil {
+			return run.fail(appsv1.EvaluationFailedReason, err)
+		}
+		if !run.updateRequired {
+			continue
+		}
+		err = r.withRotatedCredentials(ctx, log, konfig, run, func() error {
+			return runKubecfgUpdate(ctx, log, run.konfig, run.manifests.path, true, false)
+		})
+		if err != nil {
+			return run.fail(appsv1.ValidationFailedReason, err)
+		}
+	}
+
+	// Apply the manifests, continuing with the other targets if one fails
+	var applyErr error
+	for _, run := range runs {
+		if run.updateRequired {
+			log.Info("Applying manifests to target", "Target", run.status.Name)
+			err := r.withRotatedCredentials(ctx, log, konfig, run, func() error {
+				return runKubecfgUpdate(ctx, log, run.konfig, run.manifests.path, false, false)
+			})
+			if err != nil {
+				if err := run.fail(appsv1.ApplyFailedReason, err); applyErr == nil {
+					applyErr = err
+				}
+				continue
+			}
+		}
+		if konfig.PruneRemovedTargetsEnabled() {
+			if err := r.writeTargetInventory(ctx, konfig, run, revision); err != nil {
+				if err := run.fail(appsv1.ApplyFailedReason, err); applyErr == nil {
+					applyErr = err
+				}
+				continue
+			}
+		}
+		run.status.Ready = metav1.ConditionTrue
+		run.status.LastAppliedRevision = revision
+		run.status.LastAppliedChecksum = run.manifests.checksum
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+	konfig.Status.InventoryCount = int32(len(inventory))
+	konfig.Status.ClusterScopedCount = int32(countClusterScoped(inventory))
+	applied := make(map[string][]appsv1.InventoryEntry, len(runs))
+	for _, run := range runs {
+		applied[run.server] = append(applied[run.server], run.manifests.inventory...)
+	}
+	return r.pruneRemovedTargets(ctx, log, konfig, applied)
+}
+
+// observeTargets renders the manifests for each target of the Konfiguration
+// like reconcileTargets and compares them with the target clusters, without
+// writing to them. It returns the names of the targets applying the manifests
+// would change.
+func (r *KonfigurationReconciler) observeTargets(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) ([]string, error) {
+	runs, _, cleanup, err := r.renderTargets(ctx, log, konfig, path, revision)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, run := range runs {
+		err := r.withRotatedCredentials(ctx, log, konfig, run, func() (err error) {
+			run.updateRequired, err = runKubecfgDiff(ctx, log, run.konfig, run.manifests.path)
+			return err
+		})
+		if err != nil {
+			return nil, run.fail(appsv1.EvaluationFailedReason, err)
+		}
+		if run.updateRequired {
+			run.status.Message = fmt.Sprintf("Applying revision %s would change the target", revision)
+			changed = append(changed, run.status.Name)
+		} else {
+			run.status.Message = fmt.Sprintf("The target matches revision %s", revision)
+		}
+	}
+	return changed, nil
+}
+
+// renderTargets renders, adapts and checks the manifests for each target of
+// the Konfiguration, returning the runs of the targets and the inventory of
+// every target. The returned function records the state of the targets in the
+// status of the Konfiguration and removes the files written for them, and
+// must be called once the runs are done with.
+func (r *KonfigurationReconciler) renderTargets(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, path, revision string) ([]*targetRun, []appsv1.InventoryEntry, func(), error) {
+	previous := make(map[string]appsv1.TargetStatus, len(konfig.Status.Targets))
+	for _, status := range konfig.Status.Targets {
+		previous[status.Name] = status
+	}
+	statuses := make([]appsv1.TargetStatus, len(konfig.Spec.Targets))
+	runs := make([]*targetRun, len(konfig.Spec.Targets))
+	for i, target := range konfig.Spec.Targets {
+		statuses[i] = previous[target.Name]
+		statuses[i].Name = target.Name
+		statuses[i].Ready = metav1.ConditionUnknown
+		statuses[i].Message = ""
+		runs[i] = &targetRun{target: target, status: &statuses[i]}
+	}
+	cleanup := func() {
+		konfig.Status.Targets = statuses
+		for _, run := range runs {
+			if run.kubeconfig != "" {
+				os.Remove(run.kubeconfig)
+			}
+			if run.manifests != nil {
+				os.Remove(run.manifests.path)
+			}
+		}
+	}
+
+	if err := r.lint(ctx, log, konfig, path); err != nil {
+		return nil, nil, cleanup, err
+	}
+
+	// Render the manifests of every target. Targets rendering with the same
+	// spec render the same objects, so they are rendered and transformed once
+	// and shared, while the objects are adapted to, checked against and
+	// written for each target cluster.
+	rendered := make(map[string][]*unstructured.Unstructured)
+	var inventory []appsv1.InventoryEntry
+	for i, target := range konfig.Spec.Targets {
+		run := runs[i]
+		tk, kubeconfig, err := r.targetKonfiguration(ctx, konfig, target)
+		if err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.ArtifactFailedReason, err)
+		}
+		run.konfig, run.kubeconfig = tk, kubeconfig
+		cluster, err := r.targetCluster(kubeconfig)
+		if err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.ArtifactFailedReason, err)
+		}
+		if run.server, err = kubeconfigServer(kubeconfig); err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.ArtifactFailedReason, err)
+		}
+
+		key, err := renderKey(tk)
+		if err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.EvaluationFailedReason, err)
+		}
+		objs, ok := rendered[key]
+		if ok {
+			log.Info("Reusing objects rendered for another target", "Target", target.Name)
+		} else {
+			if objs, err = r.renderManifests(ctx, log, tk, path, revision); err != nil {
+				return nil, nil, cleanup, run.fail(appsv1.EvaluationFailedReason, err)
+			}
+			if objs, err = r.transformManifests(ctx, log, tk, objs); err != nil {
+				return nil, nil, cleanup, run.fail(appsv1.EvaluationFailedReason, err)
+			}
+			rendered[key] = objs
+		}
+		copies := make([]*unstructured.Unstructured, len(objs))
+		for j, obj := range objs {
+			copies[j] = obj.DeepCopy()
+		}
+
+		objs, run.skipped = skipObjects(log, tk, copies)
+		if run.paused, err = cluster.adaptManifests(ctx, log, tk, objs); err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.EvaluationFailedReason, err)
+		}
+		if err := cluster.preflight(ctx, log, tk, objs); err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.ValidationFailedReason, err)
+		}
+		if run.manifests, err = r.writeManifests(tk, objs); err != nil {
+			return nil, nil, cleanup, run.fail(appsv1.EvaluationFailedReason, err)
+		}
+		run.manifests.inventory = cluster.inventoryEntries(tk, objs)
+		inventory = append(inventory, run.manifests.inventory...)
+	}
+	if err := r.checkQuota(ctx, konfig, inventory); err != nil {
+		return nil, nil, cleanup, err
+	}
+
+	konfig.Status.SkippedObjects = nil
+	konfig.Status.PausedObjects = nil
+	seen := make(map[string]bool)
+	for _, run := range runs {
+		for _, obj := range run.skipped {
+			if !seen[obj] {
+				seen[obj] = true
+				konfig.Status.SkippedObjects = append(konfig.Status.SkippedObjects, obj)
+			}
+		}
+		for _, obj := range run.paused {
+			konfig.Status.PausedObjects = append(konfig.Status.PausedObjects, fmt.Sprintf("%s on target '%s'", obj, run.status.Name))
+		}
+	}
+	return runs, inventory, cleanup, nil
+}
+
+// targetKonfiguration returns a copy of the Konfiguration that renders with
+// the variables of the target and runs kubecfg against the target cluster,
+// along with the path of the kubeconfig file written for it.
+func (r *KonfigurationReconciler) targetKonfiguration(ctx context.Context, konfig *appsv1.Konfiguration, target appsv1.Target) (*appsv1.Konfiguration, string, error) {
+	kubeconfig, err := target.KubeConfig.Fetch(ctx, r.Client, konfig.GetNamespace())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch kubeconfig: %w", err)
+	}
+	f, err := ioutil.TempFile(r.artifacts.root, konfig.GetName()+"-kubeconfig-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(kubeconfig); err != nil {
+		os.Remove(f.Name())
+		return nil, "", err
+	}
+
+	tk := konfig.DeepCopy()
+	tk.Spec.KubeConfig = nil
+	tk.Spec.PreviousManifests = false
+	tk.Spec.Variables = konfig.GetVariables().Merge(target.Variables)
+	tk.Spec.KubecfgArgs = append(tk.Spec.KubecfgArgs, "--kubeconfig", f.Name())
+	return tk, f.Name(), nil
+}
+
+// renderKey returns the key the objects rendered for the given target
+// Konfiguration are shared under: its spec, without the kubeconfig kubecfg is
+// pointed at. Rendering does not depend on the cluster, since kubecfg is
+// always given the namespace.
+func renderKey(tk *appsv1.Konfiguration) (string, error) {
+	spec := tk.Spec.DeepCopy()
+	spec.KubecfgArgs = nil
+	for i := 0; i < len(tk.Spec.KubecfgArgs); i++ {
+		if tk.Spec.KubecfgArgs[i] == "--kubeconfig" {
+			i++
+			continue
+		}
+		spec.KubecfgArgs = append(spec.KubecfgArgs, tk.Spec.KubecfgArgs[i])
+	}
+	key, err := json.Marshal(spec)
+	return string(key), err
+}
+
+// kubeconfigServer returns the server of the cluster of the given kubeconfig
+// file.
+func kubeconfigServer(kubeconfig string) (string, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return config.Host, nil
+}
+
+// targetCluster returns a copy of the reconciler whose clients talk to the
+// cluster of the given kubeconfig file, to read and check the objects applied
+// to a target.
+func (r *KonfigurationReconciler) targetCluster(kubeconfig string) (*KonfigurationReconciler, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	c, err := client.New(config, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	// The namespace scope of the controller does not apply to the target
+	rc := *r
+	rc.Client, rc.clientset = c, clientset
+	rc.namespaceScoped = false
+	return &rc, nil
+}
+
+// credentialErrors are the messages of kubecfg failures caused by expired or
+// revoked credentials.
+var credentialErrors = []string{
+	"Unauthorized",
+	"the server has asked for the client to provide credentials",
+	"certificate has expired or is not yet valid",
+	"certificate signed by unknown authority",
+}
+
+// isCredentialError returns true if the given kubecfg failure was caused by
+// the credentials of the kubeconfig, as told by what kubecfg wrote to stderr.
+func isCredentialError(err error) bool {
+	stderr := kubecfgStderr(err)
+	for _, msg := range credentialErrors {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRotatedCredentials calls fn, calling it once more if it failed because
+// of the credentials of the target and its kubeconfig changed since it was
+// fetched, e.g. because its client certificate or token were rotated in the
+// meantime. The kubeconfig file of the target is rewritten in place.
+func (r *KonfigurationReconciler) withRotatedCredentials(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, run *targetRun, fn func() error) error {
+	err := fn()
+	if err == nil || !isCredentialError(err) {
+		return err
+	}
+	kubeconfig, ferr := run.target.KubeConfig.Fetch(ctx, r.Client, konfig.GetNamespace())
+	if ferr != nil {
+		log.Error(ferr, "Failed to fetch kubeconfig again", "Target", run.status.Name)
+		return err
+	}
+	current, rerr := ioutil.ReadFile(run.kubeconfig)
+	if rerr != nil || string(current) == kubeconfig {
+		return err
+	}
+	if werr := ioutil.WriteFile(run.kubeconfig, []byte(kubeconfig), 0600); werr != nil {
+		log.Error(werr, "Failed to write kubeconfig", "Target", run.status.Name)
+		return err
+	}
+	log.Info("Kubeconfig of target changed, retrying with its new credentials", "Target", run.status.Name)
+	return fn()
+}
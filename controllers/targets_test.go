@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestRenderKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  []string
+		vars  *appsv1.Variables
+		equal bool
+	}{
+		{
+			name:  "different kubeconfigs",
+			a:     []string{"--kubeconfig", "/tmp/a"},
+			b:     []string{"--kubeconfig", "/tmp/b"},
+			equal: true,
+		},
+		{
+			name:  "kubeconfig among other arguments",
+			a:     []string{"--ext-str", "env=prod", "--kubeconfig", "/tmp/a"},
+			b:     []string{"--ext-str", "env=prod", "--kubeconfig", "/tmp/b"},
+			equal: true,
+		},
+		{
+			name: "different arguments",
+			a:    []string{"--ext-str", "env=prod", "--kubeconfig", "/tmp/a"},
+			b:    []string{"--ext-str", "env=staging", "--kubeconfig", "/tmp/a"},
+		},
+		{
+			name: "different variables of the target",
+			a:    []string{"--kubeconfig", "/tmp/a"},
+			b:    []string{"--kubeconfig", "/tmp/a"},
+			vars: &appsv1.Variables{ExtStr: map[string]string{"env": "staging"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := testKonfiguration(), testKonfiguration()
+			a.Spec.KubecfgArgs = tt.a
+			b.Spec.KubecfgArgs = tt.b
+			b.Spec.Variables = tt.vars
+			keyA, err := renderKey(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyB, err := renderKey(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if equal := keyA == keyB; equal != tt.equal {
+				t.Errorf("renderKey() equal = %v, want %v", equal, tt.equal)
+			}
+		})
+	}
+}
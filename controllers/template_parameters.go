@@ -0,0 +1,72 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// bindParameters defaults the given parameters from the schema of a
+// KonfigurationTemplate and validates them against it, the way the API server
+// handles custom resources. The parameters are returned as Jsonnet code for
+// the top level arguments of the same name.
+func bindParameters(schema *apiextensionsv1.JSONSchemaProps, params *apiextensionsv1.JSON) (map[string]string, error) {
+	values := map[string]interface{}{}
+	if params != nil && len(params.Raw) != 0 {
+		if err := json.Unmarshal(params.Raw, &values); err != nil {
+			return nil, fmt.Errorf("parameters must be an object: %w", err)
+		}
+	}
+
+	if schema != nil {
+		var internal apiextensions.JSONSchemaProps
+		if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(schema, &internal, nil); err != nil {
+			return nil, fmt.Errorf("invalid parameters schema: %w", err)
+		}
+		structural, err := structuralschema.NewStructural(&internal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters schema: %w", err)
+		}
+		defaulting.Default(values, structural)
+
+		validator, _, err := apiservervalidation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: &internal})
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters schema: %w", err)
+		}
+		if errs := apiservervalidation.ValidateCustomResource(field.NewPath("spec", "parameters"), values, validator); len(errs) != 0 {
+			return nil, errs.ToAggregate()
+		}
+	}
+
+	args := make(map[string]string, len(values))
+	for name, value := range values {
+		code, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = string(code)
+	}
+	return args, nil
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// testLogLines is the number of lines of logs recorded for each test Job.
+const testLogLines int64 = 20
+
+// testPollInterval is the interval at which test Jobs are checked for
+// completion.
+const testPollInterval = 2 * time.Second
+
+// splitTestHooks separates the Jobs labeled as tests from the other objects.
+func splitTestHooks(objs []*unstructured.Unstructured) (rest, tests []*unstructured.Unstructured) {
+	for _, obj := range objs {
+		if obj.GroupVersionKind().GroupKind() == batchv1.SchemeGroupVersion.WithKind("Job").GroupKind() &&
+			obj.GetLabels()[appsv1.TestHookLabel] == "true" {
+			tests = append(tests, obj)
+			continue
+		}
+		rest = append(rest, obj)
+	}
+	return rest, tests
+}
+
+// testJob is a test Job run for a Konfiguration.
+type testJob struct {
+	obj *unstructured.Unstructured
+	// finished is true if the Job had already finished before this run, in
+	// which case its logs were recorded previously.
+	finished bool
+}
+
+// runTestHooks runs the given test Jobs and waits for them to finish. Each Job
+// is named after a hash of its contents and the rendered manifests, so a Job
+// that already succeeded is not run again until either changes. Failed Jobs
+// are removed after their logs are recorded, so they are run again on the next
+// attempt. Test Jobs of previous runs are removed.
+func (r *KonfigurationReconciler) runTestHooks(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, tests []*unstructured.Unstructured, checksum string) error {
+	jobs := make([]*testJob, 0, len(tests))
+	keep := make(map[client.ObjectKey]struct{}, len(tests))
+	for _, test := range tests {
+		obj, err := r.newTestJob(konfig, test, checksum)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, &testJob{obj: obj})
+		keep[client.ObjectKeyFromObject(obj)] = struct{}{}
+	}
+	if err := r.cleanupTestJobs(ctx, client.ObjectKeyFromObject(konfig), keep); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		var existing batchv1.Job
+		err := r.Get(ctx, client.ObjectKeyFromObject(job.obj), &existing)
+		if err == nil {
+			job.finished = jobFinished(&existing)
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Info("Running test Job", "Job", client.ObjectKeyFromObject(job.obj))
+		if err := r.Create(ctx, job.obj); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create test Job '%s/%s': %w", job.obj.GetNamespace(), job.obj.GetName(), err)
+		}
+	}
+
+	// Wait for all the Jobs to finish
+	results := make(map[client.ObjectKey]*batchv1.Job, len(jobs))
+	err := wait.PollImmediate(testPollInterval, konfig.GetTestHooksTimeout(), func() (bool, error) {
+		for _, job := range jobs {
+			key := client.ObjectKeyFromObject(job.obj)
+			if _, ok := results[key]; ok {
+				continue
+			}
+			var current batchv1.Job
+			if err := r.Get(ctx, key, &current); err != nil {
+				// The cache may not have caught up with a new Job yet
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return false, err
+			}
+			if jobFinished(&current) {
+				results[key] = &current
+			}
+		}
+		return len(results) == len(jobs), nil
+	})
+	if err != nil && err != wait.ErrWaitTimeout {
+		return err
+	}
+
+	var failed []string
+	for _, job := range jobs {
+		key := client.ObjectKeyFromObject(job.obj)
+		result, ok := results[key]
+		if !ok {
+			failed = append(failed, fmt.Sprintf("%s (timed out)", key))
+			continue
+		}
+		succeeded := jobSucceeded(result)
+		if !job.finished {
+			r.recordTestLogs(ctx, log, konfig, result, succeeded)
+		}
+		if succeeded {
+			continue
+		}
+		failed = append(failed, key.String())
+		if err := r.Delete(ctx, result, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to remove failed test Job", "Job", key)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("test Jobs did not succeed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// newTestJob returns the Job to create for the given test of the Konfiguration.
+func (r *KonfigurationReconciler) newTestJob(konfig *appsv1.Konfiguration, test *unstructured.Unstructured, checksum string) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(test.Object)
+	if err != nil {
+		return nil, err
+	}
+	hash := fmt.Sprintf("%x", sha1.Sum(append([]byte(checksum), data...)))[:10]
+
+	obj := test.DeepCopy()
+	name := test.GetName()
+	if len(name) > 52 {
+		name = name[:52]
+	}
+	obj.SetName(fmt.Sprintf("%s-%s", name, hash))
+	obj.SetNamespace(namespaceOrDefault(test, konfig))
+	labels := obj.GetLabels()
+	r.ownership.set(labels, client.ObjectKeyFromObject(konfig))
+	obj.SetLabels(labels)
+	return obj, nil
+}
+
+// cleanupTestJobs removes the test Jobs created on behalf of the given
+// Konfiguration, except those in keep.
+func (r *KonfigurationReconciler) cleanupTestJobs(ctx context.Context, key client.ObjectKey, keep map[client.ObjectKey]struct{}) error {
+	for _, selector := range r.ownership.selectors(key) {
+		selector[appsv1.TestHookLabel] = "true"
+		var list batchv1.JobList
+		if err := r.List(ctx, &list, selector); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			if _, ok := keep[client.ObjectKeyFromObject(&list.Items[i])]; ok {
+				continue
+			}
+			if err := r.Delete(ctx, &list.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordTestLogs records the tail of the logs of the pods of the given Job as
+// an event on the Konfiguration.
+func (r *KonfigurationReconciler) recordTestLogs(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, job *batchv1.Job, succeeded bool) {
+	eventType, reason := corev1.EventTypeNormal, "TestSucceeded"
+	if !succeeded {
+		eventType, reason = corev1.EventTypeWarning, appsv1.TestFailedReason
+	}
+	logs := r.jobLogs(ctx, log, job)
+	if r.recorder != nil {
+		r.recorder.Eventf(konfig, eventType, reason, "Test Job '%s/%s' finished%s", job.GetNamespace(), job.GetName(), logs)
+	}
+}
+
+// jobLogs returns the tail of the logs of the pods of the given Job.
+func (r *KonfigurationReconciler) jobLogs(ctx context.Context, log logr.Logger, job *batchv1.Job) string {
+	// Pods are listed directly rather than through the cache, to avoid watching
+	// every pod in the cluster.
+	pods, err := r.clientset.CoreV1().Pods(job.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + job.GetName(),
+	})
+	if err != nil {
+		log.Error(err, "Failed to list pods of Job", "Job", client.ObjectKeyFromObject(job))
+		return ""
+	}
+	var logs strings.Builder
+	for _, pod := range pods.Items {
+		tail := testLogLines
+		out, err := r.clientset.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), &corev1.PodLogOptions{TailLines: &tail}).DoRaw(ctx)
+		if err != nil {
+			log.Error(err, "Failed to fetch logs of pod", "Pod", client.ObjectKeyFromObject(&pod))
+			continue
+		}
+		fmt.Fprintf(&logs, "\n--- %s ---\n%s", pod.GetName(), strings.TrimSpace(string(out)))
+	}
+	return logs.String()
+}
+
+// jobFinished returns true if the Job completed or failed.
+func jobFinished(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// jobSucceeded returns true if the Job completed.
+func jobSucceeded(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobComplete && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// transformer mutates the rendered objects of a Konfiguration before they are
+// applied.
+type transformer interface {
+	Transform(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error)
+}
+
+// transformerFunc adapts a function to a transformer.
+type transformerFunc func(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error)
+
+func (f transformerFunc) Transform(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error) {
+	return f(ctx, log, konfig, objs, config)
+}
+
+// builtinTransformers are the transformers built into the controller. They
+// take precedence over executables of the same name.
+var builtinTransformers = map[string]transformer{
+	"seccomp-runtime-default": transformerFunc(seccompRuntimeDefault),
+	"normalize":               transformerFunc(normalize),
+}
+
+// transformerNameRegex matches the names of executable transformers, which
+// cannot hold path separators nor refer to parent directories.
+var transformerNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// transformManifests runs the transformers of the Konfiguration over the
+// rendered objects in order.
+func (r *KonfigurationReconciler) transformManifests(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	for _, spec := range konfig.Spec.Transformers {
+		t, err := r.getTransformer(spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Transforming manifests", "Transformer", spec.Name)
+		if objs, err = t.Transform(ctx, log, konfig, objs, spec.Config); err != nil {
+			return nil, fmt.Errorf("transformer '%s' failed: %w", spec.Name, err)
+		}
+	}
+	return objs, nil
+}
+
+// getTransformer returns the built-in transformer with the given name, or the
+// executable of that name in the transformer directory. Transformers are run
+// as executables rather than loaded as WASM modules or Go plugins, so a
+// failing transformer cannot take the controller down with it.
+func (r *KonfigurationReconciler) getTransformer(name string) (transformer, error) {
+	if t, ok := builtinTransformers[name]; ok {
+		return t, nil
+	}
+	if r.transformerDir == "" {
+		return nil, fmt.Errorf("unknown transformer '%s', executable transformers are not enabled on this controller", name)
+	}
+	if !transformerNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid transformer name '%s'", name)
+	}
+	path := filepath.Join(r.transformerDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("unknown transformer '%s': %w", name, err)
+	}
+	return execTransformer(path), nil
+}
+
+// execTransformer is an executable transformer. It is passed the objects as a
+// YAML stream on stdin and its config as `--key=value` arguments, and writes
+// the transformed objects to stdout.
+type execTransformer string
+
+func (t execTransformer) Transform(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error) {
+	var in bytes.Buffer
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		in.WriteString("---\n")
+		in.Write(data)
+	}
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]string, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("--%s=%s", key, config[key]))
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, konfig.GetTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, string(t), args...)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdin = &in
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+
+	log.Info("Running transformer", "Command", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w, stderr: %s", err, sanitizeStderr(&stderrBuf))
+	}
+	return decodeManifests(&stdoutBuf)
+}
+
+// podSpecPaths are the paths of the pod specs of the kinds that hold one.
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// seccompRuntimeDefault sets the RuntimeDefault seccomp profile on the pods of
+// the rendered objects that do not set a seccomp profile.
+func seccompRuntimeDefault(ctx context.Context, log logr.Logger, konfig *appsv1.Konfiguration, objs []*unstructured.Unstructured, config map[string]string) ([]*unstructured.Unstructured, error) {
+	for _, obj := range objs {
+		path, ok := podSpecPaths[obj.GetKind()]
+		if !ok {
+			continue
+		}
+		profilePath := append(append([]string{}, path...), "securityContext", "seccompProfile")
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, profilePath...); found {
+			continue
+		}
+		if err := unstructured.SetNestedField(obj.Object, "RuntimeDefault", append(profilePath, "type")...); err != nil {
+			return nil, fmt.Errorf("failed to set seccomp profile of %s '%s': %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return objs, nil
+}
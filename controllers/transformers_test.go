@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetTransformer(t *testing.T) {
+	root, err := ioutil.TempDir("", "transformers-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "transformers")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "label"), filepath.Join(root, "outside")} {
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\ncat\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "normalize"},
+		{name: "label"},
+		{name: "missing", wantErr: true},
+		{name: "../outside", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "sub/label", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &KonfigurationReconciler{transformerDir: dir}
+			if _, err := r.getTransformer(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("getTransformer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
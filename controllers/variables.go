@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// missingVariableRegex matches the errors of Jsonnet for external variables and
+// top-level arguments the manifests require but were not given.
+var missingVariableRegex = regexp.MustCompile(`(?:Undefined external variable|Missing argument): ([^\s,]+)`)
+
+// variablesError is the failure to resolve the named external variables or
+// top-level arguments of a Konfiguration.
+type variablesError struct {
+	names []string
+	err   error
+}
+
+func (e *variablesError) Error() string { return e.err.Error() }
+
+func (e *variablesError) Unwrap() error { return e.err }
+
+// unresolvedVariables returns an error for the failure to resolve the named
+// variables, reported with the VariablesUnresolvedReason.
+func unresolvedVariables(names []string, err error) error {
+	sort.Strings(names)
+	return withReason(appsv1.VariablesUnresolvedReason, &variablesError{names: names, err: err})
+}
+
+// missingVariables returns the error of a failed evaluation as the failure to
+// resolve variables if Jsonnet reported required variables as missing, or the
+// error as is otherwise.
+func missingVariables(err error) error {
+	if err == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range missingVariableRegex.FindAllStringSubmatch(err.Error(), -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	if len(names) == 0 {
+		return err
+	}
+	return unresolvedVariables(names, err)
+}
+
+// recordVariables records on the VariablesUnresolvedCondition of the
+// Konfiguration the variables err failed to resolve, or removes the condition
+// if err is not a failure to resolve variables. It returns err.
+func recordVariables(konfig *appsv1.Konfiguration, err error) error {
+	var ve *variablesError
+	if !errors.As(err, &ve) {
+		apimeta.RemoveStatusCondition(&konfig.Status.Conditions, appsv1.VariablesUnresolvedCondition)
+		return err
+	}
+	meta.SetResourceCondition(konfig, appsv1.VariablesUnresolvedCondition, metav1.ConditionTrue, appsv1.MissingVariablesReason,
+		fmt.Sprintf("Unresolved variables [%s]: %s", strings.Join(ve.names, ", "), ve.err))
+	return err
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+func TestMissingVariables(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantNames  []string
+		wantReason string
+	}{
+		{name: "no error"},
+		{
+			name:       "evaluation error",
+			err:        errors.New("RUNTIME ERROR: Field does not exist: foo"),
+			wantReason: meta.ReconciliationFailedReason,
+		},
+		{
+			name:       "undefined external variable",
+			err:        errors.New("RUNTIME ERROR: Undefined external variable: cluster"),
+			wantNames:  []string{"cluster"},
+			wantReason: appsv1.VariablesUnresolvedReason,
+		},
+		{
+			name:       "missing top-level arguments",
+			err:        errors.New("RUNTIME ERROR: Missing argument: replicas\nRUNTIME ERROR: Missing argument: image, and more"),
+			wantNames:  []string{"image", "replicas"},
+			wantReason: appsv1.VariablesUnresolvedReason,
+		},
+		{
+			name:       "repeated variable",
+			err:        errors.New("Undefined external variable: cluster\nUndefined external variable: cluster"),
+			wantNames:  []string{"cluster"},
+			wantReason: appsv1.VariablesUnresolvedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := missingVariables(tt.err)
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("missingVariables() error = %v, want %v", err, tt.err)
+			}
+			var names []string
+			var ve *variablesError
+			if errors.As(err, &ve) {
+				names = ve.names
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+			if err != nil && reasonFor(err) != tt.wantReason {
+				t.Errorf("reason = %s, want %s", reasonFor(err), tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestResolveArtifactPathsMissingVariableFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.jsonnet": "{}"})
+	konfig := testKonfiguration()
+	konfig.Spec.Path = "main.jsonnet"
+	konfig.Spec.Variables = &appsv1.Variables{ExtStrFiles: map[string]string{"config": "config.txt"}}
+
+	_, err := resolveArtifactPaths(logr.Discard(), konfig, dir)
+	if reasonFor(err) != appsv1.VariablesUnresolvedReason {
+		t.Errorf("reason = %s, want %s", reasonFor(err), appsv1.VariablesUnresolvedReason)
+	}
+	if len(konfig.Status.Conditions) != 1 || konfig.Status.Conditions[0].Type != appsv1.VariablesUnresolvedCondition {
+		t.Errorf("conditions = %v, want %s", konfig.Status.Conditions, appsv1.VariablesUnresolvedCondition)
+	}
+}
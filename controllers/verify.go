@@ -0,0 +1,101 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// verifyPollInterval is the interval at which applied objects are read back.
+const verifyPollInterval = 2 * time.Second
+
+// verifyApplied reads back the objects of the given inventory entries from the
+// cluster, retrying until all of them are observable or the timeout of the
+// Konfiguration expires. Kinds unknown to the cluster, e.g. because their
+// API is not served yet, are retried as well.
+func verifyApplied(ctx context.Context, log logr.Logger, reader client.Reader, konfig *appsv1.Konfiguration, entries []appsv1.InventoryEntry) error {
+	pending := make(map[appsv1.InventoryEntry]struct{}, len(entries))
+	for _, entry := range entries {
+		pending[entry] = struct{}{}
+	}
+
+	err := wait.PollImmediate(verifyPollInterval, konfig.GetTimeout(), func() (bool, error) {
+		for entry := range pending {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(entry.APIVersion)
+			obj.SetKind(entry.Kind)
+			err := reader.Get(ctx, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}, obj)
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			} else if err != nil {
+				return false, err
+			}
+			delete(pending, entry)
+		}
+		return len(pending) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		missing := make([]string, 0, len(pending))
+		for entry := range pending {
+			missing = append(missing, fmt.Sprintf("%s '%s'", entry.Kind, client.ObjectKey{Namespace: entry.Namespace, Name: entry.Name}))
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("applied objects not observable after %s: %s", konfig.GetTimeout(), strings.Join(missing, ", "))
+	} else if err != nil {
+		return err
+	}
+	log.Info("Verified applied objects", "Count", len(entries))
+	return nil
+}
+
+// healthCheckEntries returns the inventory entries of the manifests to read
+// back after apply. If the Konfiguration only checks changed objects, the
+// entries whose digest matches the previous inventory are left out. All the
+// entries are returned if there is no previous inventory to compare with.
+func (r *KonfigurationReconciler) healthCheckEntries(ctx context.Context, konfig *appsv1.Konfiguration, manifests *renderedManifests) ([]appsv1.InventoryEntry, error) {
+	if konfig.GetHealthChecksScope() != appsv1.HealthChecksScopeChanged {
+		return manifests.inventory, nil
+	}
+	previous, err := r.readInventory(ctx, konfig)
+	if err != nil {
+		return manifests.inventory, err
+	}
+	changed, ok := changedObjects(previous, manifests)
+	if !ok {
+		return manifests.inventory, nil
+	}
+	var entries []appsv1.InventoryEntry
+	for i, entry := range manifests.inventory {
+		if changed[i] {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Avi Zimmerman.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
+)
+
+// jsonnetIdentifierRegex matches the names that can be used as parameters of
+// a jsonnet function.
+var jsonnetIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// tlaFlags are the kubecfg flags setting top-level arguments.
+var tlaFlags = map[string]bool{"tla-str": true, "tla-code": true, "tla-str-file": true, "tla-code-file": true}
+
+// yamlStreamEntrypoint writes an entrypoint wrapping the one at path to a new
+// directory, and returns its path and a function removing the directory. When
+// the wrapped entrypoint evaluates to a string, such as the output of
+// std.manifestYamlStream, the wrapper evaluates to the documents parsed from
+// it, since kubecfg only accepts objects and arrays of objects. Top-level
+// arguments are passed through to the wrapped entrypoint.
+func (r *KonfigurationReconciler) yamlStreamEntrypoint(konfig *appsv1.Konfiguration, path string) (string, func(), error) {
+	if httpPathRegex.MatchString(path) {
+		return "", nil, fmt.Errorf("yamlStream requires a local entrypoint, got '%s'", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, err
+	}
+	importPath, err := json.Marshal(abs)
+	if err != nil {
+		return "", nil, err
+	}
+	params := tlaNames(konfig)
+	for _, name := range params {
+		if !jsonnetIdentifierRegex.MatchString(name) {
+			return "", nil, fmt.Errorf("yamlStream requires top-level arguments to be identifiers, got '%s'", name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "local entrypoint = import %s;\n", importPath)
+	b.WriteString("local objects(out) = if std.isString(out) then std.native('parseYaml')(out) else out;\n")
+	if len(params) == 0 {
+		b.WriteString("objects(entrypoint)\n")
+	} else {
+		args := make([]string, len(params))
+		for i, name := range params {
+			args[i] = fmt.Sprintf("%s=%s", name, name)
+		}
+		fmt.Fprintf(&b, "function(%s) objects(entrypoint(%s))\n", strings.Join(params, ", "), strings.Join(args, ", "))
+	}
+
+	dir, err := ioutil.TempDir(r.artifacts.root, konfig.GetName()+"-stream-*")
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() { os.RemoveAll(dir) }
+	wrapper := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(wrapper, []byte(b.String()), 0644); err != nil {
+		remove()
+		return "", nil, err
+	}
+	return wrapper, remove, nil
+}
+
+// tlaNames returns the sorted names of the top-level arguments kubecfg is
+// given for the Konfiguration, by its variables or its kubecfg arguments.
+func tlaNames(konfig *appsv1.Konfiguration) []string {
+	seen := make(map[string]bool)
+	if vars := konfig.GetVariables(); vars != nil {
+		for _, values := range []map[string]string{vars.TLAStr, vars.TLACode, vars.TLAStrFiles, vars.TLACodeFiles} {
+			for name := range values {
+				seen[name] = true
+			}
+		}
+	}
+	args := konfig.GetKubecfgArgs()
+	for i := 0; i < len(args); i++ {
+		flag := strings.TrimLeft(args[i], "-")
+		var value string
+		if idx := strings.Index(flag, "="); idx != -1 {
+			flag, value = flag[:idx], flag[idx+1:]
+		} else if i+1 < len(args) {
+			value = args[i+1]
+		}
+		if !tlaFlags[flag] {
+			continue
+		}
+		if idx := strings.Index(value, "="); idx != -1 {
+			value = value[:idx]
+		}
+		seen[value] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
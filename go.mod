@@ -4,18 +4,26 @@ go 1.16
 
 require (
 	github.com/cyphar/filepath-securejoin v0.2.2
+	github.com/fluxcd/pkg/apis/meta v0.9.0
 	github.com/fluxcd/pkg/runtime v0.11.1
 	github.com/fluxcd/pkg/untar v0.1.0
 	github.com/fluxcd/source-controller/api v0.13.2
 	github.com/go-logr/logr v0.3.0
+	github.com/google/go-jsonnet v0.18.0
 	github.com/hashicorp/go-retryablehttp v0.7.0
 	github.com/kr/text v0.2.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/onsi/ginkgo v1.14.1
 	github.com/onsi/gomega v1.10.2
+	github.com/prometheus/client_golang v1.7.1
+	github.com/robfig/cron/v3 v3.0.1
+	go.uber.org/zap v1.16.0
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	k8s.io/api v0.20.7
+	k8s.io/apiextensions-apiserver v0.20.1
 	k8s.io/apimachinery v0.20.7
 	k8s.io/client-go v0.20.7
 	sigs.k8s.io/controller-runtime v0.8.3
+	sigs.k8s.io/structured-merge-diff/v4 v4.0.3
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -18,20 +18,29 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"go.uber.org/zap/zapcore"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
 
 	appsv1 "github.com/pelotech/kubecfg-operator/api/v1"
 	"github.com/pelotech/kubecfg-operator/controllers"
@@ -41,6 +50,8 @@ import (
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+	// version is set at build time with -ldflags.
+	version = "dev"
 )
 
 func init() {
@@ -56,6 +67,12 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var watchNamespaces string
+	var legacyOwnershipLabelDomains string
+	var eventSinkHosts string
+	var enableWebhooks bool
+	var userAgent string
+	var kustomizationFile string
 	var reconcileOpts controllers.ReconcilerOptions
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -63,34 +80,168 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&reconcileOpts.FluxEnabled, "flux-enabled", false, "Set to have the controller watch for source-controller objects")
+	flag.StringVar(&reconcileOpts.ArtifactCacheDir, "artifact-cache-dir", os.TempDir(), "The directory to extract and cache source artifacts in")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating webhook for Konfigurations. "+
+		"Requires a serving certificate in the webhook server's certificate directory")
+	flag.StringVar(&userAgent, "user-agent", "kubecfg-operator/"+version, "The user agent of the requests of the controller. The requests kubecfg makes for a Konfiguration add konfiguration=<namespace>/<name> to it")
+	flag.BoolVar(&reconcileOpts.AuditAnnotations, "audit-annotations", false, "Annotate applied objects with the Konfiguration applying them, "+
+		"so cluster audit logs can attribute changes to it")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "", "A comma-separated list of namespaces to watch for Konfigurations. Defaults to all namespaces")
+	flag.StringVar(&reconcileOpts.NamespaceSelector, "namespace-selector", "", "A label selector namespaces must match for their Konfigurations to be reconciled")
+	flag.BoolVar(&reconcileOpts.NamespaceScoped, "namespace-scoped", false, "Run with only namespace-level permissions in the watched namespaces. "+
+		"Disables KonfigurationReports, KonfigurationInstances, preview namespaces and the namespace selector")
+	flag.StringVar(&reconcileOpts.EventsAddr, "events-addr", "", "The URL of an HTTP endpoint to post reconciliation events to. "+
+		"Events are signed with HMAC-SHA256 when the EVENTS_TOKEN environment variable is set")
+	flag.StringVar(&eventSinkHosts, "event-sink-allowed-hosts", "", "A comma-separated list of hosts, with or without a port, "+
+		"the event sinks of Konfigurations may post events to. Defaults to none, disabling the event sinks of Konfigurations")
+	flag.IntVar(&reconcileOpts.ExpeditedWorkers, "expedited-workers", 1, "The number of workers reconciling requested reconciliations and new source revisions "+
+		"from a separate queue, ahead of interval-based reconciliations. Set to 0 to use a single queue")
+	flag.StringVar(&reconcileOpts.SecretProviderDir, "secret-provider-dir", "", "The directory secret provider volumes are mounted in, one directory per provider. "+
+		"Enables resolving variables from the secret providers allowed by the apps.kubecfg.io/secret-providers annotation of each namespace")
+	flag.StringVar(&reconcileOpts.SealingKeyDir, "sealing-key-dir", "", "The directory holding the tls.key and tls.crt of the keypair sealed variables "+
+		"are encrypted against, such as a mounted kubernetes.io/tls secret. Enables sealed variables")
+	flag.StringVar(&reconcileOpts.VolumeSourceDir, "volume-source-dir", "", "The directory volumes holding artifact sources are mounted in, one directory per volume. "+
+		"Enables artifact sources read from volumes")
+	flag.StringVar(&reconcileOpts.TransformerDir, "transformer-dir", "", "The directory holding executable transformers Konfigurations may run over their rendered objects. "+
+		"Built-in transformers are always available")
+	flag.BoolVar(&reconcileOpts.NetworkPreconditions, "enable-network-preconditions", false, "Enable the preconditions of Konfigurations, "+
+		"which connect to, resolve or request addresses of their choosing from the network of the controller")
+	flag.IntVar(&reconcileOpts.NamespaceMaxConcurrentReconciles, "namespace-max-concurrent-reconciles", 0, "The maximum number of Konfigurations of a namespace "+
+		"reconciled at the same time. Namespaces may override it with the apps.kubecfg.io/max-concurrent-reconciles annotation. Defaults to unlimited")
+	flag.Float64Var(&reconcileOpts.NamespaceAPIQPS, "namespace-api-qps", 0, "The maximum rate of API requests per second made by the controller while reconciling "+
+		"the Konfigurations of a namespace, including those kubecfg makes to diff and apply the manifests, but not some "+
+		"reads and writes such as those of inventories. Namespaces may override it with the "+
+		"apps.kubecfg.io/api-qps annotation. Defaults to unlimited")
+	flag.IntVar(&reconcileOpts.NamespaceMaxObjects, "namespace-max-objects", 0, "The maximum number of objects the Konfigurations of a namespace "+
+		"may manage in total. Namespaces may override it with the apps.kubecfg.io/max-objects annotation. Defaults to unlimited")
+	flag.IntVar(&reconcileOpts.NamespaceMaxClusterScopedObjects, "namespace-max-cluster-scoped-objects", 0, "The maximum number of cluster-scoped objects "+
+		"the Konfigurations of a namespace may manage in total. Namespaces may override it with the apps.kubecfg.io/max-cluster-scoped-objects annotation. "+
+		"Defaults to unlimited")
+	flag.IntVar(&reconcileOpts.NamespaceMaxTargets, "namespace-max-targets", 0, "The maximum number of target clusters the Konfigurations of a namespace "+
+		"may apply to in total. Namespaces may override it with the apps.kubecfg.io/max-targets annotation. Defaults to unlimited")
+	flag.DurationVar(&reconcileOpts.DependencyRequeueInterval, "requeue-dependency", 30*time.Second, "The interval at which Konfigurations waiting "+
+		"on their dependencies are checked again")
+	flag.BoolVar(&reconcileOpts.ObserveOnly, "observe-only", false, "Render the manifests of every Konfiguration, compare them with the cluster "+
+		"and run their health checks, but never write to the cluster")
+	flag.BoolVar(&reconcileOpts.Shadow, "shadow", false, "Run as the shadow of the active controller, such as a new version canaried before an upgrade. "+
+		"Renders the manifests of the Konfigurations matching shadow-selector at the revision the active controller applied, and reports divergences "+
+		"as events and metrics, without writing to the cluster. Runs its own leader election, and serves no webhooks")
+	flag.StringVar(&reconcileOpts.ShadowSelector, "shadow-selector", "", "A label selector Konfigurations must match to be compared in shadow mode. "+
+		"Defaults to all Konfigurations")
+	flag.DurationVar(&reconcileOpts.ShadowInterval, "shadow-interval", 0, "The interval at which Konfigurations are compared again in shadow mode, "+
+		"in addition to whenever the active controller applies them. Defaults to no periodic comparisons")
+	flag.StringVar(&reconcileOpts.SettingsFile, "settings-file", "", "A YAML file, such as a mounted ConfigMap, overriding the settings of the flags "+
+		"observe-only, audit-annotations, enable-network-preconditions, namespace-max-concurrent-reconciles, namespace-api-qps, namespace-max-objects, "+
+		"namespace-max-cluster-scoped-objects, namespace-max-targets and requeue-dependency with the fields of the same name in camel case. "+
+		"It is applied again whenever it changes. Other flags, such as expedited-workers, require a restart to change")
+	flag.StringVar(&reconcileOpts.OwnershipLabelDomain, "ownership-label-domain", appsv1.OwnershipLabelDomain, "The domain of the labels "+
+		"tracking the objects created on behalf of Konfigurations, such as their inventories, hook Jobs and preview namespaces")
+	flag.StringVar(&legacyOwnershipLabelDomains, "legacy-ownership-label-domains", "", "A comma-separated list of domains "+
+		"ownership labels were previously set under. Objects labeled under them are recognized, and relabeled under ownership-label-domain")
+	flag.StringVar(&kustomizationFile, "convert-kustomization", "", "Print a Konfiguration equivalent to the Flux Kustomization "+
+		"in the given file, or standard input if '-', and exit, to ease the migration of kustomize overlays to Jsonnet")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
+	if kustomizationFile != "" {
+		if err := convertKustomization(kustomizationFile, os.Stdin, os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	reconcileOpts.EventsToken = os.Getenv("EVENTS_TOKEN")
+	if eventSinkHosts != "" {
+		reconcileOpts.EventSinkHosts = strings.Split(eventSinkHosts, ",")
+	}
+	if legacyOwnershipLabelDomains != "" {
+		reconcileOpts.LegacyOwnershipLabelDomains = strings.Split(legacyOwnershipLabelDomains, ",")
+	}
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	// Konfigurations annotated with kubecfg.io/log-level=debug are logged with
+	// the same options at the debug level
+	reconcileOpts.DebugLogger = zap.New(zap.UseFlagOptions(&opts), zap.Level(zapcore.DebugLevel))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgrOpts := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "54bd3b09.kubecfg.io",
-	})
+	}
+	// A shadow controller runs alongside the active controller, so it must not
+	// compete for its lease, and leaves webhooks to it
+	if reconcileOpts.Shadow {
+		mgrOpts.LeaderElectionID = "54bd3b09-shadow.kubecfg.io"
+		enableWebhooks = false
+	}
+
+	if reconcileOpts.NamespaceScoped && watchNamespaces == "" {
+		setupLog.Error(nil, "--watch-namespaces is required when running namespace-scoped")
+		os.Exit(1)
+	}
+
+	// Restrict the cache to the watched namespaces, if any
+	if watchNamespaces != "" {
+		namespaces := strings.Split(watchNamespaces, ",")
+		if len(namespaces) == 1 {
+			mgrOpts.Namespace = namespaces[0]
+		} else {
+			mgrOpts.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+		}
+		setupLog.Info("Restricting manager to namespaces", "Namespaces", namespaces)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.UserAgent = userAgent
+	mgr, err := ctrl.NewManager(restConfig, mgrOpts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.KonfigurationReconciler{
+	reconciler := &controllers.KonfigurationReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(setupLog, mgr, &reconcileOpts); err != nil {
+	}
+	if err = reconciler.SetupWithManager(setupLog, mgr, &reconcileOpts); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Konfiguration")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&appsv1.Konfiguration{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Konfiguration")
+			os.Exit(1)
+		}
+		if err = reconciler.SetupRenderWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KonfigurationRender")
+			os.Exit(1)
+		}
+	}
+	// KonfigurationReports are cluster-scoped and aggregate Konfigurations
+	// across all namespaces, and KonfigurationInstances read cluster-scoped
+	// KonfigurationTemplates. Both are left to the active controller in shadow
+	// mode.
+	if !reconcileOpts.NamespaceScoped && !reconcileOpts.Shadow {
+		if err = (&controllers.KonfigurationReportReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(setupLog, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KonfigurationReport")
+			os.Exit(1)
+		}
+		if err = (&controllers.KonfigurationInstanceReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(setupLog, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KonfigurationInstance")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -108,3 +259,43 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// convertKustomization reads the Flux Kustomization in the file at path, or
+// standard input if path is "-", and writes the equivalent Konfiguration to out
+// and the settings that could not be converted to warnings.
+func convertKustomization(path string, in io.Reader, out, warnings io.Writer) error {
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &obj.Object); err != nil {
+		return fmt.Errorf("failed to decode Kustomization: %w", err)
+	}
+	konfig, warns, err := appsv1.KonfigurationFromKustomization(obj)
+	if err != nil {
+		return err
+	}
+	for _, warn := range warns {
+		fmt.Fprintf(warnings, "Warning: %s\n", warn)
+	}
+	converted, err := runtime.DefaultUnstructuredConverter.ToUnstructured(konfig)
+	if err != nil {
+		return err
+	}
+	delete(converted, "status")
+	unstructured.RemoveNestedField(converted, "metadata", "creationTimestamp")
+	if data, err = yaml.Marshal(converted); err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}